@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"time"
 
 	"security_chat_app/internal/config"
 	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/mail"
 	"security_chat_app/internal/infrastructure/router"
+	"security_chat_app/internal/infrastructure/webpush"
 	"security_chat_app/internal/usecase/chat"
 )
 
@@ -25,6 +29,53 @@ func main() {
 		log.Fatal("チャットのユースケースの実装に不備があります")
 	}
 
+	// バックグラウンド処理の起動
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 予約メッセージの配信
+	dispatcher := chat.NewScheduledMessageDispatcher(30 * time.Second)
+	go dispatcher.Start(ctx)
+
+	// 保持期間を過ぎたメッセージの削除
+	sweeper := chat.NewRetentionSweeper(5 * time.Minute)
+	go sweeper.Start(ctx)
+
+	// コンテンツフィルターのルールの読み込み
+	go chat.DefaultContentFilter.Start(ctx)
+
+	// 未読メッセージのメール通知
+	if config.Config.SMTPHost != "" {
+		mailer := mail.NewSMTPSender(
+			config.Config.SMTPHost,
+			config.Config.SMTPPort,
+			config.Config.SMTPUsername,
+			config.Config.SMTPPassword,
+			config.Config.SMTPFrom,
+		)
+		digest, err := chat.NewEmailDigestSender(mailer, config.Config.EmailDigestDelay, time.Minute, config.Config.BaseURL)
+		if err != nil {
+			log.Printf("メール通知のテンプレートの読み込みに失敗: %v", err)
+		} else {
+			go digest.Start(ctx)
+		}
+	} else {
+		log.Printf("SMTPが設定されていないため、未読メッセージのメール通知は送信しません")
+	}
+
+	// プッシュ通知
+	if config.Config.VAPIDPublicKey != "" {
+		vapid, err := webpush.ParseVAPID(config.Config.VAPIDPublicKey, config.Config.VAPIDPrivateKey, config.Config.VAPIDSubject)
+		if err != nil {
+			log.Fatalf("VAPIDの鍵の読み込みに失敗: %v", err)
+		}
+		pushClient := webpush.NewClient(vapid, webpush.NewHTTPClient(10*time.Second))
+		chat.DefaultPushNotifier = chat.NewPushNotifier(pushClient, 10)
+		go chat.DefaultPushNotifier.Start(ctx, time.Hour)
+	} else {
+		log.Printf("VAPIDの鍵が設定されていないため、プッシュ通知は送信しません")
+	}
+
 	// ルーティングの設定
 	httpRouter := router.SetupRouter(chatUsecase)
 	if httpRouter == nil {
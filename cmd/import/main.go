@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// エクスポートしたファイルのメッセージをチャットに取り込むコマンド
+//
+//	go run ./cmd/import -chat <チャットID> [-source json|slack] [-channel <チャンネル名>] [-dry-run] <ファイル>
+func main() {
+	chatID := flag.String("chat", "", "取り込み先のチャットID")
+	sourceValue := flag.String("source", "json", "ファイルの形式（json: このアプリのエクスポート, slack: Slackのエクスポート）")
+	channel := flag.String("channel", "", "取り込むSlackのチャンネル名（チャンネルが1つの場合は省略可）")
+	dryRun := flag.Bool("dry-run", false, "保存せずに取り込む予定の内容を表示する")
+	flag.Parse()
+
+	if *chatID == "" || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	source, err := domain.ParseImportSource(*sourceValue)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("ファイルの読み込みに失敗: %v", err)
+	}
+
+	// サーバーの管理者として実行するため、全ての参加者のメッセージを取り込む
+	report, err := chat.ImportChat(nil, *chatID, source, data, *channel, *dryRun)
+	if err != nil {
+		log.Fatalf("メッセージのインポートに失敗: %v", err)
+	}
+
+	out, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(out))
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"security_chat_app/internal/infrastructure/webpush"
+)
+
+// プッシュ通知に使用するVAPIDの鍵を生成するコマンド
+// 出力をconfig.local.iniの[webpush]に貼り付けて使用する（秘密鍵はリポジトリに含めない）
+//
+//	go run ./cmd/vapid
+func main() {
+	publicKey, privateKey, err := webpush.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatalf("VAPIDの鍵の生成に失敗: %v", err)
+	}
+	fmt.Println("[webpush]")
+	fmt.Println("publicKey = " + publicKey)
+	fmt.Println("privateKey = " + privateKey)
+	fmt.Println("subject = mailto:admin@example.com")
+}
@@ -3,6 +3,9 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
+	"testing"
+	"time"
 
 	utils "security_chat_app/internal/utils/log"
 
@@ -17,11 +20,27 @@ type ConfigList struct {
 	ServiceKeyPath  string
 	ProjectId       string
 	StorageBucket   string
+	// メール
+	BaseURL          string        // メールに記載するアプリのURL
+	SMTPHost         string        // SMTPサーバー（空の場合はメールを送信しない）
+	SMTPPort         string        // SMTPサーバーのポート
+	SMTPUsername     string        // SMTPの認証ユーザー名
+	SMTPPassword     string        // SMTPの認証パスワード
+	SMTPFrom         string        // 送信元のメールアドレス
+	EmailDigestDelay time.Duration // 未読メッセージをメールで通知するまでの時間
+	// プッシュ通知
+	VAPIDPublicKey  string // VAPIDの公開鍵（空の場合はプッシュ通知を送信しない）
+	VAPIDPrivateKey string // VAPIDの秘密鍵
+	VAPIDSubject    string // プッシュサービスに伝える連絡先（mailto:またはhttps:）
 }
 
 var Config ConfigList
 
 func init() {
+	// go testはパッケージのディレクトリで実行され、config.iniやサービスアカウントの鍵がないため読み込まない
+	if testing.Testing() {
+		return
+	}
 	LoadConfig()
 	utils.LoggingSettings(Config.LogFile)
 }
@@ -47,6 +66,8 @@ func LoadConfig() {
 		ServiceKeyPath:  "",
 		ProjectId:       "",
 		StorageBucket:   "",
+		SMTPPort:         "587",
+		EmailDigestDelay: 15 * time.Minute,
 	}
 
 	// config.local.iniから値を読み込む（存在する場合）
@@ -84,6 +105,40 @@ func loadConfigValues(cfg *ini.File, config *ConfigList) {
 	if storageBucket := cfg.Section("firebase").Key("storageBucket").String(); storageBucket != "" {
 		config.StorageBucket = storageBucket
 	}
+	if baseURL := cfg.Section("web").Key("baseURL").String(); baseURL != "" {
+		config.BaseURL = baseURL
+	}
+	if host := cfg.Section("smtp").Key("host").String(); host != "" {
+		config.SMTPHost = host
+	}
+	if port := cfg.Section("smtp").Key("port").String(); port != "" {
+		config.SMTPPort = port
+	}
+	if username := cfg.Section("smtp").Key("username").String(); username != "" {
+		config.SMTPUsername = username
+	}
+	if password := cfg.Section("smtp").Key("password").String(); password != "" {
+		config.SMTPPassword = password
+	}
+	if from := cfg.Section("smtp").Key("from").String(); from != "" {
+		config.SMTPFrom = from
+	}
+	if delay := cfg.Section("notification").Key("emailDigestDelay").String(); delay != "" {
+		if d, err := time.ParseDuration(delay); err == nil && d > 0 {
+			config.EmailDigestDelay = d
+		} else {
+			log.Printf("emailDigestDelayの形式が正しくありません: %s", delay)
+		}
+	}
+	if publicKey := cfg.Section("webpush").Key("publicKey").String(); publicKey != "" {
+		config.VAPIDPublicKey = publicKey
+	}
+	if privateKey := cfg.Section("webpush").Key("privateKey").String(); privateKey != "" {
+		config.VAPIDPrivateKey = privateKey
+	}
+	if subject := cfg.Section("webpush").Key("subject").String(); subject != "" {
+		config.VAPIDSubject = subject
+	}
 }
 
 // 設定値の検証
@@ -97,6 +152,17 @@ func validateConfig(config *ConfigList) {
 	if config.DefaultIconDir == "" {
 		config.DefaultIconDir = "internal/web/images/defaultIcon"
 	}
+	if config.BaseURL == "" {
+		config.BaseURL = "http://localhost:" + config.Port
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+	if config.SMTPHost != "" && config.SMTPFrom == "" {
+		log.Fatalf("エラー: SMTPを使用する場合はfromを設定してください")
+	}
+	if (config.VAPIDPublicKey != "" || config.VAPIDPrivateKey != "") &&
+		(config.VAPIDPublicKey == "" || config.VAPIDPrivateKey == "" || config.VAPIDSubject == "") {
+		log.Fatalf("エラー: プッシュ通知を使用する場合はpublicKey・privateKey・subjectを設定してください")
+	}
 
 	// ファイルの存在確認
 	if _, err := os.Stat(config.ServiceKeyPath); os.IsNotExist(err) {
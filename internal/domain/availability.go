@@ -0,0 +1,157 @@
+package domain
+
+import (
+	"errors"
+	"time"
+
+	// 実行環境にタイムゾーンのデータがない場合でもおやすみモードの時間帯を判定できるようにする
+	_ "time/tzdata"
+)
+
+// ユーザーが設定する在席状況
+type Availability string
+
+const (
+	AvailabilityOnline    Availability = "online"    // オンライン（未設定の場合も同じ）
+	AvailabilityAway      Availability = "away"      // 離席中
+	AvailabilityBusy      Availability = "busy"      // 取り込み中
+	AvailabilityDND       Availability = "dnd"       // 通知を停止中（おやすみモード）
+	AvailabilityInvisible Availability = "invisible" // オフライン表示（他のユーザーにはオフラインと表示する）
+	AvailabilityOffline   Availability = "offline"   // オフライン（他のユーザーに表示する状態で、設定はできない）
+)
+
+// おやすみモードの時間帯の時刻の形式
+const DNDTimeLayout = "15:04"
+
+// デフォルトのタイムゾーン
+const DefaultTimeZone = "Asia/Tokyo"
+
+// 在席状況に関するエラー
+var (
+	ErrInvalidAvailability = errors.New("在席状況の指定が正しくありません")
+	ErrInvalidDNDSchedule  = errors.New("おやすみモードの開始と終了の時刻を正しく入力してください")
+	ErrInvalidTimeZone     = errors.New("タイムゾーンの指定が正しくありません")
+)
+
+// 選択できる在席状況の一覧
+func Availabilities() []Availability {
+	return []Availability{AvailabilityOnline, AvailabilityAway, AvailabilityBusy, AvailabilityDND, AvailabilityInvisible}
+}
+
+// 文字列から在席状況を取得する（未設定の場合はオンライン）
+func ParseAvailability(value string) (Availability, error) {
+	if value == "" {
+		return AvailabilityOnline, nil
+	}
+	for _, a := range Availabilities() {
+		if string(a) == value {
+			return a, nil
+		}
+	}
+	return AvailabilityOnline, ErrInvalidAvailability
+}
+
+// 画面に表示する在席状況の名前
+func (a Availability) Label() string {
+	switch a {
+	case AvailabilityAway:
+		return "離席中"
+	case AvailabilityBusy:
+		return "取り込み中"
+	case AvailabilityDND:
+		return "通知を停止中"
+	case AvailabilityInvisible:
+		return "オフライン表示"
+	case AvailabilityOffline:
+		return "オフライン"
+	default:
+		return "オンライン"
+	}
+}
+
+// おやすみモードのスケジュールの構造体
+// 毎日、指定したタイムゾーンの開始時刻から終了時刻まで通知を停止する（日付をまたぐ時間帯も指定できる）
+type DNDSchedule struct {
+	Enabled  bool   // スケジュールを有効にするかどうか
+	Start    string // 開始時刻（HH:MM）
+	End      string // 終了時刻（HH:MM）
+	TimeZone string // タイムゾーン（IANAのタイムゾーン名）
+}
+
+// おやすみモードのスケジュールを検証する（無効にしている場合も入力された値は検証する）
+func ValidateDNDSchedule(s DNDSchedule) error {
+	if s.Start == "" && s.End == "" && !s.Enabled {
+		return nil
+	}
+	start, err := time.Parse(DNDTimeLayout, s.Start)
+	if err != nil {
+		return ErrInvalidDNDSchedule
+	}
+	end, err := time.Parse(DNDTimeLayout, s.End)
+	if err != nil || start.Equal(end) {
+		return ErrInvalidDNDSchedule
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil || s.TimeZone == "" {
+		return ErrInvalidTimeZone
+	}
+	return nil
+}
+
+// 指定した日時がおやすみモードの時間帯かどうか
+func (s DNDSchedule) ActiveAt(now time.Time) bool {
+	if !s.Enabled {
+		return false
+	}
+	start, err := time.Parse(DNDTimeLayout, s.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(DNDTimeLayout, s.End)
+	if err != nil {
+		return false
+	}
+	local := now.In(s.Location())
+	minutes := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+	if from < to {
+		return from <= minutes && minutes < to
+	}
+	// 22:00〜08:00のように日付をまたぐ場合
+	return minutes >= from || minutes < to
+}
+
+// スケジュールのタイムゾーン（未設定または不正な場合はデフォルトのタイムゾーン）
+// ユーザーのタイムゾーンとして、時刻の指定（/remind など）の解釈にも使用する
+func (s DNDSchedule) Location() *time.Location {
+	if s.TimeZone != "" {
+		if loc, err := time.LoadLocation(s.TimeZone); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// 他のユーザーに表示する在席状況
+// オフライン表示の場合はオフラインと表示し、おやすみモードの時間帯は通知を停止中と表示する
+func (u *User) PresenceAt(now time.Time) Availability {
+	if !u.IsOnline || u.Availability == AvailabilityInvisible {
+		return AvailabilityOffline
+	}
+	if u.Availability == "" || u.Availability == AvailabilityOnline {
+		if u.DNDSchedule.ActiveAt(now) {
+			return AvailabilityDND
+		}
+		return AvailabilityOnline
+	}
+	return u.Availability
+}
+
+// 通知（プッシュ通知・メール）を停止しているかどうか
+func (u *User) NotificationsPausedAt(now time.Time) bool {
+	return u.Availability == AvailabilityDND || u.DNDSchedule.ActiveAt(now)
+}
@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ブロックに関するエラー
+// ブロックされた側には、ブロックされていることが分からないように一般的な内容を返す
+var (
+	ErrCannotBlockSelf   = errors.New("自分自身はブロックできません")
+	ErrChatUnavailable   = errors.New("このユーザーとはチャットを開始できません")
+	ErrMessageNotAllowed = errors.New("このチャットにはメッセージを送信できません")
+)
+
+// ユーザーのブロックの構造体
+type Block struct {
+	BlockerID string    // ブロックしたユーザーのID
+	BlockedID string    // ブロックされたユーザーのID
+	CreatedAt time.Time // ブロックした日時
+}
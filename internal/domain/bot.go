@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 1人のユーザーが作成できるボットの上限数
+const MaxBotsPerUser = 5
+
+// ボットの名前の文字数の範囲
+const (
+	MinBotNameLength = 2
+	MaxBotNameLength = 20
+)
+
+// ボットのAPIトークンの接頭辞
+const BotTokenPrefix = "bot_"
+
+// ボットに関するエラー
+var (
+	ErrBotNameInvalid   = fmt.Errorf("ボットの名前は%d文字以上%d文字以下で入力してください", MinBotNameLength, MaxBotNameLength)
+	ErrBotLimitExceeded = fmt.Errorf("作成できるボットは最大%d個です", MaxBotsPerUser)
+	ErrBotNotFound      = errors.New("ボットが見つかりません")
+	ErrBotNotInChat     = errors.New("ボットはこのチャットに参加していません")
+	ErrBotAlreadyInChat = errors.New("ボットは既にこのチャットに参加しています")
+	ErrInvalidBotToken  = errors.New("APIトークンが正しくありません")
+)
+
+// ボットの構造体
+// ボットはユーザーとしても登録され、参加したチャットにメッセージを送信できる
+type Bot struct {
+	ID        string    // ボットのID（ボットのユーザーIDと同じ）
+	Name      string    // ボットの名前
+	OwnerID   string    // ボットを作成したユーザーのID
+	TokenHash string    // APIトークンのハッシュ値（トークン自体は保存しない）
+	CreatedAt time.Time // ボットの作成日時
+}
+
+// ボットの名前を検証する
+func ValidateBotName(name string) error {
+	n := utf8.RuneCountInString(strings.TrimSpace(name))
+	if n < MinBotNameLength || n > MaxBotNameLength {
+		return ErrBotNameInvalid
+	}
+	return nil
+}
@@ -1,12 +1,32 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
 	"time"
 )
 
 // メッセージの種類
 type MessageType string
 
+const (
+	MessageTypeText   MessageType = "text"   // 通常のメッセージ
+	MessageTypeSystem MessageType = "system" // システムメッセージ（ピン留めなどのイベント）
+	MessageTypePoll   MessageType = "poll"   // 投票（/poll コマンドで作成）
+
+	MessageTypeEncrypted MessageType = "encrypted" // エンドツーエンド暗号化されたメッセージ（サーバーは暗号文のみ保持する）
+)
+
+// 1つのチャットでピン留めできるメッセージの上限数
+const MaxPinnedMessages = 5
+
+// ピン留めに関するエラー
+var (
+	ErrPinLimitExceeded = fmt.Errorf("ピン留めできるメッセージは最大%d件です", MaxPinnedMessages)
+	ErrAlreadyPinned    = errors.New("このメッセージは既にピン留めされています")
+	ErrNotPinned        = errors.New("このメッセージはピン留めされていません")
+)
+
 // チャットの構造体
 type Chat struct {
 	ID        string    // チャットのID
@@ -15,8 +35,49 @@ type Chat struct {
 	CreatedAt time.Time // チャットの作成日時
 	UpdatedAt time.Time // チャットの更新日時
 	Contact   Contact   // チャットの相手
+
+	PinnedMessages []PinnedMessage // ピン留めされたメッセージ
+	Settings       ChatSettings    // ログインユーザーのチャット設定
+	UnreadCount    int             // 未読メッセージ数
+	Retention      RetentionPolicy // メッセージの保持期間
+	MentionCount   int             // ログインユーザーへの未読のメンション数
+	Draft          string          // ログインユーザーの送信前の下書き（無い場合は空文字）
+	IsBlocked      bool            // ログインユーザーがチャットの相手をブロックしているかどうか
+	Bots           []Bot           // チャットに参加しているボット
+	E2EE           bool            // エンドツーエンド暗号化が有効かどうか
+	KeyVerified    bool            // ログインユーザーが相手の暗号化の鍵（安全番号）を確認済みかどうか
+}
+
+// チャット参加者ごとの設定の構造体
+type ChatSettings struct {
+	Muted      bool      // ミュート中かどうか（通知と未読バッジを表示しない）
+	Archived   bool      // アーカイブしたかどうか
+	ArchivedAt time.Time // アーカイブした日時
+	Hidden     bool      // 非表示にしたかどうか
+	HiddenAt   time.Time // 非表示にした日時
 }
 
+// アーカイブ中かどうか（アーカイブ後に新しいメッセージが届いた場合は一覧に戻す）
+func (s ChatSettings) IsArchived(lastMessageAt time.Time) bool {
+	return s.Archived && !lastMessageAt.After(s.ArchivedAt)
+}
+
+// 非表示中かどうか（非表示後に新しいメッセージが届いた場合は一覧に戻す）
+func (s ChatSettings) IsHidden(lastMessageAt time.Time) bool {
+	return s.Hidden && !lastMessageAt.After(s.HiddenAt)
+}
+
+// チャット設定の操作
+type ChatSettingsAction string
+
+const (
+	ChatSettingsMute      ChatSettingsAction = "mute"      // ミュート
+	ChatSettingsUnmute    ChatSettingsAction = "unmute"    // ミュート解除
+	ChatSettingsArchive   ChatSettingsAction = "archive"   // アーカイブ
+	ChatSettingsUnarchive ChatSettingsAction = "unarchive" // アーカイブ解除
+	ChatSettingsHide      ChatSettingsAction = "hide"      // 非表示
+)
+
 // チャット参加者の構造体
 type ChatParticipant struct {
 	ID       string    // チャット参加者のID
@@ -28,16 +89,38 @@ type ChatParticipant struct {
 
 // メッセージの構造体
 type Message struct {
-	ID         string      // メッセージのID
-	ChatID     string      // チャットのID
-	SenderID   string      // 送信者のID
-	SenderName string      // 送信者の名前
-	Content    string      // メッセージの内容
-	MediaURL   string      // メッセージのメディアのURL
-	CreatedAt  time.Time   // メッセージの作成日時
-	IsRead     bool        // メッセージが読まれたかどうか
-	ReadBy     []string    // メッセージを読んだユーザーのID
-	ReplyTo    string      // メッセージの返信先のID
+	ID            string              // メッセージのID
+	ChatID        string              // チャットのID
+	SenderID      string              // 送信者のID
+	SenderName    string              // 送信者の名前
+	Content       string              // メッセージの内容
+	MediaURL      string              // メッセージのメディアのURL
+	CreatedAt     time.Time           // メッセージの作成日時
+	IsRead        bool                // メッセージが読まれたかどうか
+	ReadBy        []string            // メッセージを読んだユーザーのID
+	ReplyTo       string              // メッセージの返信先のID
+	Type          MessageType         // メッセージの種類
+	IsPinned      bool                // ピン留めされているかどうか
+	MediaPath     string              // メディアのStorage上のパス
+	ExpiresAt     time.Time           // 保持期間により削除される日時（無期限の場合はゼロ値）
+	Mentions      []Mention           // メッセージ内のメンション
+	LinkPreview   *LinkPreview        // メッセージ内のURLのプレビュー（取得前はnil）
+	ForwardedFrom *ForwardedFrom      // 転送元のメッセージの情報（転送したメッセージ以外はnil）
+	Poll          *Poll               // 投票の内容（投票以外のメッセージはnil）
+	IsBot         bool                // ボットが送信したメッセージかどうか
+	IsIntegration bool                // 受信Webhookから投稿されたメッセージかどうか
+	Attachments   []MessageAttachment // 受信Webhookからの投稿に付いた添付
+	Encrypted     *EncryptedEnvelope  // 暗号化されたメッセージの暗号文（暗号化されていないメッセージはnil）
+}
+
+// ピン留めされたメッセージの構造体（チャットのドキュメントに保存される）
+type PinnedMessage struct {
+	MessageID    string    // ピン留めされたメッセージのID
+	Content      string    // ピン留め時点のメッセージの内容
+	SenderName   string    // メッセージの送信者の名前
+	PinnedBy     string    // ピン留めしたユーザーのID
+	PinnedByName string    // ピン留めしたユーザーの名前
+	PinnedAt     time.Time // ピン留めした日時
 }
 
 // ビジネスロジックの為のチャットのユースケース
@@ -52,6 +135,7 @@ type ChatRepository interface {
 	AddChat(user, message string) error
 	GetChats(userID string) ([]Chat, error)
 	GetMessages(chatID string) ([]Message, error)
+	GetContacts(userID string) ([]Contact, error)
 }
 
 // チャットのコントローラー
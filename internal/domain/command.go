@@ -0,0 +1,10 @@
+package domain
+
+import "errors"
+
+// スラッシュコマンドに関するエラー
+var (
+	ErrUnknownCommand = errors.New("不明なコマンドです。/help で使えるコマンドを確認できます")
+	ErrReminderUsage  = errors.New("使い方: /remind 30m 内容（時間は 10m・2h・1d または 15:04 の形式）")
+	ErrPollUsage      = errors.New(`使い方: /poll "質問" "選択肢1" "選択肢2" ...`)
+)
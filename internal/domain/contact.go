@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 1人のユーザーが同時に送信できる承認待ちの連絡先申請の最大数
+const MaxPendingContactRequests = 50
+
+// ログインユーザーから見た相手との連絡先の関係
+type ContactStatus string
+
+const (
+	ContactStatusNone     ContactStatus = ""         // 連絡先ではない
+	ContactStatusContact  ContactStatus = "contact"  // 連絡先
+	ContactStatusSent     ContactStatus = "sent"     // 自分が申請して承認待ち
+	ContactStatusReceived ContactStatus = "received" // 相手から申請を受け取っている
+)
+
+// 表示用のラベル
+func (s ContactStatus) Label() string {
+	switch s {
+	case ContactStatusContact:
+		return "連絡先"
+	case ContactStatusSent:
+		return "申請中"
+	case ContactStatusReceived:
+		return "申請を受け取っています"
+	default:
+		return ""
+	}
+}
+
+// 連絡先に関するエラー
+// ブロックされた側には、ブロックされていることが分からないように一般的な内容を返す
+var (
+	ErrCannotAddSelfContact        = errors.New("自分自身は連絡先に追加できません")
+	ErrAlreadyContact              = errors.New("このユーザーは既に連絡先に追加されています")
+	ErrContactRequestExists        = errors.New("このユーザーには既に連絡先の申請を送信しています")
+	ErrContactRequestNotFound      = errors.New("連絡先の申請が見つかりません")
+	ErrContactRequestLimitExceeded = fmt.Errorf("承認待ちの連絡先の申請は最大%d件です", MaxPendingContactRequests)
+	ErrContactRequestUnavailable   = errors.New("このユーザーには連絡先の申請を送信できません")
+	ErrNotContact                  = errors.New("このユーザーは連絡先に追加されていません")
+	ErrContactsOnly                = errors.New("このユーザーは連絡先に追加したユーザーからのチャットのみ受け付けています")
+)
+
+// 連絡先の申請の構造体
+// 承認すると双方の連絡先に追加し、承認・拒否・取り消しのいずれでも申請は削除する
+type ContactRequest struct {
+	FromID    string    // 申請したユーザーのID
+	FromName  string    // 申請したユーザーの名前
+	ToID      string    // 申請されたユーザーのID
+	ToName    string    // 申請されたユーザーの名前
+	CreatedAt time.Time // 申請した日時
+}
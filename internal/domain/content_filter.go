@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// フィルターのルールの照合方法
+type FilterMatchType string
+
+const (
+	FilterMatchWord  FilterMatchType = "word"  // 語句を含む
+	FilterMatchRegex FilterMatchType = "regex" // 正規表現に一致する
+)
+
+// 照合方法の表示名
+func (t FilterMatchType) Label() string {
+	switch t {
+	case FilterMatchWord:
+		return "語句"
+	case FilterMatchRegex:
+		return "正規表現"
+	default:
+		return string(t)
+	}
+}
+
+// ルールに一致した場合の処理
+type FilterAction string
+
+const (
+	FilterActionMask   FilterAction = "mask"   // 一致した部分を伏せ字にして送信する
+	FilterActionReject FilterAction = "reject" // 送信を拒否する
+	FilterActionFlag   FilterAction = "flag"   // そのまま送信し、管理者の確認待ちにする
+)
+
+// 選択できる処理（表示順）
+func FilterActions() []FilterAction {
+	return []FilterAction{FilterActionMask, FilterActionReject, FilterActionFlag}
+}
+
+// 処理の表示名
+func (a FilterAction) Label() string {
+	switch a {
+	case FilterActionMask:
+		return "伏せ字にする"
+	case FilterActionReject:
+		return "送信を拒否する"
+	case FilterActionFlag:
+		return "管理者に報告する"
+	default:
+		return string(a)
+	}
+}
+
+// ルールのパターンの最大文字数
+const MaxFilterPatternLength = 200
+
+// コンテンツフィルターに関するエラー
+var (
+	ErrFilterPatternEmpty     = errors.New("語句またはパターンを入力してください")
+	ErrFilterPatternTooLong   = fmt.Errorf("語句またはパターンは%d文字以内で入力してください", MaxFilterPatternLength)
+	ErrInvalidFilterPattern   = errors.New("正規表現の形式が正しくありません")
+	ErrInvalidFilterMatchType = errors.New("照合方法が正しくありません")
+	ErrInvalidFilterAction    = errors.New("一致した場合の処理が正しくありません")
+	ErrFilterRuleNotFound     = errors.New("ルールが見つかりません")
+	ErrContentRejected        = errors.New("送信できない表現が含まれています")
+)
+
+// コンテンツフィルターのルールの構造体
+// 照合は全角・半角、大文字・小文字、カタカナ・ひらがなを区別せずに行う
+type FilterRule struct {
+	ID        string          // ルールのID
+	Pattern   string          // 語句または正規表現
+	MatchType FilterMatchType // 照合方法
+	Action    FilterAction    // 一致した場合の処理
+	Note      string          // 管理者向けのメモ
+	Enabled   bool            // 有効かどうか
+	CreatedBy string          // ルールを追加した管理者のID
+	CreatedAt time.Time       // ルールの追加日時
+	UpdatedAt time.Time       // ルールの更新日時
+}
+
+// フィルターのルールを検証する
+func ValidateFilterRule(pattern string, matchType FilterMatchType, action FilterAction) error {
+	if strings.TrimSpace(pattern) == "" {
+		return ErrFilterPatternEmpty
+	}
+	if utf8.RuneCountInString(pattern) > MaxFilterPatternLength {
+		return ErrFilterPatternTooLong
+	}
+	switch matchType {
+	case FilterMatchWord:
+	case FilterMatchRegex:
+		if _, err := regexp.Compile(pattern); err != nil {
+			return ErrInvalidFilterPattern
+		}
+	default:
+		return ErrInvalidFilterMatchType
+	}
+	switch action {
+	case FilterActionMask, FilterActionReject, FilterActionFlag:
+		return nil
+	default:
+		return ErrInvalidFilterAction
+	}
+}
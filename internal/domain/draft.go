@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// 下書きとして保存できる最大文字数
+const MaxDraftLength = 10000
+
+// 下書きに関するエラー
+var ErrDraftTooLong = fmt.Errorf("下書きは%d文字以内で入力してください", MaxDraftLength)
+
+// 送信前のメッセージの下書きの構造体（ユーザーとチャットごとに1件）
+type Draft struct {
+	ChatID    string    // 下書きを書いているチャットのID
+	UserID    string    // 下書きを書いたユーザーのID
+	Content   string    // 下書きの内容
+	UpdatedAt time.Time // 最後に保存した日時
+}
+
+// 下書きの内容を検証する
+func ValidateDraft(content string) error {
+	if utf8.RuneCountInString(content) > MaxDraftLength {
+		return ErrDraftTooLong
+	}
+	return nil
+}
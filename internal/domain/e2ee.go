@@ -0,0 +1,204 @@
+package domain
+
+import (
+	"crypto/ecdh"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// エンドツーエンド暗号化の方式のバージョン
+// v1: 双方の公開鍵からECDH（P-256）で共有した値をHKDF-SHA256で鍵にし、AES-256-GCMで暗号化する
+// 暗号化と復号はクライアントで行い、サーバーは暗号文の形式だけを検証する
+const E2EEVersion = 1
+
+// AES-GCMのノンスと認証タグのバイト数
+const (
+	E2EENonceSize = 12
+	E2EETagSize   = 16
+)
+
+// 暗号文の最大バイト数（最大文字数のメッセージをUTF-8で暗号化した場合の大きさ）
+const MaxEncryptedMessageSize = MaxMessageLength*4 + E2EETagSize
+
+// 鍵のIDの長さ（公開鍵のSHA-256の先頭16バイトを16進数にしたもの）
+const identityKeyIDLength = 32
+
+// エンドツーエンド暗号化に関するエラー
+var (
+	ErrIdentityKeyInvalid         = errors.New("公開鍵の形式が正しくありません")
+	ErrIdentityKeyNotFound        = errors.New("公開鍵が登録されていません")
+	ErrE2EEUnavailable            = errors.New("相手が公開鍵を登録していないため、暗号化を有効にできません")
+	ErrE2EENotSupported           = errors.New("暗号化はボットが参加していない1対1のチャットでのみ利用できます")
+	ErrE2EEAlreadyEnabled         = errors.New("このチャットは既に暗号化されています")
+	ErrPlaintextInE2EEChat        = errors.New("暗号化されたチャットには暗号化していないメッセージを送信できません")
+	ErrEncryptedMessageInvalid    = errors.New("暗号化されたメッセージの形式が正しくありません")
+	ErrEncryptedMessageNotAllowed = errors.New("このチャットは暗号化されていません")
+	ErrE2EEKeyOutdated            = errors.New("公開鍵が更新されています。ページを再読み込みしてから送信してください")
+	ErrSafetyNumberMismatch       = errors.New("安全番号が変更されています。新しい安全番号を確認してください")
+)
+
+// ユーザーの公開鍵（識別鍵）の構造体
+// 秘密鍵はクライアントの端末から出さず、サーバーには公開鍵だけを登録する
+type IdentityKey struct {
+	KeyID     string    // 鍵のID（公開鍵から計算する）
+	UserID    string    // 鍵を登録したユーザーのID
+	PublicKey string    // 公開鍵（P-256の非圧縮形式をBase64URLでエンコードしたもの）
+	CreatedAt time.Time // 登録日時
+	Current   bool      // ユーザーの現在の鍵かどうか（新しい鍵を登録すると古い鍵はfalseになる）
+}
+
+// 公開鍵を検証して識別鍵を作成する
+func NewIdentityKey(userID string, publicKey string, now time.Time) (IdentityKey, error) {
+	publicKey = strings.TrimSpace(publicKey)
+	raw, err := decodeE2EEBase64(publicKey)
+	if err != nil {
+		return IdentityKey{}, ErrIdentityKeyInvalid
+	}
+	if _, err := ecdh.P256().NewPublicKey(raw); err != nil {
+		return IdentityKey{}, ErrIdentityKeyInvalid
+	}
+	return IdentityKey{
+		KeyID:     IdentityKeyID(raw),
+		UserID:    userID,
+		PublicKey: base64.RawURLEncoding.EncodeToString(raw),
+		CreatedAt: now,
+		Current:   true,
+	}, nil
+}
+
+// 公開鍵から鍵のIDを計算する
+func IdentityKeyID(publicKey []byte) string {
+	sum := sha256.Sum256(publicKey)
+	return hex.EncodeToString(sum[:identityKeyIDLength/2])
+}
+
+// 暗号化されたメッセージの構造体
+// 鍵のIDで送信者と受信者の鍵を特定し、受信者は相手の公開鍵と自分の秘密鍵で復号する
+type EncryptedEnvelope struct {
+	Version        int    // 暗号化の方式のバージョン
+	SenderKeyID    string // 送信者の鍵のID
+	RecipientKeyID string // 受信者の鍵のID
+	Nonce          string // ノンス（Base64URL）
+	Ciphertext     string // 認証タグを含む暗号文（Base64URL）
+}
+
+// 暗号化されたメッセージの形式を検証する（内容はサーバーでは復号できないため検証しない）
+func ValidateEncryptedEnvelope(e EncryptedEnvelope) error {
+	if e.Version != E2EEVersion {
+		return ErrEncryptedMessageInvalid
+	}
+	if !isIdentityKeyID(e.SenderKeyID) || !isIdentityKeyID(e.RecipientKeyID) {
+		return ErrEncryptedMessageInvalid
+	}
+	nonce, err := decodeE2EEBase64(e.Nonce)
+	if err != nil || len(nonce) != E2EENonceSize {
+		return ErrEncryptedMessageInvalid
+	}
+	if base64.RawURLEncoding.DecodedLen(len(e.Ciphertext)) > MaxEncryptedMessageSize+2 {
+		return ErrMessageTooLong
+	}
+	ciphertext, err := decodeE2EEBase64(e.Ciphertext)
+	if err != nil || len(ciphertext) <= E2EETagSize {
+		return ErrEncryptedMessageInvalid
+	}
+	if len(ciphertext) > MaxEncryptedMessageSize {
+		return ErrMessageTooLong
+	}
+	return nil
+}
+
+// 鍵のIDの形式かどうか
+func isIdentityKeyID(s string) bool {
+	if len(s) != identityKeyIDLength {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Base64URLの文字列をデコードする（パディングの有無はどちらでもよい）
+func decodeE2EEBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// 安全番号の計算でハッシュを繰り返す回数（総当たりで同じ番号の鍵を探しにくくする）
+const safetyNumberIterations = 5200
+
+// 安全番号のバージョン（計算方法を変更した場合に古い番号と区別する）
+const safetyNumberVersion = 0
+
+// 安全番号（2人の公開鍵から計算する60桁の数字）
+// 2人の端末に表示される番号が一致すれば、お互いの鍵がすり替えられていないことを確認できる
+type SafetyNumber string
+
+// 2人の公開鍵から安全番号を計算する（どちらの側から計算しても同じ番号になる）
+func NewSafetyNumber(a IdentityKey, b IdentityKey) (SafetyNumber, error) {
+	fa, err := keyFingerprint(a)
+	if err != nil {
+		return "", err
+	}
+	fb, err := keyFingerprint(b)
+	if err != nil {
+		return "", err
+	}
+	if fb < fa {
+		fa, fb = fb, fa
+	}
+	return SafetyNumber(fa + fb), nil
+}
+
+// 表示用に5桁ずつに区切った安全番号
+func (n SafetyNumber) Groups() []string {
+	var groups []string
+	for i := 0; i+5 <= len(n); i += 5 {
+		groups = append(groups, string(n[i:i+5]))
+	}
+	return groups
+}
+
+// 1人分の鍵の指紋（30桁）
+// ユーザーIDと公開鍵のハッシュを繰り返し計算し、先頭30バイトを5バイトずつ5桁の数字にする
+func keyFingerprint(key IdentityKey) (string, error) {
+	publicKey, err := decodeE2EEBase64(key.PublicKey)
+	if err != nil {
+		return "", ErrIdentityKeyInvalid
+	}
+
+	data := make([]byte, 0, 2+len(publicKey)+len(key.UserID))
+	data = binary.BigEndian.AppendUint16(data, safetyNumberVersion)
+	data = append(data, publicKey...)
+	data = append(data, key.UserID...)
+	digest := sha512.Sum512(data)
+	for i := 1; i < safetyNumberIterations; i++ {
+		digest = sha512.Sum512(append(digest[:], publicKey...))
+	}
+
+	var b strings.Builder
+	for i := 0; i < 30; i += 5 {
+		chunk := uint64(digest[i])<<32 | uint64(binary.BigEndian.Uint32(digest[i+1:i+5]))
+		fmt.Fprintf(&b, "%05d", chunk%100000)
+	}
+	return b.String(), nil
+}
+
+// 相手の鍵の確認（安全番号を確認済みにしたこと）の構造体
+// 確認した時点の双方の鍵のIDを記録し、どちらかの鍵が変更された場合は確認済みとみなさない
+type KeyVerification struct {
+	VerifierID    string    // 確認したユーザーのID
+	TargetID      string    // 確認された相手のID
+	VerifierKeyID string    // 確認した時点の自分の鍵のID
+	TargetKeyID   string    // 確認した時点の相手の鍵のID
+	VerifiedAt    time.Time // 確認した日時
+}
+
+// 現在の鍵の組み合わせで確認済みかどうか
+func (v KeyVerification) ValidFor(own IdentityKey, target IdentityKey) bool {
+	return v.VerifierKeyID == own.KeyID && v.TargetKeyID == target.KeyID
+}
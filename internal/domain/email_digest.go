@@ -0,0 +1,38 @@
+package domain
+
+import "time"
+
+// 1通のメールに載せるチャットごとのメッセージの件数
+const EmailDigestMessagesPerChat = 5
+
+// メールに載せるメッセージの本文の最大文字数
+const EmailDigestContentLength = 100
+
+// 未読メッセージのメールの内容
+type EmailDigest struct {
+	UserName string            // 宛先のユーザーの名前
+	Chats    []EmailDigestChat // 未読メッセージがあるチャット
+	Total    int               // 未読メッセージの合計件数
+	AppURL   string            // アプリのURL
+	Settings string            // 通知設定のURL
+}
+
+// チャットごとの未読メッセージ
+type EmailDigestChat struct {
+	Name     string               // チャットの相手の名前
+	URL      string               // チャットのURL
+	Messages []EmailDigestMessage // メールに載せるメッセージ（新しいものからEmailDigestMessagesPerChat件）
+	Unread   int                  // 未読メッセージの件数
+}
+
+// メールに載せるメッセージ
+type EmailDigestMessage struct {
+	SenderName string    // 送信者の名前
+	Content    string    // 本文（長い場合は省略する）
+	CreatedAt  time.Time // 送信日時
+}
+
+// メールに載せていない未読メッセージの件数
+func (c EmailDigestChat) Remaining() int {
+	return c.Unread - len(c.Messages)
+}
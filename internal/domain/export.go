@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// エクスポートするファイルの形式
+type ExportFormat string
+
+const (
+	ExportFormatJSON ExportFormat = "json" // JSON（インポートに使用できる形式）
+	ExportFormatHTML ExportFormat = "html" // 単体で閲覧できるHTML
+	ExportFormatText ExportFormat = "txt"  // プレーンテキスト
+)
+
+// JSON形式のエクスポートのバージョン（インポート時に形式を判別するために使用する）
+const ExportVersion = "security_chat_app/v1"
+
+// エクスポートに関するエラー
+var ErrInvalidExportFormat = errors.New("エクスポートの形式はjson・html・txtのいずれかを指定してください")
+
+// 文字列からエクスポートの形式を取得する（未指定の場合はJSON）
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch ExportFormat(value) {
+	case "", ExportFormatJSON:
+		return ExportFormatJSON, nil
+	case ExportFormatHTML, ExportFormatText:
+		return ExportFormat(value), nil
+	default:
+		return "", ErrInvalidExportFormat
+	}
+}
+
+// JSON形式のエクスポートの先頭部分の構造体（messagesは続けて出力する）
+type ChatExport struct {
+	Version      string              `json:"version"`
+	ChatID       string              `json:"chat_id"`
+	ExportedAt   time.Time           `json:"exported_at"`
+	Participants []ExportParticipant `json:"participants"`
+	Messages     []ExportMessage     `json:"messages"`
+}
+
+// エクスポートするチャット参加者の構造体
+type ExportParticipant struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// エクスポートするメッセージの構造体
+type ExportMessage struct {
+	ID                  string    `json:"id"`
+	Type                string    `json:"type"`
+	SenderID            string    `json:"sender_id"`
+	SenderName          string    `json:"sender_name"`
+	Content             string    `json:"content"`
+	CreatedAt           time.Time `json:"created_at"`
+	MediaURL            string    `json:"media_url,omitempty"`
+	ForwardedFromSender string    `json:"forwarded_from_sender,omitempty"`
+}
+
+// メッセージをエクスポートする形式に変換する
+func NewExportMessage(m Message) ExportMessage {
+	exported := ExportMessage{
+		ID:         m.ID,
+		Type:       string(m.Type),
+		SenderID:   m.SenderID,
+		SenderName: m.SenderName,
+		Content:    m.Content,
+		CreatedAt:  m.CreatedAt,
+		MediaURL:   m.MediaURL,
+	}
+	if m.ForwardedFrom != nil {
+		exported.ForwardedFromSender = m.ForwardedFrom.SenderName
+	}
+	// 暗号化されたメッセージの本文はサーバーでは読めないため、エクスポートには含めない
+	if m.Type == MessageTypeEncrypted {
+		exported.Content = "（暗号化されたメッセージ）"
+	}
+	return exported
+}
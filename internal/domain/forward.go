@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 一度に転送できるチャットの上限数
+const MaxForwardTargets = 10
+
+// 転送に関するエラー
+var (
+	ErrForwardNoTarget       = errors.New("転送先のチャットを選択してください")
+	ErrForwardTooManyTargets = fmt.Errorf("一度に転送できるチャットは最大%d件です", MaxForwardTargets)
+	ErrForwardNotAllowed     = errors.New("このメッセージは転送できません")
+)
+
+// 転送元のメッセージの情報の構造体（転送したメッセージに保存される）
+type ForwardedFrom struct {
+	ChatID     string    // 転送元のチャットのID
+	MessageID  string    // 転送元のメッセージのID
+	SenderID   string    // 元のメッセージの送信者のID
+	SenderName string    // 元のメッセージの送信者の名前
+	SentAt     time.Time // 元のメッセージの送信日時
+}
+
+// 転送先のチャットIDを検証し、重複と転送元のチャットを除いて返す
+func ValidateForwardTargets(sourceChatID string, targetChatIDs []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var targets []string
+	for _, id := range targetChatIDs {
+		if id == "" || id == sourceChatID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		targets = append(targets, id)
+	}
+	if len(targets) == 0 {
+		return nil, ErrForwardNoTarget
+	}
+	if len(targets) > MaxForwardTargets {
+		return nil, ErrForwardTooManyTargets
+	}
+	return targets, nil
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"time"
+)
+
+// インポートするファイルの形式
+type ImportSource string
+
+const (
+	ImportSourceApp   ImportSource = "json"  // このアプリのJSON形式のエクスポート
+	ImportSourceSlack ImportSource = "slack" // Slackのエクスポート（zip）
+)
+
+// インポートに関するエラー
+var (
+	ErrInvalidImportSource    = errors.New("インポートの形式はjson・slackのいずれかを指定してください")
+	ErrUnsupportedExport      = errors.New("対応していないエクスポートの形式です")
+	ErrImportChannelRequired  = errors.New("インポートするチャンネルを指定してください")
+	ErrImportChannelNotFound  = errors.New("指定したチャンネルがエクスポートに含まれていません")
+	ErrImportMessagesNotFound = errors.New("インポートするメッセージがありません")
+)
+
+// 文字列からインポートの形式を取得する（未指定の場合はこのアプリのJSON形式）
+func ParseImportSource(value string) (ImportSource, error) {
+	switch ImportSource(value) {
+	case "", ImportSourceApp:
+		return ImportSourceApp, nil
+	case ImportSourceSlack:
+		return ImportSourceSlack, nil
+	default:
+		return "", ErrInvalidImportSource
+	}
+}
+
+// インポートするメッセージの構造体（取り込み元の形式から変換したもの）
+type ImportMessage struct {
+	SourceID    string      // 取り込み元でのメッセージID（重複の判定に使用）
+	SenderEmail string      // 送信者のメールアドレス（ユーザーの対応付けに使用）
+	SenderName  string      // 取り込み元での送信者名
+	Content     string      // メッセージ内容
+	Type        MessageType // メッセージの種類
+	CreatedAt   time.Time   // 取り込み元での送信日時
+}
+
+// インポート後のメッセージID
+// 取り込み元のIDから決まるため、同じメッセージを再度インポートしても重複しない
+func (m ImportMessage) MessageID(source ImportSource) string {
+	sum := sha256.Sum256([]byte(string(source) + ":" + m.SourceID))
+	return "imp_" + hex.EncodeToString(sum[:16])
+}
+
+// メッセージを送信日時の順に並べる（同じ日時の場合は取り込み元の順序を保つ）
+func SortImportMessages(messages []ImportMessage) {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+}
+
+// インポートの結果（ドライランの場合は取り込む予定の件数）
+type ImportReport struct {
+	DryRun          bool            `json:"dry_run"`
+	Source          ImportSource    `json:"source"`
+	Total           int             `json:"total"`            // 読み込んだメッセージの件数
+	Imported        int             `json:"imported"`         // 取り込んだ（取り込む予定の）件数
+	Duplicates      int             `json:"duplicates"`       // インポート済みのため除外した件数
+	Skipped         int             `json:"skipped"`          // 送信者を対応付けられないなどで除外した件数
+	Rejected        int             `json:"rejected"`         // ブロック・文字数の制限・コンテンツフィルターで拒否された件数
+	UnmappedSenders []string        `json:"unmapped_senders"` // 対応するユーザーが見つからない送信者（画面からのインポートではインポートしたユーザー以外の送信者）
+	Mapping         []ImportMapping `json:"mapping"`          // 送信者とユーザーの対応
+	FirstMessageAt  *time.Time      `json:"first_message_at,omitempty"`
+	LastMessageAt   *time.Time      `json:"last_message_at,omitempty"`
+}
+
+// 取り込み元の送信者とユーザーの対応
+type ImportMapping struct {
+	SenderEmail string `json:"sender_email"`
+	SenderName  string `json:"sender_name"`
+	UserID      string `json:"user_id"`
+	UserName    string `json:"user_name"`
+}
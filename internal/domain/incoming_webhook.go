@@ -0,0 +1,107 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 1つのチャットに登録できる受信Webhookの上限数
+const MaxIncomingWebhooksPerChat = 5
+
+// 受信Webhookのトークンの接頭辞
+const IncomingWebhookTokenPrefix = "iwh_"
+
+// 受信Webhookの名前の文字数の範囲
+const (
+	MinIncomingWebhookNameLength = 1
+	MaxIncomingWebhookNameLength = 30
+)
+
+// 受信Webhookで受け付ける添付の上限
+const (
+	MaxMessageAttachments        = 5    // 1つのメッセージに付けられる添付の数
+	MaxAttachmentTitleLength     = 100  // 添付のタイトルの最大文字数
+	MaxAttachmentTextLength      = 1000 // 添付の本文の最大文字数
+	IncomingWebhookRateLimit     = 30   // 1つの受信Webhookが期間内に投稿できる数
+	IncomingWebhookRateWindowSec = 60   // 投稿数を数える期間（秒）
+)
+
+// 添付の色の形式（#RRGGBB）
+var attachmentColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// 受信Webhookに関するエラー
+var (
+	ErrIncomingWebhookNameInvalid   = fmt.Errorf("受信Webhookの名前は%d〜%d文字で入力してください", MinIncomingWebhookNameLength, MaxIncomingWebhookNameLength)
+	ErrIncomingWebhookLimitExceeded = fmt.Errorf("1つのチャットに登録できる受信Webhookは最大%d個です", MaxIncomingWebhooksPerChat)
+	ErrIncomingWebhookNotFound      = errors.New("受信Webhookが見つかりません")
+	ErrInvalidIncomingWebhookToken  = errors.New("受信WebhookのURLが正しくないか、無効化されています")
+	ErrIncomingWebhookRateLimited   = errors.New("投稿が多すぎます。しばらく待ってから再度お試しください")
+	ErrTooManyAttachments           = fmt.Errorf("添付は%d個まで指定できます", MaxMessageAttachments)
+	ErrAttachmentEmpty              = errors.New("添付にはタイトルか本文を指定してください")
+	ErrAttachmentTitleTooLong       = fmt.Errorf("添付のタイトルは%d文字以内で指定してください", MaxAttachmentTitleLength)
+	ErrAttachmentTextTooLong        = fmt.Errorf("添付の本文は%d文字以内で指定してください", MaxAttachmentTextLength)
+	ErrAttachmentLinkInvalid        = errors.New("添付のリンクはhttp://またはhttps://で始まるURLを指定してください")
+	ErrAttachmentColorInvalid       = errors.New("添付の色は#RRGGBBの形式で指定してください")
+)
+
+// 外部のサービスからチャットに投稿するための受信Webhookの構造体
+// トークンはハッシュ値だけを保存し、URLは作成時にしか表示しない
+type IncomingWebhook struct {
+	ID         string    // 受信WebhookのID（投稿したメッセージの送信者IDにもなる）
+	ChatID     string    // 投稿先のチャットのID
+	Name       string    // 投稿に表示する送信者の名前
+	TokenHash  string    // トークンのハッシュ値
+	CreatedBy  string    // 作成したユーザーのID
+	CreatedAt  time.Time // 作成日時
+	LastUsedAt time.Time // 最後に投稿した日時（未使用の場合はゼロ値）
+}
+
+// メッセージの添付（外部のサービスからの投稿に付ける補足情報）
+type MessageAttachment struct {
+	Title     string // タイトル
+	TitleLink string // タイトルのリンク先
+	Text      string // 本文
+	Color     string // 左端に表示する色（#RRGGBB）
+}
+
+// 受信Webhookの名前を検証する
+func ValidateIncomingWebhookName(name string) error {
+	n := utf8.RuneCountInString(name)
+	if n < MinIncomingWebhookNameLength || n > MaxIncomingWebhookNameLength {
+		return ErrIncomingWebhookNameInvalid
+	}
+	return nil
+}
+
+// メッセージの添付を検証する
+func ValidateAttachments(attachments []MessageAttachment) error {
+	if len(attachments) > MaxMessageAttachments {
+		return ErrTooManyAttachments
+	}
+	for _, a := range attachments {
+		if strings.TrimSpace(a.Title) == "" && strings.TrimSpace(a.Text) == "" {
+			return ErrAttachmentEmpty
+		}
+		if utf8.RuneCountInString(a.Title) > MaxAttachmentTitleLength {
+			return ErrAttachmentTitleTooLong
+		}
+		if utf8.RuneCountInString(a.Text) > MaxAttachmentTextLength {
+			return ErrAttachmentTextTooLong
+		}
+		if a.TitleLink != "" {
+			u, err := url.Parse(a.TitleLink)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return ErrAttachmentLinkInvalid
+			}
+		}
+		if a.Color != "" && !attachmentColorPattern.MatchString(a.Color) {
+			return ErrAttachmentColorInvalid
+		}
+	}
+	return nil
+}
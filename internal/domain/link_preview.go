@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// メッセージ内のURLのプレビューの構造体
+type LinkPreview struct {
+	URL         string    // プレビューしたURL
+	Title       string    // ページのタイトル
+	Description string    // ページの説明
+	ImageURL    string    // ページの画像のURL
+	SiteName    string    // サイト名
+	FetchedAt   time.Time // プレビューを取得した日時
+}
+
+// プレビューとして表示できる情報があるかどうか
+func (p *LinkPreview) IsEmpty() bool {
+	return p == nil || (p.Title == "" && p.Description == "" && p.ImageURL == "")
+}
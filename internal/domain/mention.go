@@ -0,0 +1,120 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// メンションの構造体（メッセージに保存される）
+type Mention struct {
+	UserID   string // メンションされたユーザーのID
+	Username string // メンションした時点のユーザー名
+	Start    int    // 本文中の「@」の位置（バイト単位）
+	End      int    // 本文中のメンションの終了位置（バイト単位）
+}
+
+// 通知の種類
+type NotificationType string
+
+const (
+	NotificationTypeMention NotificationType = "mention" // メンションされた
+)
+
+// 通知の構造体
+type Notification struct {
+	ID         string           // 通知のID
+	UserID     string           // 通知を受け取るユーザーのID
+	Type       NotificationType // 通知の種類
+	ChatID     string           // 通知元のチャットのID
+	MessageID  string           // 通知元のメッセージのID
+	SenderID   string           // 通知元のメッセージの送信者のID
+	SenderName string           // 通知元のメッセージの送信者の名前
+	Content    string           // 通知元のメッセージの内容
+	IsRead     bool             // 通知を確認したかどうか
+	CreatedAt  time.Time        // 通知の作成日時
+}
+
+// ParseMentions 本文中の「@ユーザー名」をチャットの参加者と照合してメンションを抽出する
+// 名前が前方一致する参加者が複数いる場合は、最も長い名前の参加者を優先する
+func ParseMentions(content string, participants []Contact) []Mention {
+	var mentions []Mention
+	for i := 0; i < len(content); i++ {
+		if content[i] != '@' || !isMentionStart(content, i) {
+			continue
+		}
+
+		rest := content[i+1:]
+		var matched *Contact
+		for j := range participants {
+			name := participants[j].Username
+			if name == "" || !strings.HasPrefix(rest, name) || !isMentionEnd(rest, len(name)) {
+				continue
+			}
+			if matched == nil || len(name) > len(matched.Username) {
+				matched = &participants[j]
+			}
+		}
+		if matched == nil {
+			continue
+		}
+
+		end := i + 1 + len(matched.Username)
+		mentions = append(mentions, Mention{
+			UserID:   matched.ID,
+			Username: matched.Username,
+			Start:    i,
+			End:      end,
+		})
+		i = end - 1
+	}
+	return mentions
+}
+
+// メンションされたユーザーのIDを重複なく取得する
+func MentionedUserIDs(mentions []Mention) []string {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, m := range mentions {
+		if seen[m.UserID] {
+			continue
+		}
+		seen[m.UserID] = true
+		ids = append(ids, m.UserID)
+	}
+	return ids
+}
+
+// メッセージが指定したユーザーへのメンションを含むかどうか
+func (m Message) MentionsUser(userID string) bool {
+	for _, mention := range m.Mentions {
+		if mention.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// 「@」の直前が英数字の場合（メールアドレスなど）はメンションとみなさない
+func isMentionStart(content string, at int) bool {
+	if at == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(content[:at])
+	return !isASCIIWordRune(r)
+}
+
+// 名前の直後に英数字が続く場合は別のユーザー名とみなす
+func isMentionEnd(rest string, nameLen int) bool {
+	if nameLen >= len(rest) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(rest[nameLen:])
+	return !isASCIIWordRune(r)
+}
+
+// 半角英数字とアンダースコアかどうか
+func isASCIIWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 1件のメッセージとして送信できる最大文字数（下書きと同じ）
+const MaxMessageLength = MaxDraftLength
+
+// メッセージの送信処理に関するエラー
+var (
+	ErrMessageEmpty       = errors.New("メッセージを入力してください")
+	ErrMessageTooLong     = fmt.Errorf("メッセージは%d文字以内で入力してください", MaxMessageLength)
+	ErrNotChatParticipant = errors.New("このチャットにはアクセスできません")
+)
+
+// メッセージの送信処理でメッセージが拒否された場合のエラー
+// Errの内容はそのままユーザーに表示される
+type MessageRejectedError struct {
+	Processor string // 拒否した処理の名前
+	Err       error  // 拒否した理由
+}
+
+func (e *MessageRejectedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *MessageRejectedError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,123 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// 投票の選択肢の数の制限
+const (
+	MinPollOptions = 2
+	MaxPollOptions = 10
+)
+
+// 投票の質問・選択肢の最大文字数
+const MaxPollTextLength = 200
+
+// 投票に関するエラー
+var (
+	ErrPollQuestionEmpty = errors.New("投票の質問を入力してください")
+	ErrPollOptionCount   = fmt.Errorf("投票の選択肢は%d〜%d個で指定してください", MinPollOptions, MaxPollOptions)
+	ErrPollTextTooLong   = fmt.Errorf("投票の質問と選択肢は%d文字以内で入力してください", MaxPollTextLength)
+	ErrPollDuplicate     = errors.New("投票の選択肢が重複しています")
+	ErrInvalidPollOption = errors.New("選択肢が正しくありません")
+	ErrNotPoll           = errors.New("このメッセージは投票ではありません")
+	ErrPollNotFound      = errors.New("投票が見つかりません")
+)
+
+// 投票の構造体（メッセージのドキュメントに保存される）
+type Poll struct {
+	Question string       // 質問
+	Options  []PollOption // 選択肢
+}
+
+// 投票の選択肢の構造体
+type PollOption struct {
+	Text  string   // 選択肢の内容
+	Votes []string // 投票したユーザーのID
+}
+
+// 投票の総数
+func (p Poll) TotalVotes() int {
+	total := 0
+	for _, o := range p.Options {
+		total += len(o.Votes)
+	}
+	return total
+}
+
+// 選択肢の得票率（0〜100の整数）
+func (p Poll) Percent(option int) int {
+	total := p.TotalVotes()
+	if total == 0 || option < 0 || option >= len(p.Options) {
+		return 0
+	}
+	return len(p.Options[option].Votes) * 100 / total
+}
+
+// ユーザーが投票した選択肢の番号（投票していない場合は-1）
+func (p Poll) VotedOption(userID string) int {
+	for i, o := range p.Options {
+		for _, v := range o.Votes {
+			if v == userID {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// 選択肢に投票する（1人1票で、別の選択肢に投票すると投票先を変更する。同じ選択肢に投票すると取り消す）
+func (p *Poll) Vote(userID string, option int) error {
+	if option < 0 || option >= len(p.Options) {
+		return ErrInvalidPollOption
+	}
+	current := p.VotedOption(userID)
+	if current >= 0 {
+		votes := p.Options[current].Votes[:0]
+		for _, v := range p.Options[current].Votes {
+			if v != userID {
+				votes = append(votes, v)
+			}
+		}
+		p.Options[current].Votes = votes
+	}
+	if current != option {
+		p.Options[option].Votes = append(p.Options[option].Votes, userID)
+	}
+	return nil
+}
+
+// 投票の質問と選択肢から投票を作成する
+func NewPoll(question string, options []string) (*Poll, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, ErrPollQuestionEmpty
+	}
+	if len(options) < MinPollOptions || len(options) > MaxPollOptions {
+		return nil, ErrPollOptionCount
+	}
+	if utf8.RuneCountInString(question) > MaxPollTextLength {
+		return nil, ErrPollTextTooLong
+	}
+
+	poll := &Poll{Question: question}
+	seen := make(map[string]bool, len(options))
+	for _, text := range options {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return nil, ErrPollOptionCount
+		}
+		if utf8.RuneCountInString(text) > MaxPollTextLength {
+			return nil, ErrPollTextTooLong
+		}
+		if seen[text] {
+			return nil, ErrPollDuplicate
+		}
+		seen[text] = true
+		poll.Options = append(poll.Options, PollOption{Text: text})
+	}
+	return poll, nil
+}
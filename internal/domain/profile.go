@@ -0,0 +1,251 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// プロフィールの各項目の最大文字数
+const (
+	MaxBioLength              = 300 // 自己紹介
+	MaxStatusTextLength       = 60  // ステータスのメッセージ
+	MaxDepartmentLength       = 50  // 部署
+	MaxJobTitleLength         = 50  // 役職
+	MaxProfileLinkLabelLength = 30  // リンクの表示名
+	MaxProfileLinkURLLength   = 500 // リンクのURL
+)
+
+// プロフィールに登録できるリンクの最大数
+const MaxProfileLinks = 5
+
+// ステータスの絵文字の最大文字数（肌の色や結合文字を含む1つの絵文字を想定）
+const maxStatusEmojiRunes = 10
+
+// プロフィールに関するエラー
+var (
+	ErrBioTooLong              = fmt.Errorf("自己紹介は%d文字以下で入力してください", MaxBioLength)
+	ErrStatusTextTooLong       = fmt.Errorf("ステータスは%d文字以下で入力してください", MaxStatusTextLength)
+	ErrStatusEmojiInvalid      = errors.New("ステータスの絵文字は1つだけ入力してください")
+	ErrInvalidStatusExpiry     = errors.New("ステータスの有効期限の指定が正しくありません")
+	ErrDepartmentTooLong       = fmt.Errorf("部署は%d文字以下で入力してください", MaxDepartmentLength)
+	ErrJobTitleTooLong         = fmt.Errorf("役職は%d文字以下で入力してください", MaxJobTitleLength)
+	ErrTooManyProfileLinks     = fmt.Errorf("リンクは最大%d件まで登録できます", MaxProfileLinks)
+	ErrProfileLinkInvalid      = errors.New("リンクにはhttpまたはhttpsのURLを入力してください")
+	ErrProfileLinkLabelTooLong = fmt.Errorf("リンクの表示名は%d文字以下で入力してください", MaxProfileLinkLabelLength)
+)
+
+// プロフィールのリンクの構造体
+type ProfileLink struct {
+	Label string // 表示名（未入力の場合はホスト名）
+	URL   string // リンク先のURL
+}
+
+// ユーザーのプロフィールの構造体
+// ユーザーに埋め込まれ、Firestoreにはユーザーのドキュメントのフィールドとして保存される
+type Profile struct {
+	Bio             string        // 自己紹介
+	StatusEmoji     string        // ステータスの絵文字
+	StatusText      string        // ステータスのメッセージ
+	StatusExpiresAt time.Time     // ステータスの有効期限（ゼロ値の場合は無期限）
+	Department      string        // 部署
+	JobTitle        string        // 役職
+	Links           []ProfileLink // リンク
+}
+
+// 有効なステータスが設定されているかどうか（テンプレート用）
+func (p Profile) HasStatus() bool {
+	return p.StatusActiveAt(time.Now())
+}
+
+// 指定した日時にステータスが有効かどうか
+func (p Profile) StatusActiveAt(now time.Time) bool {
+	if p.StatusEmoji == "" && p.StatusText == "" {
+		return false
+	}
+	return p.StatusExpiresAt.IsZero() || now.Before(p.StatusExpiresAt)
+}
+
+// 部署と役職をまとめた表示用の文字列
+func (p Profile) Affiliation() string {
+	switch {
+	case p.Department != "" && p.JobTitle != "":
+		return p.Department + " / " + p.JobTitle
+	case p.Department != "":
+		return p.Department
+	default:
+		return p.JobTitle
+	}
+}
+
+// プロフィールを検証する
+// 各項目の前後の空白を取り除き、空のリンクを除外してから検証する
+func ValidateProfile(p *Profile) error {
+	p.Bio = strings.TrimSpace(p.Bio)
+	p.StatusEmoji = strings.TrimSpace(p.StatusEmoji)
+	p.StatusText = strings.TrimSpace(p.StatusText)
+	p.Department = strings.TrimSpace(p.Department)
+	p.JobTitle = strings.TrimSpace(p.JobTitle)
+
+	if utf8.RuneCountInString(p.Bio) > MaxBioLength {
+		return ErrBioTooLong
+	}
+	if utf8.RuneCountInString(p.StatusText) > MaxStatusTextLength {
+		return ErrStatusTextTooLong
+	}
+	if p.StatusEmoji != "" && !isSingleEmoji(p.StatusEmoji) {
+		return ErrStatusEmojiInvalid
+	}
+	if utf8.RuneCountInString(p.Department) > MaxDepartmentLength {
+		return ErrDepartmentTooLong
+	}
+	if utf8.RuneCountInString(p.JobTitle) > MaxJobTitleLength {
+		return ErrJobTitleTooLong
+	}
+
+	var links []ProfileLink
+	for _, link := range p.Links {
+		link.Label = strings.TrimSpace(link.Label)
+		link.URL = strings.TrimSpace(link.URL)
+		if link.Label == "" && link.URL == "" {
+			continue
+		}
+		if len(link.URL) > MaxProfileLinkURLLength {
+			return ErrProfileLinkInvalid
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User != nil {
+			return ErrProfileLinkInvalid
+		}
+		if link.Label == "" {
+			link.Label = u.Hostname()
+		}
+		if utf8.RuneCountInString(link.Label) > MaxProfileLinkLabelLength {
+			return ErrProfileLinkLabelTooLong
+		}
+		links = append(links, link)
+	}
+	if len(links) > MaxProfileLinks {
+		return ErrTooManyProfileLinks
+	}
+	p.Links = links
+
+	// ステータスが空の場合は有効期限も残さない
+	if p.StatusEmoji == "" && p.StatusText == "" {
+		p.StatusExpiresAt = time.Time{}
+	}
+	return nil
+}
+
+// 1つの絵文字かどうか
+// 文字や数字を含まず、記号（So）と結合用の文字（ZWJ・異体字セレクタ・肌の色など）だけで構成されているものを絵文字とみなす
+func isSingleEmoji(s string) bool {
+	if utf8.RuneCountInString(s) > maxStatusEmojiRunes {
+		return false
+	}
+	symbols, regions, joiners := 0, 0, 0
+	for _, r := range s {
+		switch {
+		case r >= 0x1F1E6 && r <= 0x1F1FF: // 国旗の地域指示子（2つで1つの国旗になる）
+			regions++
+		case r >= 0x1F3FB && r <= 0x1F3FF: // 肌の色の修飾子（前の絵文字の一部）
+		case r == 0x200D: // ZWJ（前後の絵文字を結合する）
+			joiners++
+		case unicode.Is(unicode.So, r):
+			symbols++
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r):
+		default:
+			return false
+		}
+	}
+	if regions > 0 {
+		return regions == 2 && symbols == 0
+	}
+	return symbols >= 1 && symbols <= joiners+1
+}
+
+// ステータスの有効期限の選択肢
+type StatusExpiry string
+
+const (
+	StatusExpiryKeep   StatusExpiry = "keep" // 変更しない（現在の有効期限を維持する）
+	StatusExpiryNever  StatusExpiry = ""     // 無期限
+	StatusExpiry30Min  StatusExpiry = "30m"  // 30分後
+	StatusExpiry1Hour  StatusExpiry = "1h"   // 1時間後
+	StatusExpiry4Hours StatusExpiry = "4h"   // 4時間後
+	StatusExpiry1Day   StatusExpiry = "1d"   // 1日後
+	StatusExpiry7Days  StatusExpiry = "7d"   // 7日後
+)
+
+// 選択できるステータスの有効期限の一覧（「変更しない」は含まない）
+func StatusExpiries() []StatusExpiry {
+	return []StatusExpiry{StatusExpiryNever, StatusExpiry30Min, StatusExpiry1Hour, StatusExpiry4Hours, StatusExpiry1Day, StatusExpiry7Days}
+}
+
+// 文字列からステータスの有効期限を取得する
+func ParseStatusExpiry(value string) (StatusExpiry, error) {
+	if StatusExpiry(value) == StatusExpiryKeep {
+		return StatusExpiryKeep, nil
+	}
+	for _, e := range StatusExpiries() {
+		if string(e) == value {
+			return e, nil
+		}
+	}
+	return StatusExpiryNever, ErrInvalidStatusExpiry
+}
+
+// 有効期限までの長さ（無期限・変更しない場合は0）
+func (e StatusExpiry) Duration() time.Duration {
+	switch e {
+	case StatusExpiry30Min:
+		return 30 * time.Minute
+	case StatusExpiry1Hour:
+		return time.Hour
+	case StatusExpiry4Hours:
+		return 4 * time.Hour
+	case StatusExpiry1Day:
+		return 24 * time.Hour
+	case StatusExpiry7Days:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// 画面に表示する有効期限の名前
+func (e StatusExpiry) Label() string {
+	switch e {
+	case StatusExpiryKeep:
+		return "変更しない"
+	case StatusExpiry30Min:
+		return "30分"
+	case StatusExpiry1Hour:
+		return "1時間"
+	case StatusExpiry4Hours:
+		return "4時間"
+	case StatusExpiry1Day:
+		return "1日"
+	case StatusExpiry7Days:
+		return "7日"
+	default:
+		return "無期限"
+	}
+}
+
+// 有効期限の日時を計算する
+// 変更しない場合は現在の有効期限を、無期限の場合はゼロ値を返す
+func (e StatusExpiry) ExpiresAt(now time.Time, current time.Time) time.Time {
+	switch e {
+	case StatusExpiryKeep:
+		return current
+	case StatusExpiryNever:
+		return time.Time{}
+	default:
+		return now.Add(e.Duration())
+	}
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 1人のユーザーが登録できるプッシュ通知の購読の最大数（端末・ブラウザごとに1つ）
+const MaxPushSubscriptionsPerUser = 10
+
+// 購読のエンドポイントのURLの最大文字数
+const MaxPushEndpointLength = 1000
+
+// プッシュ通知の本文の最大文字数
+const PushBodyLength = 100
+
+// プッシュ通知の種類
+type PushNotificationType string
+
+const (
+	PushNotificationMessage        PushNotificationType = "message"         // 新しいメッセージ
+	PushNotificationMention        PushNotificationType = "mention"         // メンションされた
+	PushNotificationContactRequest PushNotificationType = "contact_request" // 連絡先の申請
+)
+
+// プッシュ通知に関するエラー
+var (
+	ErrPushSubscriptionInvalid  = errors.New("プッシュ通知の購読情報が正しくありません")
+	ErrPushSubscriptionNotFound = errors.New("プッシュ通知の購読が見つかりません")
+	ErrPushSubscriptionExpired  = errors.New("プッシュ通知の購読の有効期限が切れています")
+	ErrPushUnavailable          = errors.New("このサーバーではプッシュ通知を利用できません")
+)
+
+// プッシュ通知の購読の構造体
+// ブラウザのPushSubscriptionに対応し、端末・ブラウザごとに1つ登録される
+type PushSubscription struct {
+	ID         string    // 購読のID（エンドポイントから生成する）
+	UserID     string    // 通知を受け取るユーザーのID
+	Endpoint   string    // プッシュサービスのURL
+	P256dh     string    // ブラウザの公開鍵（P-256、Base64URL）
+	Auth       string    // 認証用のシークレット（16バイト、Base64URL）
+	UserAgent  string    // 登録したブラウザ（一覧の表示用）
+	ExpiresAt  time.Time // 有効期限（ブラウザが指定しない場合はゼロ値）
+	CreatedAt  time.Time // 登録日時
+	LastUsedAt time.Time // 最後に通知を送信した日時
+}
+
+// 有効期限が切れているかどうか
+func (s PushSubscription) IsExpired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// プッシュ通知の購読情報を検証する
+// エンドポイントはhttpsの公開されたURLで、鍵はRFC 8291で使用する長さである必要がある
+func ValidatePushSubscription(sub PushSubscription) error {
+	if sub.Endpoint == "" || len(sub.Endpoint) > MaxPushEndpointLength {
+		return ErrPushSubscriptionInvalid
+	}
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || (u.Port() != "" && u.Port() != "443") {
+		return ErrPushSubscriptionInvalid
+	}
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPushSubscriptionInvalid
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast()) {
+		return ErrPushSubscriptionInvalid
+	}
+
+	// 非圧縮形式のP-256の公開鍵（65バイト）と16バイトの認証用シークレット
+	p256dh, err := DecodePushKey(sub.P256dh)
+	if err != nil || len(p256dh) != 65 || p256dh[0] != 0x04 {
+		return ErrPushSubscriptionInvalid
+	}
+	auth, err := DecodePushKey(sub.Auth)
+	if err != nil || len(auth) != 16 {
+		return ErrPushSubscriptionInvalid
+	}
+	return nil
+}
+
+// Base64URLの鍵をデコードする（パディングの有無はブラウザによって異なる）
+func DecodePushKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,264 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// 通報の対象の種類
+type ReportTarget string
+
+const (
+	ReportTargetMessage ReportTarget = "message" // メッセージ
+	ReportTargetUser    ReportTarget = "user"    // ユーザー（プロフィール）
+)
+
+// 通報の理由
+type ReportReason string
+
+const (
+	ReportReasonSpam          ReportReason = "spam"          // スパム・宣伝
+	ReportReasonHarassment    ReportReason = "harassment"    // 嫌がらせ・いじめ
+	ReportReasonInappropriate ReportReason = "inappropriate" // 不適切な内容
+	ReportReasonImpersonation ReportReason = "impersonation" // なりすまし
+	ReportReasonOther         ReportReason = "other"         // その他
+
+	// コンテンツフィルターによる自動検出（ユーザーは選択できない）
+	ReportReasonContentFilter ReportReason = "content_filter"
+)
+
+// 選択できる通報の理由（表示順）
+func ReportReasons() []ReportReason {
+	return []ReportReason{
+		ReportReasonSpam,
+		ReportReasonHarassment,
+		ReportReasonInappropriate,
+		ReportReasonImpersonation,
+		ReportReasonOther,
+	}
+}
+
+// 通報の理由の表示名
+func (r ReportReason) Label() string {
+	switch r {
+	case ReportReasonSpam:
+		return "スパム・宣伝"
+	case ReportReasonHarassment:
+		return "嫌がらせ・いじめ"
+	case ReportReasonInappropriate:
+		return "不適切な内容"
+	case ReportReasonImpersonation:
+		return "なりすまし"
+	case ReportReasonOther:
+		return "その他"
+	case ReportReasonContentFilter:
+		return "コンテンツフィルターによる検出"
+	default:
+		return string(r)
+	}
+}
+
+// 通報の状態
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "open"      // 対応待ち
+	ReportStatusDismissed ReportStatus = "dismissed" // 対応不要として却下
+	ReportStatusResolved  ReportStatus = "resolved"  // 対応済み（メッセージの削除・アカウントの停止）
+)
+
+// 通報の状態の表示名
+func (s ReportStatus) Label() string {
+	switch s {
+	case ReportStatusOpen:
+		return "対応待ち"
+	case ReportStatusDismissed:
+		return "却下"
+	case ReportStatusResolved:
+		return "対応済み"
+	default:
+		return string(s)
+	}
+}
+
+// 通報に対する管理者の操作
+type ModerationAction string
+
+const (
+	ModerationDismiss       ModerationAction = "dismiss"        // 却下
+	ModerationDeleteMessage ModerationAction = "delete_message" // メッセージの削除
+	ModerationSuspendUser   ModerationAction = "suspend_user"   // アカウントの停止
+)
+
+// 操作の表示名
+func (a ModerationAction) Label() string {
+	switch a {
+	case ModerationDismiss:
+		return "却下"
+	case ModerationDeleteMessage:
+		return "メッセージの削除"
+	case ModerationSuspendUser:
+		return "アカウントの停止"
+	default:
+		return string(a)
+	}
+}
+
+// 操作後の通報の状態
+func (a ModerationAction) ResultStatus() ReportStatus {
+	if a == ModerationDismiss {
+		return ReportStatusDismissed
+	}
+	return ReportStatusResolved
+}
+
+// 通報のコメントの最大文字数
+const MaxReportCommentLength = 1000
+
+// 通報に関するエラー
+var (
+	ErrInvalidReportTarget     = errors.New("通報の対象が正しくありません")
+	ErrInvalidReportReason     = errors.New("通報の理由を選択してください")
+	ErrReportCommentTooLong    = fmt.Errorf("コメントは%d文字以内で入力してください", MaxReportCommentLength)
+	ErrCannotReportSelf        = errors.New("自分自身は通報できません")
+	ErrInvalidModerationAction = errors.New("不正な操作です")
+	ErrReportAlreadyHandled    = errors.New("この通報は既に対応済みです")
+	ErrReportNotFound          = errors.New("通報が見つかりません")
+	ErrModerationNotApplicable = errors.New("この通報にはその操作を行えません")
+	ErrAdminRequired           = errors.New("管理者のみ利用できます")
+	ErrAccountSuspended        = errors.New("このアカウントは利用が停止されています")
+)
+
+// 通報の構造体
+type Report struct {
+	ID               string           // 通報のID
+	TargetType       ReportTarget     // 通報の対象の種類
+	ReporterID       string           // 通報したユーザーのID
+	ReporterName     string           // 通報したユーザーの名前
+	ReportedUserID   string           // 通報されたユーザーのID
+	ReportedUserName string           // 通報されたユーザーの名前
+	ChatID           string           // 通報されたメッセージのチャットのID（ユーザーの通報の場合は空）
+	MessageID        string           // 通報されたメッセージのID（ユーザーの通報の場合は空）
+	Reason           ReportReason     // 通報の理由
+	Comment          string           // 通報者のコメント
+	Snapshot         string           // 通報時点の内容（メッセージの本文やユーザー名）
+	SnapshotMediaURL string           // 通報時点の添付メディアのURL
+	Status           ReportStatus     // 通報の状態
+	Action           ModerationAction // 管理者が行った操作（対応待ちの場合は空）
+	HandledBy        string           // 対応した管理者のID
+	HandledAt        time.Time        // 対応した日時
+	CreatedAt        time.Time        // 通報した日時
+}
+
+// 操作を行えるかどうか
+func (r Report) CanApply(action ModerationAction) error {
+	if r.Status != ReportStatusOpen {
+		return ErrReportAlreadyHandled
+	}
+	switch action {
+	case ModerationDismiss, ModerationSuspendUser:
+		return nil
+	case ModerationDeleteMessage:
+		if r.TargetType != ReportTargetMessage {
+			return ErrModerationNotApplicable
+		}
+		return nil
+	default:
+		return ErrInvalidModerationAction
+	}
+}
+
+// 監査ログの操作の種類
+type AuditAction string
+
+const (
+	AuditReportCreated   AuditAction = "report_created"   // 通報の作成
+	AuditReportDismissed AuditAction = "report_dismissed" // 通報の却下
+	AuditMessageDeleted  AuditAction = "message_deleted"  // メッセージの削除
+	AuditUserSuspended   AuditAction = "user_suspended"   // アカウントの停止
+
+	AuditFilterRuleCreated AuditAction = "filter_rule_created" // フィルターのルールの追加
+	AuditFilterRuleUpdated AuditAction = "filter_rule_updated" // フィルターのルールの有効・無効の切り替え
+	AuditFilterRuleDeleted AuditAction = "filter_rule_deleted" // フィルターのルールの削除
+
+	AuditWebhookCreated AuditAction = "webhook_created" // 全体のWebhookの登録
+	AuditWebhookUpdated AuditAction = "webhook_updated" // 全体のWebhookの有効・無効の切り替え
+	AuditWebhookDeleted AuditAction = "webhook_deleted" // 全体のWebhookの削除
+)
+
+// 操作の表示名
+func (a AuditAction) Label() string {
+	switch a {
+	case AuditReportCreated:
+		return "通報"
+	case AuditReportDismissed:
+		return "通報の却下"
+	case AuditMessageDeleted:
+		return "メッセージの削除"
+	case AuditUserSuspended:
+		return "アカウントの停止"
+	case AuditFilterRuleCreated:
+		return "フィルターのルールの追加"
+	case AuditFilterRuleUpdated:
+		return "フィルターのルールの変更"
+	case AuditFilterRuleDeleted:
+		return "フィルターのルールの削除"
+	case AuditWebhookCreated:
+		return "Webhookの登録"
+	case AuditWebhookUpdated:
+		return "Webhookの変更"
+	case AuditWebhookDeleted:
+		return "Webhookの削除"
+	default:
+		return string(a)
+	}
+}
+
+// 管理者の操作に対応する監査ログの種類
+func (a ModerationAction) AuditAction() AuditAction {
+	switch a {
+	case ModerationDeleteMessage:
+		return AuditMessageDeleted
+	case ModerationSuspendUser:
+		return AuditUserSuspended
+	default:
+		return AuditReportDismissed
+	}
+}
+
+// 監査ログの構造体（通報と管理者の操作の記録）
+type AuditLog struct {
+	ID           string      // 監査ログのID
+	Action       AuditAction // 操作の種類
+	ActorID      string      // 操作したユーザーのID
+	ActorName    string      // 操作したユーザーの名前
+	ReportID     string      // 関連する通報のID
+	TargetUserID string      // 操作の対象のユーザーのID
+	ChatID       string      // 操作の対象のチャットのID
+	MessageID    string      // 操作の対象のメッセージのID
+	Detail       string      // 補足情報
+	CreatedAt    time.Time   // 操作した日時
+}
+
+// 通報の内容を検証する
+func ValidateReport(target ReportTarget, reason ReportReason, comment string) error {
+	if target != ReportTargetMessage && target != ReportTargetUser {
+		return ErrInvalidReportTarget
+	}
+	valid := false
+	for _, r := range ReportReasons() {
+		if r == reason {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return ErrInvalidReportReason
+	}
+	if utf8.RuneCountInString(comment) > MaxReportCommentLength {
+		return ErrReportCommentTooLong
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// チャットのメッセージ保持期間
+type RetentionPolicy string
+
+const (
+	RetentionNever  RetentionPolicy = "never" // 無期限（自動削除しない）
+	Retention1Day   RetentionPolicy = "1d"    // 1日後に削除
+	Retention7Days  RetentionPolicy = "7d"    // 7日後に削除
+	Retention30Days RetentionPolicy = "30d"   // 30日後に削除
+)
+
+// 保持期間に関するエラー
+var ErrInvalidRetentionPolicy = errors.New("保持期間の指定が正しくありません")
+
+// 選択できる保持期間の一覧
+func RetentionPolicies() []RetentionPolicy {
+	return []RetentionPolicy{RetentionNever, Retention1Day, Retention7Days, Retention30Days}
+}
+
+// 文字列から保持期間を取得する（未設定の場合は無期限）
+func ParseRetentionPolicy(value string) (RetentionPolicy, error) {
+	if value == "" {
+		return RetentionNever, nil
+	}
+	for _, p := range RetentionPolicies() {
+		if string(p) == value {
+			return p, nil
+		}
+	}
+	return RetentionNever, ErrInvalidRetentionPolicy
+}
+
+// 保持期間の長さ（無期限の場合は0）
+func (p RetentionPolicy) Duration() time.Duration {
+	switch p {
+	case Retention1Day:
+		return 24 * time.Hour
+	case Retention7Days:
+		return 7 * 24 * time.Hour
+	case Retention30Days:
+		return 30 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// 画面に表示する保持期間の名前
+func (p RetentionPolicy) Label() string {
+	switch p {
+	case Retention1Day:
+		return "1日"
+	case Retention7Days:
+		return "7日"
+	case Retention30Days:
+		return "30日"
+	default:
+		return "無期限"
+	}
+}
+
+// メッセージが自動削除される日時（無期限の場合はゼロ値）
+func (p RetentionPolicy) ExpiresAt(createdAt time.Time) time.Time {
+	if p.Duration() == 0 {
+		return time.Time{}
+	}
+	return createdAt.Add(p.Duration())
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 予約メッセージの状態
+type ScheduleStatus string
+
+const (
+	ScheduleStatusPending  ScheduleStatus = "pending"  // 送信待ち
+	ScheduleStatusSending  ScheduleStatus = "sending"  // 送信処理中
+	ScheduleStatusSent     ScheduleStatus = "sent"     // 送信済み
+	ScheduleStatusCanceled ScheduleStatus = "canceled" // キャンセル済み
+	ScheduleStatusFailed   ScheduleStatus = "failed"   // 送信失敗
+)
+
+// 予約できる最も先の日時（現在からの期間）
+const MaxScheduleAhead = 365 * 24 * time.Hour
+
+// 予約メッセージに関するエラー
+var (
+	ErrScheduleContentEmpty = errors.New("メッセージを入力してください")
+	ErrScheduleInPast       = errors.New("送信日時には現在より後の日時を指定してください")
+	ErrScheduleTooFar       = fmt.Errorf("送信日時は%d日以内で指定してください", int(MaxScheduleAhead/(24*time.Hour)))
+	ErrScheduleNotPending   = errors.New("送信待ちの予約メッセージではありません")
+	ErrScheduleNotFound     = errors.New("予約メッセージが見つかりません")
+)
+
+// 予約メッセージの構造体
+type ScheduledMessage struct {
+	ID         string         // 予約メッセージのID
+	ChatID     string         // 送信先のチャットのID
+	SenderID   string         // 送信者のID
+	SenderName string         // 送信者の名前
+	Content    string         // メッセージの内容
+	SendAt     time.Time      // 送信予定日時
+	Status     ScheduleStatus // 予約の状態
+	CreatedAt  time.Time      // 予約の作成日時
+	UpdatedAt  time.Time      // 予約の更新日時
+}
+
+// 予約メッセージの内容と送信日時を検証する
+// 送信時に拒否されないように、本文の長さは送信時と同じく文字数で検証する
+func ValidateScheduledMessage(content string, sendAt time.Time, now time.Time) error {
+	if strings.TrimSpace(content) == "" {
+		return ErrScheduleContentEmpty
+	}
+	if utf8.RuneCountInString(content) > MaxMessageLength {
+		return ErrMessageTooLong
+	}
+	if !sendAt.After(now) {
+		return ErrScheduleInPast
+	}
+	if sendAt.After(now.Add(MaxScheduleAhead)) {
+		return ErrScheduleTooFar
+	}
+	return nil
+}
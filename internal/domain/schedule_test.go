@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateScheduledMessage(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		content string
+		sendAt  time.Time
+		want    error
+	}{
+		{"正しい予約", "こんにちは", later, nil},
+		{"空の本文", " \n", later, ErrScheduleContentEmpty},
+		{"上限ちょうどの本文", strings.Repeat("あ", MaxMessageLength), later, nil},
+		{"上限を超える本文", strings.Repeat("あ", MaxMessageLength+1), later, ErrMessageTooLong},
+		{"バイト数では上限を超えるが文字数では超えない本文", strings.Repeat("😀", MaxMessageLength), later, nil},
+		{"現在の日時", "x", now, ErrScheduleInPast},
+		{"過去の日時", "x", now.Add(-time.Minute), ErrScheduleInPast},
+		{"予約できる期間ちょうど", "x", now.Add(MaxScheduleAhead), nil},
+		{"予約できる期間を超える日時", "x", now.Add(MaxScheduleAhead + time.Second), ErrScheduleTooFar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateScheduledMessage(tt.content, tt.sendAt, now); !errors.Is(err, tt.want) {
+				t.Errorf("ValidateScheduledMessage() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,7 @@ type TemplateData struct {
 	Messages         []Message  // メッセージ
 	Contacts         []Contact  // 連絡先
 	Chats            []Chat     // チャット
+	ArchivedChats    []Chat     // アーカイブしたチャット
 	CurrentChat      *Chat      // 現在のチャット
 	SignupForm       SignupForm // サインアップフォーム
 	LoginForm        LoginForm  // ログインフォーム
@@ -15,6 +16,10 @@ type TemplateData struct {
 	ValidationErrors []string   // バリデーションエラー
 	Error            string     // エラー
 	ChatID           string     // チャットID
+
+	ScheduledMessages []ScheduledMessage // 送信待ちの予約メッセージ
+	Notifications     []Notification     // ログインユーザーへの通知
+	OwnBots           []Bot              // ログインユーザーが作成したボット
 }
 
 // DefaultIcon デフォルトアイコンの情報
@@ -4,22 +4,34 @@ import "time"
 
 // ユーザーの構造体
 type User struct {
-	ID            string    // ユーザーのID
-	Name          string    // ユーザーの名前
-	Email         string    // ユーザーのメールアドレス
-	Password      string    // ユーザーのパスワード
-	CreatedAt     time.Time // ユーザーの作成日時
-	UpdatedAt     time.Time // ユーザーの更新日時
-	IsOnline      bool      // ユーザーがオンラインかどうか
-	Icon          string    // ユーザーのアイコン
-	Contacts      []Contact // ユーザーの連絡先
+	ID            string       // ユーザーのID
+	Name          string       // ユーザーの名前
+	Email         string       // ユーザーのメールアドレス
+	Password      string       // ユーザーのパスワード
+	CreatedAt     time.Time    // ユーザーの作成日時
+	UpdatedAt     time.Time    // ユーザーの更新日時
+	IsOnline      bool         // ユーザーがオンラインかどうか
+	Icon          string       // ユーザーのアイコン
+	Contacts      []Contact    // ユーザーの連絡先
+	IsAdmin       bool         // 管理者かどうか（通報の管理画面を利用できる）
+	IsSuspended   bool         // アカウントが停止されているかどうか
+	SuspendedAt   time.Time    // アカウントが停止された日時
+	IsBot         bool         // ボットのアカウントかどうか（ログインできない）
+	EmailDigest   bool         // 未読メッセージをメールで受け取るかどうか
+	EmailDigestAt time.Time    // 未読メッセージのメールで最後に通知したメッセージの日時
+	ContactsOnly  bool         // 連絡先のユーザーからのチャットのみ受け付けるかどうか
+	Availability  Availability // 在席状況（未設定の場合はオンライン）
+	DNDSchedule   DNDSchedule  // おやすみモードのスケジュール
+	Profile                    // プロフィール（自己紹介・ステータス・部署と役職・リンク）
 }
 
 // 連絡先を交換したユーザーの構造体
 type Contact struct {
-	ID       string    // 連絡先のID
-	Username string    // 連絡先のユーザー名
-	Icon     string    // 連絡先のアイコンのURL
-	LastSeen time.Time // 連絡先の最終接続日時
-	IsOnline bool      // 連絡先がオンラインかどうか
+	ID       string       // 連絡先のID
+	Username string       // 連絡先のユーザー名
+	Icon     string       // 連絡先のアイコンのURL
+	LastSeen time.Time    // 連絡先の最終接続日時
+	IsOnline bool         // 連絡先がオンラインかどうか（オフライン表示の場合はfalse）
+	Presence Availability // 連絡先の在席状況
+	Profile               // 連絡先のプロフィール
 }
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Webhookで通知するイベント
+type WebhookEvent string
+
+// メッセージの編集はまだできないため、編集のイベントは追加していない
+const (
+	WebhookMessageCreated WebhookEvent = "message.created" // メッセージの送信
+	WebhookMessageDeleted WebhookEvent = "message.deleted" // メッセージの削除
+)
+
+// 選択できるイベント（表示順）
+func WebhookEvents() []WebhookEvent {
+	return []WebhookEvent{WebhookMessageCreated, WebhookMessageDeleted}
+}
+
+// イベントの表示名
+func (e WebhookEvent) Label() string {
+	switch e {
+	case WebhookMessageCreated:
+		return "メッセージの送信"
+	case WebhookMessageDeleted:
+		return "メッセージの削除"
+	default:
+		return string(e)
+	}
+}
+
+// Webhookの配信の状態
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // 配信に成功した
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // 再試行しても配信できなかった
+)
+
+// 配信の状態の表示名
+func (s WebhookDeliveryStatus) Label() string {
+	switch s {
+	case WebhookDeliverySucceeded:
+		return "成功"
+	case WebhookDeliveryFailed:
+		return "失敗"
+	default:
+		return "未配信"
+	}
+}
+
+// 1つのチャットに登録できるWebhookの上限数
+const MaxWebhooksPerChat = 5
+
+// WebhookのURLの最大文字数
+const MaxWebhookURLLength = 500
+
+// Webhookの署名用シークレットの接頭辞
+const WebhookSecretPrefix = "whsec_"
+
+// Webhookに関するエラー
+var (
+	ErrWebhookURLInvalid    = errors.New("WebhookのURLはhttps://で始まる公開されたURLを入力してください")
+	ErrWebhookURLTooLong    = fmt.Errorf("WebhookのURLは%d文字以内で入力してください", MaxWebhookURLLength)
+	ErrWebhookEventRequired = errors.New("通知するイベントを1つ以上選択してください")
+	ErrInvalidWebhookEvent  = errors.New("通知するイベントが正しくありません")
+	ErrWebhookLimitExceeded = fmt.Errorf("1つのチャットに登録できるWebhookは最大%d個です", MaxWebhooksPerChat)
+	ErrWebhookNotFound      = errors.New("Webhookが見つかりません")
+)
+
+// 送信するWebhookの構造体
+// ChatIDが空の場合は全てのチャットのイベントを通知する（管理者のみ登録できる）
+type Webhook struct {
+	ID              string                // WebhookのID
+	ChatID          string                // 対象のチャットのID（全体の場合は空）
+	URL             string                // 通知先のURL
+	Secret          string                // 署名用のシークレット
+	Events          []WebhookEvent        // 通知するイベント
+	Enabled         bool                  // 有効かどうか
+	CreatedBy       string                // 登録したユーザーのID
+	CreatedAt       time.Time             // 登録日時
+	LastStatus      WebhookDeliveryStatus // 最後の配信の状態（未配信の場合は空）
+	LastStatusCode  int                   // 最後の配信のHTTPステータスコード
+	LastError       string                // 最後の配信のエラー
+	LastDeliveredAt time.Time             // 最後の配信日時
+}
+
+// 全てのチャットを対象にするWebhookかどうか
+func (w Webhook) IsGlobal() bool {
+	return w.ChatID == ""
+}
+
+// イベントを通知するかどうか
+func (w Webhook) Subscribes(event WebhookEvent) bool {
+	if !w.Enabled {
+		return false
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// Webhookの配信履歴の構造体
+type WebhookDelivery struct {
+	ID          string                // 配信のID（受信側の重複排除にも使用する）
+	WebhookID   string                // WebhookのID
+	Event       WebhookEvent          // 通知したイベント
+	Status      WebhookDeliveryStatus // 配信の状態
+	Attempts    int                   // 試行回数
+	StatusCode  int                   // 最後の試行のHTTPステータスコード（接続できなかった場合は0）
+	Error       string                // 最後の試行のエラー
+	CreatedAt   time.Time             // イベントの発生日時
+	CompletedAt time.Time             // 配信の完了日時
+}
+
+// WebhookのURLと通知するイベントを検証する
+func ValidateWebhook(rawURL string, events []WebhookEvent) error {
+	if len(rawURL) > MaxWebhookURLLength {
+		return ErrWebhookURLTooLong
+	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || (u.Port() != "" && u.Port() != "443") {
+		return ErrWebhookURLInvalid
+	}
+	// 内部ネットワークのアドレスは配信時にも拒否するが、登録時点で分かるものはここで弾く
+	host := u.Hostname()
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookURLInvalid
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast()) {
+		return ErrWebhookURLInvalid
+	}
+
+	if len(events) == 0 {
+		return ErrWebhookEventRequired
+	}
+	for _, e := range events {
+		switch e {
+		case WebhookMessageCreated, WebhookMessageDeleted:
+		default:
+			return ErrInvalidWebhookEvent
+		}
+	}
+	return nil
+}
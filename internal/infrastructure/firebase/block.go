@@ -0,0 +1,110 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+// ブロックを保存するコレクション
+const blocksCollection = "blocks"
+
+// ブロックのドキュメントID（ブロックしたユーザーとされたユーザーの組ごとに1件）
+func blockID(blockerID string, blockedID string) string {
+	return blockerID + "_" + blockedID
+}
+
+// ユーザーをブロックする（ブロック済みの場合は何もしない）
+func BlockUser(blockerID string, blockedID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(blocksCollection).Doc(blockID(blockerID, blockedID)).Set(ctx, map[string]interface{}{
+		"blocker_id": blockerID,
+		"blocked_id": blockedID,
+		"created_at": time.Now(),
+	})
+	if err != nil {
+		log.Printf("ブロックの保存エラー: %v, blockerID=%s, blockedID=%s", err, blockerID, blockedID)
+		return err
+	}
+	return nil
+}
+
+// ブロックを解除する
+func UnblockUser(blockerID string, blockedID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(blocksCollection).Doc(blockID(blockerID, blockedID)).Delete(ctx)
+	if err != nil {
+		log.Printf("ブロックの解除エラー: %v, blockerID=%s, blockedID=%s", err, blockerID, blockedID)
+		return err
+	}
+	return nil
+}
+
+// blockerIDのユーザーがblockedIDのユーザーをブロックしているかどうか
+func IsBlocked(blockerID string, blockedID string) (bool, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(blocksCollection).
+		Where("blocker_id", "==", blockerID).
+		Where("blocked_id", "==", blockedID).
+		Limit(1).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return false, err
+	}
+	return len(docs) > 0, nil
+}
+
+// ユーザーがブロックしているユーザーのIDを取得する（新しい順）
+func GetBlockedUserIDs(blockerID string) ([]string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(blocksCollection).Where("blocker_id", "==", blockerID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	type blocked struct {
+		id        string
+		createdAt time.Time
+	}
+	var list []blocked
+	for _, doc := range docs {
+		data := doc.Data()
+		id, _ := data["blocked_id"].(string)
+		createdAt, _ := data["created_at"].(time.Time)
+		if id != "" {
+			list = append(list, blocked{id: id, createdAt: createdAt})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].createdAt.After(list[j].createdAt) })
+
+	ids := make([]string, 0, len(list))
+	for _, b := range list {
+		ids = append(ids, b.id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,200 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ボットを保存するコレクション（ドキュメントIDはボットのユーザーID）
+const botsCollection = "bots"
+
+// ボットを作成する
+// ボットのユーザーとボットの情報を同時に保存する
+func CreateBot(bot domain.Bot, user domain.User) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	batch := client.Batch()
+	batch.Set(client.Collection("users").Doc(bot.ID), user)
+	batch.Set(client.Collection(botsCollection).Doc(bot.ID), map[string]interface{}{
+		"id":         bot.ID,
+		"name":       bot.Name,
+		"owner_id":   bot.OwnerID,
+		"token_hash": bot.TokenHash,
+		"created_at": bot.CreatedAt,
+	})
+	if _, err := batch.Commit(ctx); err != nil {
+		log.Printf("ボットの保存エラー: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ボットを取得する
+func GetBot(botID string) (*domain.Bot, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(botsCollection).Doc(botID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrBotNotFound
+	}
+	bot := toBot(doc.Ref.ID, doc.Data())
+	return &bot, nil
+}
+
+// APIトークンのハッシュ値からボットを取得する
+func GetBotByTokenHash(tokenHash string) (*domain.Bot, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(botsCollection).
+		Where("token_hash", "==", tokenHash).
+		Limit(1).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, domain.ErrInvalidBotToken
+	}
+	bot := toBot(docs[0].Ref.ID, docs[0].Data())
+	return &bot, nil
+}
+
+// ユーザーが作成したボットを取得する（作成した順）
+func GetBotsByOwner(ownerID string) ([]domain.Bot, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(botsCollection).
+		Where("owner_id", "==", ownerID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	bots := make([]domain.Bot, 0, len(docs))
+	for _, doc := range docs {
+		bots = append(bots, toBot(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(bots, func(i, j int) bool {
+		return bots[i].CreatedAt.Before(bots[j].CreatedAt)
+	})
+	return bots, nil
+}
+
+// ボットを削除する
+// ボットのユーザーも削除し、参加しているチャットから外す（送信済みのメッセージは残す）
+func DeleteBot(botID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	chats, err := client.Collection("chats").Where("bots", "array-contains", botID).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	batch := client.Batch()
+	for _, chat := range chats {
+		batch.Update(chat.Ref, []firestore.Update{
+			{Path: "bots", Value: firestore.ArrayRemove(botID)},
+		})
+	}
+	batch.Delete(client.Collection(botsCollection).Doc(botID))
+	batch.Delete(client.Collection("users").Doc(botID))
+	_, err = batch.Commit(ctx)
+	return err
+}
+
+// ボットをチャットに参加させる
+// チャットは1対1のため、ボットは参加者（participants）ではなくbotsに追加する
+func AddChatBot(chatID string, botID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection("chats").Doc(chatID).Update(ctx, []firestore.Update{
+		{Path: "bots", Value: firestore.ArrayUnion(botID)},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	return err
+}
+
+// ボットをチャットから外す
+func RemoveChatBot(chatID string, botID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection("chats").Doc(chatID).Update(ctx, []firestore.Update{
+		{Path: "bots", Value: firestore.ArrayRemove(botID)},
+	})
+	return err
+}
+
+// チャットに参加しているボットのIDを取得する
+func GetChatBots(chatID string) ([]string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection("chats").Doc(chatID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	bots, _ := doc.Data()["bots"].([]interface{})
+	var result []string
+	for _, b := range bots {
+		if id, ok := b.(string); ok {
+			result = append(result, id)
+		}
+	}
+	return result, nil
+}
+
+// Firestoreのボットのデータをドメインの構造体に変換
+func toBot(id string, data map[string]interface{}) domain.Bot {
+	bot := domain.Bot{ID: id}
+	bot.Name, _ = data["name"].(string)
+	bot.OwnerID, _ = data["owner_id"].(string)
+	bot.TokenHash, _ = data["token_hash"].(string)
+	bot.CreatedAt, _ = data["created_at"].(time.Time)
+	return bot
+}
@@ -0,0 +1,238 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 連絡先の申請と連絡先を保存するコレクション
+const (
+	contactRequestsCollection = "contact_requests"
+	contactsCollection        = "contacts"
+)
+
+// 連絡先の申請のドキュメントID（申請したユーザーとされたユーザーの組ごとに1件）
+func contactRequestID(fromID string, toID string) string {
+	return fromID + "_" + toID
+}
+
+// 連絡先のドキュメントID（ユーザーごとに相手1人につき1件）
+func contactID(userID string, contactUserID string) string {
+	return userID + "_" + contactUserID
+}
+
+// 連絡先の申請を保存する
+func AddContactRequest(req domain.ContactRequest) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(contactRequestsCollection).Doc(contactRequestID(req.FromID, req.ToID)).Set(ctx, map[string]interface{}{
+		"from_id":    req.FromID,
+		"from_name":  req.FromName,
+		"to_id":      req.ToID,
+		"to_name":    req.ToName,
+		"created_at": req.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("連絡先の申請の保存エラー: %v, fromID=%s, toID=%s", err, req.FromID, req.ToID)
+		return err
+	}
+	return nil
+}
+
+// 連絡先の申請を取得する
+func GetContactRequest(fromID string, toID string) (*domain.ContactRequest, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(contactRequestsCollection).Doc(contactRequestID(fromID, toID)).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrContactRequestNotFound
+	}
+	req := toContactRequest(doc.Data())
+	return &req, nil
+}
+
+// 連絡先の申請を削除する（拒否・取り消し）
+func DeleteContactRequest(fromID string, toID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(contactRequestsCollection).Doc(contactRequestID(fromID, toID)).Delete(ctx)
+	if err != nil {
+		log.Printf("連絡先の申請の削除エラー: %v, fromID=%s, toID=%s", err, fromID, toID)
+		return err
+	}
+	return nil
+}
+
+// ユーザーが受け取った連絡先の申請を取得する（新しい順）
+func GetContactRequestsTo(userID string) ([]domain.ContactRequest, error) {
+	return getContactRequests("to_id", userID)
+}
+
+// ユーザーが送信した連絡先の申請を取得する（新しい順）
+func GetContactRequestsFrom(userID string) ([]domain.ContactRequest, error) {
+	return getContactRequests("from_id", userID)
+}
+
+// 指定したフィールドで連絡先の申請を検索する
+func getContactRequests(field string, userID string) ([]domain.ContactRequest, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(contactRequestsCollection).Where(field, "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]domain.ContactRequest, 0, len(docs))
+	for _, doc := range docs {
+		requests = append(requests, toContactRequest(doc.Data()))
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].CreatedAt.After(requests[j].CreatedAt)
+	})
+	return requests, nil
+}
+
+// 連絡先の申請を承認する
+// 申請を削除し、双方の連絡先に追加する（申請が取り消されていた場合は追加しない）
+func AcceptContactRequest(fromID string, toID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	requestRef := client.Collection(contactRequestsCollection).Doc(contactRequestID(fromID, toID))
+
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		if _, err := tx.Get(requestRef); err != nil {
+			return domain.ErrContactRequestNotFound
+		}
+
+		now := time.Now()
+		for _, pair := range [][2]string{{fromID, toID}, {toID, fromID}} {
+			ref := client.Collection(contactsCollection).Doc(contactID(pair[0], pair[1]))
+			err := tx.Set(ref, map[string]interface{}{
+				"user_id":    pair[0],
+				"contact_id": pair[1],
+				"created_at": now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Delete(requestRef)
+	})
+}
+
+// 連絡先から削除する（相手の連絡先からも削除する）
+func DeleteContact(userID string, contactUserID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	batch := client.Batch()
+	batch.Delete(client.Collection(contactsCollection).Doc(contactID(userID, contactUserID)))
+	batch.Delete(client.Collection(contactsCollection).Doc(contactID(contactUserID, userID)))
+	if _, err := batch.Commit(ctx); err != nil {
+		log.Printf("連絡先の削除エラー: %v, userID=%s, contactID=%s", err, userID, contactUserID)
+		return err
+	}
+	return nil
+}
+
+// contactUserIDのユーザーがuserIDのユーザーの連絡先かどうか
+func IsContact(userID string, contactUserID string) (bool, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return false, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(contactsCollection).
+		Where("user_id", "==", userID).
+		Where("contact_id", "==", contactUserID).
+		Limit(1).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return false, err
+	}
+	return len(docs) > 0, nil
+}
+
+// ユーザーの連絡先のIDを取得する（追加した順）
+func GetContactIDs(userID string) ([]string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(contactsCollection).Where("user_id", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	type contact struct {
+		id        string
+		createdAt time.Time
+	}
+	var list []contact
+	for _, doc := range docs {
+		data := doc.Data()
+		id, _ := data["contact_id"].(string)
+		createdAt, _ := data["created_at"].(time.Time)
+		if id != "" {
+			list = append(list, contact{id: id, createdAt: createdAt})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].createdAt.Before(list[j].createdAt) })
+
+	ids := make([]string, 0, len(list))
+	for _, c := range list {
+		ids = append(ids, c.id)
+	}
+	return ids, nil
+}
+
+// Firestoreのデータを連絡先の申請に変換する
+func toContactRequest(data map[string]interface{}) domain.ContactRequest {
+	var req domain.ContactRequest
+	req.FromID, _ = data["from_id"].(string)
+	req.FromName, _ = data["from_name"].(string)
+	req.ToID, _ = data["to_id"].(string)
+	req.ToName, _ = data["to_name"].(string)
+	req.CreatedAt, _ = data["created_at"].(time.Time)
+	return req
+}
@@ -0,0 +1,131 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// コンテンツフィルターのルールを保存するコレクション
+const filterRulesCollection = "content_filter_rules"
+
+// フィルターのルールを追加する
+func AddFilterRule(rule domain.FilterRule) (string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection(filterRulesCollection).NewDoc()
+	_, err = ref.Set(ctx, map[string]interface{}{
+		"id":         ref.ID,
+		"pattern":    rule.Pattern,
+		"match_type": string(rule.MatchType),
+		"action":     string(rule.Action),
+		"note":       rule.Note,
+		"enabled":    rule.Enabled,
+		"created_by": rule.CreatedBy,
+		"created_at": rule.CreatedAt,
+		"updated_at": rule.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("フィルターのルールの保存エラー: %v", err)
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// フィルターのルールを全て取得する（追加した順）
+func GetFilterRules() ([]domain.FilterRule, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(filterRulesCollection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]domain.FilterRule, 0, len(docs))
+	for _, doc := range docs {
+		rules = append(rules, toFilterRule(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+	return rules, nil
+}
+
+// フィルターのルールを取得する
+func GetFilterRule(ruleID string) (*domain.FilterRule, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(filterRulesCollection).Doc(ruleID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrFilterRuleNotFound
+	}
+	rule := toFilterRule(doc.Ref.ID, doc.Data())
+	return &rule, nil
+}
+
+// フィルターのルールの有効・無効を切り替える
+func SetFilterRuleEnabled(ruleID string, enabled bool) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(filterRulesCollection).Doc(ruleID).Update(ctx, []firestore.Update{
+		{Path: "enabled", Value: enabled},
+		{Path: "updated_at", Value: time.Now()},
+	})
+	return err
+}
+
+// フィルターのルールを削除する
+func DeleteFilterRule(ruleID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(filterRulesCollection).Doc(ruleID).Delete(ctx)
+	return err
+}
+
+// Firestoreのルールのデータをドメインの構造体に変換
+func toFilterRule(id string, data map[string]interface{}) domain.FilterRule {
+	rule := domain.FilterRule{ID: id}
+	rule.Pattern, _ = data["pattern"].(string)
+	if matchType, ok := data["match_type"].(string); ok {
+		rule.MatchType = domain.FilterMatchType(matchType)
+	}
+	if action, ok := data["action"].(string); ok {
+		rule.Action = domain.FilterAction(action)
+	}
+	rule.Note, _ = data["note"].(string)
+	rule.Enabled, _ = data["enabled"].(bool)
+	rule.CreatedBy, _ = data["created_by"].(string)
+	rule.CreatedAt, _ = data["created_at"].(time.Time)
+	rule.UpdatedAt, _ = data["updated_at"].(time.Time)
+	return rule
+}
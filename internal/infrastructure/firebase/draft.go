@@ -0,0 +1,93 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+)
+
+// 下書きを保存するコレクション
+const draftsCollection = "drafts"
+
+// 下書きのドキュメントID（ユーザーとチャットごとに1件）
+func draftID(chatID string, userID string) string {
+	return chatID + "_" + userID
+}
+
+// 下書きを保存する
+func SaveDraft(chatID string, userID string, content string) (time.Time, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	updatedAt := time.Now()
+	_, err = client.Collection(draftsCollection).Doc(draftID(chatID, userID)).Set(ctx, map[string]interface{}{
+		"chat_id":    chatID,
+		"user_id":    userID,
+		"content":    content,
+		"updated_at": updatedAt,
+	})
+	if err != nil {
+		log.Printf("下書きの保存エラー: %v, chatID=%s, userID=%s", err, chatID, userID)
+		return time.Time{}, err
+	}
+	return updatedAt, nil
+}
+
+// 下書きを削除する（下書きが無い場合もエラーにならない）
+func DeleteDraft(chatID string, userID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(draftsCollection).Doc(draftID(chatID, userID)).Delete(ctx)
+	if err != nil {
+		log.Printf("下書きの削除エラー: %v, chatID=%s, userID=%s", err, chatID, userID)
+		return err
+	}
+	return nil
+}
+
+// ユーザーの下書きをチャットIDごとに取得する
+func GetDrafts(userID string) (map[string]domain.Draft, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(draftsCollection).Where("user_id", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := make(map[string]domain.Draft)
+	for _, doc := range docs {
+		var draft struct {
+			ChatID    string    `firestore:"chat_id"`
+			UserID    string    `firestore:"user_id"`
+			Content   string    `firestore:"content"`
+			UpdatedAt time.Time `firestore:"updated_at"`
+		}
+		if err := doc.DataTo(&draft); err != nil {
+			log.Printf("下書きの変換エラー: %v, id=%s", err, doc.Ref.ID)
+			continue
+		}
+		drafts[draft.ChatID] = domain.Draft{
+			ChatID:    draft.ChatID,
+			UserID:    draft.UserID,
+			Content:   draft.Content,
+			UpdatedAt: draft.UpdatedAt,
+		}
+	}
+	return drafts, nil
+}
@@ -0,0 +1,239 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 公開鍵と鍵の確認を保存するコレクション
+const (
+	identityKeysCollection     = "identity_keys"     // ドキュメントIDは鍵のID
+	keyVerificationsCollection = "key_verifications" // ドキュメントIDは確認したユーザーと相手のIDの組
+)
+
+// 鍵の確認のドキュメントID（ユーザーごとに相手1人につき1件）
+func keyVerificationID(verifierID string, targetID string) string {
+	return verifierID + "_" + targetID
+}
+
+// 公開鍵を登録し、ユーザーの現在の鍵にする
+// それまでの現在の鍵は古い鍵として残し（過去のメッセージの復号に使う）、その鍵を返す（初めて登録した場合はnil）
+func SaveIdentityKey(key domain.IdentityKey) (*domain.IdentityKey, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	var previous *domain.IdentityKey
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		previous = nil
+		query := client.Collection(identityKeysCollection).
+			Where("user_id", "==", key.UserID).
+			Where("current", "==", true)
+		docs, err := tx.Documents(query).GetAll()
+		if err != nil {
+			return err
+		}
+		for _, doc := range docs {
+			if doc.Ref.ID == key.KeyID {
+				continue
+			}
+			k := toIdentityKey(doc.Ref.ID, doc.Data())
+			if previous == nil || k.CreatedAt.After(previous.CreatedAt) {
+				previous = &k
+			}
+			if err := tx.Update(doc.Ref, []firestore.Update{{Path: "current", Value: false}}); err != nil {
+				return err
+			}
+		}
+		return tx.Set(client.Collection(identityKeysCollection).Doc(key.KeyID), map[string]interface{}{
+			"user_id":    key.UserID,
+			"public_key": key.PublicKey,
+			"created_at": key.CreatedAt,
+			"current":    true,
+		})
+	})
+	if err != nil {
+		log.Printf("公開鍵の保存エラー: %v, userID=%s, keyID=%s", err, key.UserID, key.KeyID)
+		return nil, err
+	}
+	return previous, nil
+}
+
+// ユーザーの現在の公開鍵を取得する
+func GetCurrentIdentityKey(userID string) (*domain.IdentityKey, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(identityKeysCollection).
+		Where("user_id", "==", userID).
+		Where("current", "==", true).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, domain.ErrIdentityKeyNotFound
+	}
+	key := toIdentityKey(docs[0].Ref.ID, docs[0].Data())
+	return &key, nil
+}
+
+// 鍵のIDから公開鍵を取得する（古い鍵も取得できる）
+func GetIdentityKey(keyID string) (*domain.IdentityKey, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(identityKeysCollection).Doc(keyID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrIdentityKeyNotFound
+	}
+	key := toIdentityKey(doc.Ref.ID, doc.Data())
+	return &key, nil
+}
+
+// チャットのエンドツーエンド暗号化を有効にする（一度有効にしたチャットは無効にできない）
+func EnableChatE2EE(chatID string, enabledAt time.Time) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection("chats").Doc(chatID).Update(ctx, []firestore.Update{
+		{Path: "e2ee_enabled", Value: true},
+		{Path: "e2ee_enabled_at", Value: enabledAt},
+	})
+	if err != nil {
+		log.Printf("チャットの暗号化の有効化エラー: %v, chatID=%s", err, chatID)
+		return err
+	}
+	return nil
+}
+
+// チャットのエンドツーエンド暗号化が有効かどうか
+func IsChatE2EE(chatID string) (bool, error) {
+	data, err := GetData("chats", chatID)
+	if err != nil {
+		return false, err
+	}
+	enabled, _ := data["e2ee_enabled"].(bool)
+	return enabled, nil
+}
+
+// 相手の鍵を確認済みにする（既に確認済みの場合は確認した時点の鍵を更新する）
+func SaveKeyVerification(v domain.KeyVerification) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(keyVerificationsCollection).Doc(keyVerificationID(v.VerifierID, v.TargetID)).Set(ctx, map[string]interface{}{
+		"verifier_id":     v.VerifierID,
+		"target_id":       v.TargetID,
+		"verifier_key_id": v.VerifierKeyID,
+		"target_key_id":   v.TargetKeyID,
+		"verified_at":     v.VerifiedAt,
+	})
+	if err != nil {
+		log.Printf("鍵の確認の保存エラー: %v, verifierID=%s, targetID=%s", err, v.VerifierID, v.TargetID)
+		return err
+	}
+	return nil
+}
+
+// 相手の鍵の確認を取得する（確認していない場合はnil）
+func GetKeyVerification(verifierID string, targetID string) (*domain.KeyVerification, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(keyVerificationsCollection).Doc(keyVerificationID(verifierID, targetID)).Get(ctx)
+	if err != nil || !doc.Exists() {
+		return nil, nil
+	}
+	data := doc.Data()
+	v := &domain.KeyVerification{}
+	v.VerifierID, _ = data["verifier_id"].(string)
+	v.TargetID, _ = data["target_id"].(string)
+	v.VerifierKeyID, _ = data["verifier_key_id"].(string)
+	v.TargetKeyID, _ = data["target_key_id"].(string)
+	v.VerifiedAt, _ = data["verified_at"].(time.Time)
+	return v, nil
+}
+
+// 相手の鍵の確認を取り消す
+func DeleteKeyVerification(verifierID string, targetID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(keyVerificationsCollection).Doc(keyVerificationID(verifierID, targetID)).Delete(ctx)
+	if err != nil {
+		log.Printf("鍵の確認の削除エラー: %v, verifierID=%s, targetID=%s", err, verifierID, targetID)
+		return err
+	}
+	return nil
+}
+
+// Firestoreの公開鍵のデータをドメインの構造体に変換
+func toIdentityKey(id string, data map[string]interface{}) domain.IdentityKey {
+	key := domain.IdentityKey{KeyID: id}
+	key.UserID, _ = data["user_id"].(string)
+	key.PublicKey, _ = data["public_key"].(string)
+	key.CreatedAt, _ = data["created_at"].(time.Time)
+	key.Current, _ = data["current"].(bool)
+	return key
+}
+
+// 暗号化されたメッセージの暗号文をFirestoreに保存する形式に変換
+func EncryptedEnvelopeToData(e domain.EncryptedEnvelope) map[string]interface{} {
+	return map[string]interface{}{
+		"version":          e.Version,
+		"sender_key_id":    e.SenderKeyID,
+		"recipient_key_id": e.RecipientKeyID,
+		"nonce":            e.Nonce,
+		"ciphertext":       e.Ciphertext,
+	}
+}
+
+// Firestoreの暗号文のデータをドメインの構造体に変換（暗号化されていない場合はnil）
+func ToEncryptedEnvelope(data interface{}) *domain.EncryptedEnvelope {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	e := &domain.EncryptedEnvelope{}
+	if version, ok := m["version"].(int64); ok {
+		e.Version = int(version)
+	}
+	e.SenderKeyID, _ = m["sender_key_id"].(string)
+	e.RecipientKeyID, _ = m["recipient_key_id"].(string)
+	e.Nonce, _ = m["nonce"].(string)
+	e.Ciphertext, _ = m["ciphertext"].(string)
+	return e
+}
@@ -0,0 +1,72 @@
+package firebase
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+)
+
+// 未読メッセージのメールを受け取るユーザーを取得する
+func GetEmailDigestUsers() ([]domain.User, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection("users").Where("EmailDigest", "==", true).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]domain.User, 0, len(docs))
+	for _, doc := range docs {
+		var user domain.User
+		if err := doc.DataTo(&user); err != nil {
+			continue
+		}
+		user.ID = doc.Ref.ID
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+// 期間内に相手から届いた未読メッセージを取得する（古い順）
+// afterより後、before以前に送信されたメッセージが対象
+func GetUnreadMessagesBetween(chatID string, userID string, after time.Time, before time.Time) ([]map[string]interface{}, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection("chats").Doc(chatID).Collection("messages").
+		Where("created_at", ">", after).
+		Where("created_at", "<=", before).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []map[string]interface{}
+	for _, doc := range docs {
+		data := doc.Data()
+		if isRead, _ := data["is_read"].(bool); isRead {
+			continue
+		}
+		if senderID, _ := data["sender_id"].(string); senderID == userID {
+			continue
+		}
+		messages = append(messages, data)
+	}
+	sort.Slice(messages, func(i, j int) bool {
+		ti, _ := messages[i]["created_at"].(time.Time)
+		tj, _ := messages[j]["created_at"].(time.Time)
+		return ti.Before(tj)
+	})
+	return messages, nil
+}
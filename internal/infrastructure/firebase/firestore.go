@@ -6,6 +6,9 @@ import (
 	"log"
 	"strings"
 	"time"
+
+	"security_chat_app/internal/domain"
+
 	"cloud.google.com/go/firestore"
 	"google.golang.org/api/iterator"
 )
@@ -171,6 +174,11 @@ func SearchUser(searchQuery string) ([]map[string]interface{}, error) {
 		data := doc.Data()
 		data["ID"] = doc.Ref.ID
 
+		// ボットのアカウントは検索結果に表示しない
+		if isBot, _ := data["IsBot"].(bool); isBot {
+			continue
+		}
+
 		// ユーザー名を取得
 		name, ok := data["Name"].(string)
 		if !ok {
@@ -226,9 +234,12 @@ func AddChatMessage(chatID string, message map[string]interface{}) error {
 
 	ctx := context.Background()
 
-	// メッセージIDを生成
-	messageID := fmt.Sprintf("msg_%d", time.Now().UnixNano())
-	message["id"] = messageID
+	// メッセージIDを生成（呼び出し元で採番済みの場合はそのIDを使用）
+	messageID, ok := message["id"].(string)
+	if !ok || messageID == "" {
+		messageID = fmt.Sprintf("msg_%d", time.Now().UnixNano())
+		message["id"] = messageID
+	}
 
 	// メッセージを保存
 	_, err = client.Collection("chats").Doc(chatID).Collection("messages").Doc(messageID).Set(ctx, message)
@@ -276,6 +287,52 @@ func GetChatMessages(chatID string) ([]map[string]interface{}, error) {
 	return messages, nil
 }
 
+// チャットのメッセージを古い順にページ単位で読み込み、ページごとにfnを呼び出す
+// 大量のメッセージを一度にメモリへ読み込まないようにするため、エクスポートなどで使用する
+func IterateChatMessages(chatID string, pageSize int, fn func(messages []map[string]interface{}) error) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	query := client.Collection("chats").Doc(chatID).Collection("messages").
+		OrderBy("created_at", firestore.Asc).
+		OrderBy(firestore.DocumentID, firestore.Asc).
+		Limit(pageSize)
+
+	var last *firestore.DocumentSnapshot
+	for {
+		q := query
+		if last != nil {
+			q = q.StartAfter(last)
+		}
+		docs, err := q.Documents(ctx).GetAll()
+		if err != nil {
+			return err
+		}
+		if len(docs) == 0 {
+			return nil
+		}
+
+		messages := make([]map[string]interface{}, 0, len(docs))
+		for _, doc := range docs {
+			data := doc.Data()
+			data["id"] = doc.Ref.ID
+			messages = append(messages, data)
+		}
+		if err := fn(messages); err != nil {
+			return err
+		}
+
+		if len(docs) < pageSize {
+			return nil
+		}
+		last = docs[len(docs)-1]
+	}
+}
+
 // チャットの存在確認
 func CheckChatExists(chatID string) (bool, error) {
 	client, err := InitFirebase()
@@ -358,3 +415,343 @@ func GetAllChats(userID string) ([]map[string]interface{}, error) {
 
 	return chats, nil
 }
+
+// チャットのメッセージを1件取得する
+func GetChatMessage(chatID string, messageID string) (map[string]interface{}, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection("chats").Doc(chatID).Collection("messages").Doc(messageID).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := doc.Data()
+	data["id"] = doc.Ref.ID
+	return data, nil
+}
+
+// チャットのメッセージのフィールドを更新する
+func UpdateChatMessage(chatID string, messageID string, updates map[string]interface{}) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	var fields []firestore.Update
+	for key, value := range updates {
+		fields = append(fields, firestore.Update{Path: key, Value: value})
+	}
+	_, err = client.Collection("chats").Doc(chatID).Collection("messages").Doc(messageID).Update(ctx, fields)
+	return err
+}
+
+// メッセージをピン留めする（上限数と重複はトランザクション内で検証する）
+func PinChatMessage(chatID string, pin map[string]interface{}, limit int) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	chatRef := client.Collection("chats").Doc(chatID)
+
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(chatRef)
+		if err != nil {
+			return err
+		}
+
+		pinned, _ := doc.Data()["pinned_messages"].([]interface{})
+		for _, p := range pinned {
+			if pm, ok := p.(map[string]interface{}); ok && pm["message_id"] == pin["message_id"] {
+				return domain.ErrAlreadyPinned
+			}
+		}
+		if len(pinned) >= limit {
+			return domain.ErrPinLimitExceeded
+		}
+
+		return tx.Update(chatRef, []firestore.Update{
+			{
+				Path:  "pinned_messages",
+				Value: append(pinned, pin),
+			},
+		})
+	})
+}
+
+// メッセージのピン留めを解除する
+func UnpinChatMessage(chatID string, messageID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	chatRef := client.Collection("chats").Doc(chatID)
+
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(chatRef)
+		if err != nil {
+			return err
+		}
+
+		pinned, _ := doc.Data()["pinned_messages"].([]interface{})
+		remaining := []interface{}{}
+		found := false
+		for _, p := range pinned {
+			if pm, ok := p.(map[string]interface{}); ok && pm["message_id"] == messageID {
+				found = true
+				continue
+			}
+			remaining = append(remaining, p)
+		}
+		if !found {
+			return domain.ErrNotPinned
+		}
+
+		return tx.Update(chatRef, []firestore.Update{
+			{
+				Path:  "pinned_messages",
+				Value: remaining,
+			},
+		})
+	})
+}
+
+// チャット参加者ごとの設定を更新する
+func UpdateChatSettings(chatID string, userID string, settings map[string]interface{}) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var updates []firestore.Update
+	for key, value := range settings {
+		updates = append(updates, firestore.Update{
+			FieldPath: firestore.FieldPath{"participant_settings", userID, key},
+			Value:     value,
+		})
+	}
+
+	ctx := context.Background()
+	_, err = client.Collection("chats").Doc(chatID).Update(ctx, updates)
+	if err != nil {
+		log.Printf("チャット設定の更新エラー: %v, chatID=%s, userID=%s", err, chatID, userID)
+		return err
+	}
+	return nil
+}
+
+// 相手から届いた未読メッセージを既読にする
+func MarkChatMessagesAsRead(chatID string, userID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	messagesRef := client.Collection("chats").Doc(chatID).Collection("messages")
+	docs, err := messagesRef.Where("is_read", "==", false).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	// バッチ書き込みは500件までのため分割してコミットする
+	batch := client.Batch()
+	count := 0
+	for _, doc := range docs {
+		if senderID, _ := doc.Data()["sender_id"].(string); senderID == userID {
+			continue
+		}
+		batch.Update(doc.Ref, []firestore.Update{{Path: "is_read", Value: true}})
+		count++
+		if count == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch = client.Batch()
+			count = 0
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+
+	_, err = batch.Commit(ctx)
+	return err
+}
+
+// 保持期間が設定されたチャットを全て取得する
+func GetChatsWithRetention() ([]map[string]interface{}, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	var policies []string
+	for _, p := range domain.RetentionPolicies() {
+		if p.Duration() > 0 {
+			policies = append(policies, string(p))
+		}
+	}
+
+	ctx := context.Background()
+	docs, err := client.Collection("chats").Where("retention", "in", policies).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var chats []map[string]interface{}
+	for _, doc := range docs {
+		data := doc.Data()
+		data["id"] = doc.Ref.ID
+		chats = append(chats, data)
+	}
+	return chats, nil
+}
+
+// 指定日時より前に作成されたメッセージを削除し、削除したメッセージを返す
+func DeleteMessagesBefore(chatID string, cutoff time.Time) ([]map[string]interface{}, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection("chats").Doc(chatID).Collection("messages").
+		Where("created_at", "<", cutoff).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []map[string]interface{}
+	for _, doc := range docs {
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			log.Printf("期限切れメッセージの削除エラー: %v, chatID=%s, messageID=%s", err, chatID, doc.Ref.ID)
+			continue
+		}
+		data := doc.Data()
+		data["id"] = doc.Ref.ID
+		deleted = append(deleted, data)
+	}
+	return deleted, nil
+}
+
+// 指定したIDのうち、チャットに既に存在するメッセージのIDを返す
+func GetExistingChatMessageIDs(chatID string, messageIDs []string) (map[string]bool, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	messagesRef := client.Collection("chats").Doc(chatID).Collection("messages")
+	existing := make(map[string]bool)
+
+	// 一度に取得する件数を制限して分割して取得する
+	for start := 0; start < len(messageIDs); start += 500 {
+		end := start + 500
+		if end > len(messageIDs) {
+			end = len(messageIDs)
+		}
+		var refs []*firestore.DocumentRef
+		for _, id := range messageIDs[start:end] {
+			refs = append(refs, messagesRef.Doc(id))
+		}
+		docs, err := client.GetAll(ctx, refs)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range docs {
+			if doc.Exists() {
+				existing[doc.Ref.ID] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+// 複数のメッセージをまとめてチャットに保存する（各メッセージのidをドキュメントIDとする）
+func AddChatMessages(chatID string, messages []map[string]interface{}) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	messagesRef := client.Collection("chats").Doc(chatID).Collection("messages")
+
+	// バッチ書き込みは500件までのため分割してコミットする
+	batch := client.Batch()
+	count := 0
+	for _, message := range messages {
+		messageID, _ := message["id"].(string)
+		if messageID == "" {
+			return fmt.Errorf("メッセージIDがありません")
+		}
+		batch.Set(messagesRef.Doc(messageID), message)
+		count++
+		if count == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch = client.Batch()
+			count = 0
+		}
+	}
+	if count > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return err
+		}
+	}
+
+	// チャットの更新時刻を更新
+	_, err = client.Collection("chats").Doc(chatID).Update(ctx, []firestore.Update{
+		{
+			Path:  "updated_at",
+			Value: time.Now(),
+		},
+	})
+	return err
+}
+
+// チャットのメッセージを削除し、削除したメッセージを返す
+func DeleteChatMessage(chatID string, messageID string) (map[string]interface{}, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection("chats").Doc(chatID).Collection("messages").Doc(messageID)
+	doc, err := ref.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ref.Delete(ctx); err != nil {
+		log.Printf("メッセージの削除エラー: %v, chatID=%s, messageID=%s", err, chatID, messageID)
+		return nil, err
+	}
+
+	data := doc.Data()
+	data["id"] = doc.Ref.ID
+	return data, nil
+}
@@ -0,0 +1,181 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 受信Webhookを保存するコレクション
+const incomingWebhooksCollection = "incoming_webhooks"
+
+// 受信Webhookを保存する
+func AddIncomingWebhook(webhook domain.IncomingWebhook) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(incomingWebhooksCollection).Doc(webhook.ID).Set(ctx, map[string]interface{}{
+		"id":         webhook.ID,
+		"chat_id":    webhook.ChatID,
+		"name":       webhook.Name,
+		"token_hash": webhook.TokenHash,
+		"created_by": webhook.CreatedBy,
+		"created_at": webhook.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("受信Webhookの保存エラー: %v", err)
+		return err
+	}
+	return nil
+}
+
+// 受信Webhookを取得する
+func GetIncomingWebhook(webhookID string) (*domain.IncomingWebhook, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(incomingWebhooksCollection).Doc(webhookID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrIncomingWebhookNotFound
+	}
+	webhook := toIncomingWebhook(doc.Ref.ID, doc.Data())
+	return &webhook, nil
+}
+
+// トークンのハッシュ値から受信Webhookを取得する
+func GetIncomingWebhookByTokenHash(tokenHash string) (*domain.IncomingWebhook, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(incomingWebhooksCollection).
+		Where("token_hash", "==", tokenHash).
+		Limit(1).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, domain.ErrInvalidIncomingWebhookToken
+	}
+	webhook := toIncomingWebhook(docs[0].Ref.ID, docs[0].Data())
+	return &webhook, nil
+}
+
+// チャットの受信Webhookを取得する（作成した順）
+func GetIncomingWebhooksByChat(chatID string) ([]domain.IncomingWebhook, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(incomingWebhooksCollection).
+		Where("chat_id", "==", chatID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]domain.IncomingWebhook, 0, len(docs))
+	for _, doc := range docs {
+		webhooks = append(webhooks, toIncomingWebhook(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// 受信Webhookを削除する（トークンは以降使用できなくなる。投稿済みのメッセージは残す）
+func DeleteIncomingWebhook(webhookID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(incomingWebhooksCollection).Doc(webhookID).Delete(ctx)
+	return err
+}
+
+// 受信Webhookの最後に投稿した日時を更新する
+func TouchIncomingWebhook(webhookID string, usedAt time.Time) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(incomingWebhooksCollection).Doc(webhookID).Update(ctx, []firestore.Update{
+		{Path: "last_used_at", Value: usedAt},
+	})
+	return err
+}
+
+// 添付をFirestoreに保存する形式に変換
+func AttachmentsToData(attachments []domain.MessageAttachment) []map[string]interface{} {
+	data := make([]map[string]interface{}, 0, len(attachments))
+	for _, a := range attachments {
+		data = append(data, map[string]interface{}{
+			"title":      a.Title,
+			"title_link": a.TitleLink,
+			"text":       a.Text,
+			"color":      a.Color,
+		})
+	}
+	return data
+}
+
+// Firestoreの添付のデータをドメインの構造体に変換（添付がない場合はnil）
+func ToAttachments(data interface{}) []domain.MessageAttachment {
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil
+	}
+	var attachments []domain.MessageAttachment
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		var a domain.MessageAttachment
+		a.Title, _ = m["title"].(string)
+		a.TitleLink, _ = m["title_link"].(string)
+		a.Text, _ = m["text"].(string)
+		a.Color, _ = m["color"].(string)
+		attachments = append(attachments, a)
+	}
+	return attachments
+}
+
+// Firestoreの受信Webhookのデータをドメインの構造体に変換
+func toIncomingWebhook(id string, data map[string]interface{}) domain.IncomingWebhook {
+	webhook := domain.IncomingWebhook{ID: id}
+	webhook.ChatID, _ = data["chat_id"].(string)
+	webhook.Name, _ = data["name"].(string)
+	webhook.TokenHash, _ = data["token_hash"].(string)
+	webhook.CreatedBy, _ = data["created_by"].(string)
+	webhook.CreatedAt, _ = data["created_at"].(time.Time)
+	webhook.LastUsedAt, _ = data["last_used_at"].(time.Time)
+	return webhook
+}
@@ -0,0 +1,113 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 通知を保存するコレクション
+const notificationsCollection = "notifications"
+
+// 通知を追加する
+func AddNotification(notification map[string]interface{}) (string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection(notificationsCollection).NewDoc()
+	notification["id"] = ref.ID
+
+	if _, err := ref.Set(ctx, notification); err != nil {
+		log.Printf("通知の保存エラー: %v", err)
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// ユーザーへの通知を取得する
+func GetNotifications(userID string, notificationType domain.NotificationType) ([]domain.Notification, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(notificationsCollection).
+		Where("user_id", "==", userID).
+		Where("type", "==", string(notificationType)).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.Notification
+	for _, doc := range docs {
+		results = append(results, toNotification(doc.Ref.ID, doc.Data()))
+	}
+	return results, nil
+}
+
+// ユーザーへの未読の通知をすべて既読にする
+func MarkNotificationsAsRead(userID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(notificationsCollection).
+		Where("user_id", "==", userID).
+		Where("is_read", "==", false).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	// バッチ書き込みは500件までのため分割してコミットする
+	batch := client.Batch()
+	count := 0
+	for _, doc := range docs {
+		batch.Update(doc.Ref, []firestore.Update{{Path: "is_read", Value: true}})
+		count++
+		if count == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return err
+			}
+			batch = client.Batch()
+			count = 0
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+
+	_, err = batch.Commit(ctx)
+	return err
+}
+
+// Firestoreの通知データをドメインの構造体に変換
+func toNotification(id string, data map[string]interface{}) domain.Notification {
+	n := domain.Notification{ID: id}
+	n.UserID, _ = data["user_id"].(string)
+	if t, ok := data["type"].(string); ok {
+		n.Type = domain.NotificationType(t)
+	}
+	n.ChatID, _ = data["chat_id"].(string)
+	n.MessageID, _ = data["message_id"].(string)
+	n.SenderID, _ = data["sender_id"].(string)
+	n.SenderName, _ = data["sender_name"].(string)
+	n.Content, _ = data["content"].(string)
+	n.IsRead, _ = data["is_read"].(bool)
+	n.CreatedAt, _ = data["created_at"].(time.Time)
+	return n
+}
@@ -0,0 +1,94 @@
+package firebase
+
+import (
+	"context"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 投票に投票する（1人1票で、同じ選択肢に投票した場合は取り消す）
+// 同時に投票されても票が失われないように、トランザクション内で更新する
+func VotePoll(chatID string, messageID string, userID string, option int) (*domain.Poll, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection("chats").Doc(chatID).Collection("messages").Doc(messageID)
+
+	var poll *domain.Poll
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return domain.ErrPollNotFound
+		}
+		data := doc.Data()
+		if messageType, _ := data["type"].(string); messageType != string(domain.MessageTypePoll) {
+			return domain.ErrNotPoll
+		}
+		poll = ToPoll(data["poll"])
+		if poll == nil {
+			return domain.ErrNotPoll
+		}
+		if err := poll.Vote(userID, option); err != nil {
+			return err
+		}
+		return tx.Update(ref, []firestore.Update{
+			{Path: "poll", Value: PollToData(*poll)},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return poll, nil
+}
+
+// 投票をFirestoreに保存する形式に変換
+func PollToData(poll domain.Poll) map[string]interface{} {
+	options := make([]map[string]interface{}, 0, len(poll.Options))
+	for _, o := range poll.Options {
+		votes := o.Votes
+		if votes == nil {
+			votes = []string{}
+		}
+		options = append(options, map[string]interface{}{
+			"text":  o.Text,
+			"votes": votes,
+		})
+	}
+	return map[string]interface{}{
+		"question": poll.Question,
+		"options":  options,
+	}
+}
+
+// Firestoreの投票のデータをドメインの構造体に変換（投票でない場合はnil）
+func ToPoll(data interface{}) *domain.Poll {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	poll := &domain.Poll{}
+	poll.Question, _ = m["question"].(string)
+	options, _ := m["options"].([]interface{})
+	for _, item := range options {
+		om, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		option := domain.PollOption{}
+		option.Text, _ = om["text"].(string)
+		votes, _ := om["votes"].([]interface{})
+		for _, v := range votes {
+			if id, ok := v.(string); ok {
+				option.Votes = append(option.Votes, id)
+			}
+		}
+		poll.Options = append(poll.Options, option)
+	}
+	return poll
+}
@@ -0,0 +1,69 @@
+package firebase
+
+import (
+	"context"
+	"log"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// ユーザーのプロフィールを更新する
+// プロフィールの各項目はユーザーのドキュメントのフィールドとしてまとめて更新する
+func UpdateProfile(userID string, profile domain.Profile) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	links := make([]map[string]interface{}, 0, len(profile.Links))
+	for _, link := range profile.Links {
+		links = append(links, map[string]interface{}{
+			"Label": link.Label,
+			"URL":   link.URL,
+		})
+	}
+
+	ctx := context.Background()
+	_, err = client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "Bio", Value: profile.Bio},
+		{Path: "StatusEmoji", Value: profile.StatusEmoji},
+		{Path: "StatusText", Value: profile.StatusText},
+		{Path: "StatusExpiresAt", Value: profile.StatusExpiresAt},
+		{Path: "Department", Value: profile.Department},
+		{Path: "JobTitle", Value: profile.JobTitle},
+		{Path: "Links", Value: links},
+	})
+	if err != nil {
+		log.Printf("プロフィールの更新エラー: %v, userID=%s", err, userID)
+		return err
+	}
+	return nil
+}
+
+// ユーザーの在席状況とおやすみモードのスケジュールを更新する
+func UpdateAvailability(userID string, availability domain.Availability, schedule domain.DNDSchedule) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "Availability", Value: string(availability)},
+		{Path: "DNDSchedule", Value: map[string]interface{}{
+			"Enabled":  schedule.Enabled,
+			"Start":    schedule.Start,
+			"End":      schedule.End,
+			"TimeZone": schedule.TimeZone,
+		}},
+	})
+	if err != nil {
+		log.Printf("在席状況の更新エラー: %v, userID=%s", err, userID)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+)
+
+// プッシュ通知の購読を保存するコレクション
+const pushSubscriptionsCollection = "push_subscriptions"
+
+// プッシュ通知の購読を保存する
+// 同じエンドポイント（同じブラウザ）の購読は上書きする
+func SavePushSubscription(sub domain.PushSubscription) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	data := map[string]interface{}{
+		"id":         sub.ID,
+		"user_id":    sub.UserID,
+		"endpoint":   sub.Endpoint,
+		"p256dh":     sub.P256dh,
+		"auth":       sub.Auth,
+		"user_agent": sub.UserAgent,
+		"created_at": sub.CreatedAt,
+	}
+	// 有効期限のない購読は期限切れの検索の対象にしない
+	if !sub.ExpiresAt.IsZero() {
+		data["expires_at"] = sub.ExpiresAt
+	}
+
+	ctx := context.Background()
+	_, err = client.Collection(pushSubscriptionsCollection).Doc(sub.ID).Set(ctx, data)
+	if err != nil {
+		log.Printf("プッシュ通知の購読の保存エラー: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ユーザーのプッシュ通知の購読を取得する（登録した順）
+func GetPushSubscriptionsByUser(userID string) ([]domain.PushSubscription, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(pushSubscriptionsCollection).
+		Where("user_id", "==", userID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]domain.PushSubscription, 0, len(docs))
+	for _, doc := range docs {
+		subs = append(subs, toPushSubscription(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].CreatedAt.Before(subs[j].CreatedAt)
+	})
+	return subs, nil
+}
+
+// プッシュ通知の購読を取得する
+func GetPushSubscription(subscriptionID string) (*domain.PushSubscription, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(pushSubscriptionsCollection).Doc(subscriptionID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrPushSubscriptionNotFound
+	}
+	sub := toPushSubscription(doc.Ref.ID, doc.Data())
+	return &sub, nil
+}
+
+// プッシュ通知の購読を削除する
+func DeletePushSubscription(subscriptionID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(pushSubscriptionsCollection).Doc(subscriptionID).Delete(ctx)
+	return err
+}
+
+// 有効期限が切れたプッシュ通知の購読を削除し、削除した件数を返す
+func DeleteExpiredPushSubscriptions(now time.Time) (int, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(pushSubscriptionsCollection).
+		Where("expires_at", "<=", now).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	// バッチ書き込みは500件までのため分割してコミットする
+	batch := client.Batch()
+	count := 0
+	deleted := 0
+	for _, doc := range docs {
+		batch.Delete(doc.Ref)
+		count++
+		if count == 500 {
+			if _, err := batch.Commit(ctx); err != nil {
+				return deleted, err
+			}
+			deleted += count
+			batch = client.Batch()
+			count = 0
+		}
+	}
+	if count == 0 {
+		return deleted, nil
+	}
+
+	if _, err := batch.Commit(ctx); err != nil {
+		return deleted, err
+	}
+	return deleted + count, nil
+}
+
+// プッシュ通知の購読の最後に通知した日時を更新する
+func TouchPushSubscription(subscriptionID string, usedAt time.Time) error {
+	return UpdateField(pushSubscriptionsCollection, subscriptionID, "last_used_at", usedAt)
+}
+
+// Firestoreのデータをプッシュ通知の購読に変換する
+func toPushSubscription(id string, data map[string]interface{}) domain.PushSubscription {
+	sub := domain.PushSubscription{ID: id}
+	sub.UserID, _ = data["user_id"].(string)
+	sub.Endpoint, _ = data["endpoint"].(string)
+	sub.P256dh, _ = data["p256dh"].(string)
+	sub.Auth, _ = data["auth"].(string)
+	sub.UserAgent, _ = data["user_agent"].(string)
+	sub.ExpiresAt, _ = data["expires_at"].(time.Time)
+	sub.CreatedAt, _ = data["created_at"].(time.Time)
+	sub.LastUsedAt, _ = data["last_used_at"].(time.Time)
+	return sub
+}
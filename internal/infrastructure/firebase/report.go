@@ -0,0 +1,279 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 通報と監査ログを保存するコレクション
+const (
+	reportsCollection   = "reports"
+	auditLogsCollection = "audit_logs"
+)
+
+// 通報を追加する
+func AddReport(report domain.Report) (string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection(reportsCollection).NewDoc()
+	_, err = ref.Set(ctx, map[string]interface{}{
+		"id":                 ref.ID,
+		"target_type":        string(report.TargetType),
+		"reporter_id":        report.ReporterID,
+		"reporter_name":      report.ReporterName,
+		"reported_user_id":   report.ReportedUserID,
+		"reported_user_name": report.ReportedUserName,
+		"chat_id":            report.ChatID,
+		"message_id":         report.MessageID,
+		"reason":             string(report.Reason),
+		"comment":            report.Comment,
+		"snapshot":           report.Snapshot,
+		"snapshot_media_url": report.SnapshotMediaURL,
+		"status":             string(domain.ReportStatusOpen),
+		"created_at":         report.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("通報の保存エラー: %v", err)
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// 状態を指定して通報を取得する（新しい順）
+func GetReports(status domain.ReportStatus) ([]domain.Report, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(reportsCollection).Where("status", "==", string(status)).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []domain.Report
+	for _, doc := range docs {
+		reports = append(reports, toReport(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CreatedAt.After(reports[j].CreatedAt)
+	})
+	return reports, nil
+}
+
+// 通報を取得する
+func GetReport(reportID string) (*domain.Report, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(reportsCollection).Doc(reportID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrReportNotFound
+	}
+	report := toReport(doc.Ref.ID, doc.Data())
+	return &report, nil
+}
+
+// 対応待ちの通報を対応済みにする
+// 複数の管理者が同時に操作しても、1件の通報は1度しか対応されない
+func ClaimReport(reportID string, action domain.ModerationAction, adminID string) (*domain.Report, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection(reportsCollection).Doc(reportID)
+
+	var report domain.Report
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return domain.ErrReportNotFound
+		}
+		report = toReport(doc.Ref.ID, doc.Data())
+		if err := report.CanApply(action); err != nil {
+			return err
+		}
+
+		report.Status = action.ResultStatus()
+		report.Action = action
+		report.HandledBy = adminID
+		report.HandledAt = time.Now()
+		return tx.Update(ref, []firestore.Update{
+			{Path: "status", Value: string(report.Status)},
+			{Path: "action", Value: string(report.Action)},
+			{Path: "handled_by", Value: report.HandledBy},
+			{Path: "handled_at", Value: report.HandledAt},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// 対応に失敗した通報を対応待ちに戻す
+func ReopenReport(reportID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(reportsCollection).Doc(reportID).Update(ctx, []firestore.Update{
+		{Path: "status", Value: string(domain.ReportStatusOpen)},
+		{Path: "action", Value: firestore.Delete},
+		{Path: "handled_by", Value: firestore.Delete},
+		{Path: "handled_at", Value: firestore.Delete},
+	})
+	return err
+}
+
+// アカウントを停止し、ログイン中のセッションを全て削除する
+func SuspendUser(userID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection("users").Doc(userID).Update(ctx, []firestore.Update{
+		{Path: "IsSuspended", Value: true},
+		{Path: "SuspendedAt", Value: time.Now()},
+		{Path: "IsOnline", Value: false},
+	})
+	if err != nil {
+		log.Printf("アカウントの停止エラー: %v, userID=%s", err, userID)
+		return err
+	}
+
+	// セッションにはログイン時のユーザー情報が保存されている
+	docs, err := client.Collection("sessions").Where("User.ID", "==", userID).Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		if _, err := doc.Ref.Delete(ctx); err != nil {
+			log.Printf("セッションの削除エラー: %v, userID=%s", err, userID)
+			return err
+		}
+	}
+	return nil
+}
+
+// 監査ログを追加する
+func AddAuditLog(entry domain.AuditLog) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection(auditLogsCollection).NewDoc()
+	_, err = ref.Set(ctx, map[string]interface{}{
+		"id":             ref.ID,
+		"action":         string(entry.Action),
+		"actor_id":       entry.ActorID,
+		"actor_name":     entry.ActorName,
+		"report_id":      entry.ReportID,
+		"target_user_id": entry.TargetUserID,
+		"chat_id":        entry.ChatID,
+		"message_id":     entry.MessageID,
+		"detail":         entry.Detail,
+		"created_at":     entry.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("監査ログの保存エラー: %v", err)
+		return err
+	}
+	return nil
+}
+
+// 新しい順に監査ログを取得する
+func GetAuditLogs(limit int) ([]domain.AuditLog, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(auditLogsCollection).
+		OrderBy("created_at", firestore.Desc).
+		Limit(limit).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var logs []domain.AuditLog
+	for _, doc := range docs {
+		data := doc.Data()
+		entry := domain.AuditLog{ID: doc.Ref.ID}
+		if action, ok := data["action"].(string); ok {
+			entry.Action = domain.AuditAction(action)
+		}
+		entry.ActorID, _ = data["actor_id"].(string)
+		entry.ActorName, _ = data["actor_name"].(string)
+		entry.ReportID, _ = data["report_id"].(string)
+		entry.TargetUserID, _ = data["target_user_id"].(string)
+		entry.ChatID, _ = data["chat_id"].(string)
+		entry.MessageID, _ = data["message_id"].(string)
+		entry.Detail, _ = data["detail"].(string)
+		entry.CreatedAt, _ = data["created_at"].(time.Time)
+		logs = append(logs, entry)
+	}
+	return logs, nil
+}
+
+// Firestoreの通報データをドメインの構造体に変換
+func toReport(id string, data map[string]interface{}) domain.Report {
+	report := domain.Report{ID: id}
+	if target, ok := data["target_type"].(string); ok {
+		report.TargetType = domain.ReportTarget(target)
+	}
+	report.ReporterID, _ = data["reporter_id"].(string)
+	report.ReporterName, _ = data["reporter_name"].(string)
+	report.ReportedUserID, _ = data["reported_user_id"].(string)
+	report.ReportedUserName, _ = data["reported_user_name"].(string)
+	report.ChatID, _ = data["chat_id"].(string)
+	report.MessageID, _ = data["message_id"].(string)
+	if reason, ok := data["reason"].(string); ok {
+		report.Reason = domain.ReportReason(reason)
+	}
+	report.Comment, _ = data["comment"].(string)
+	report.Snapshot, _ = data["snapshot"].(string)
+	report.SnapshotMediaURL, _ = data["snapshot_media_url"].(string)
+	if status, ok := data["status"].(string); ok {
+		report.Status = domain.ReportStatus(status)
+	}
+	if action, ok := data["action"].(string); ok {
+		report.Action = domain.ModerationAction(action)
+	}
+	report.HandledBy, _ = data["handled_by"].(string)
+	report.HandledAt, _ = data["handled_at"].(time.Time)
+	report.CreatedAt, _ = data["created_at"].(time.Time)
+	return report
+}
@@ -0,0 +1,197 @@
+package firebase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// 予約メッセージを保存するコレクション
+const scheduledMessagesCollection = "scheduled_messages"
+
+// 予約メッセージを追加する
+func AddScheduledMessage(message map[string]interface{}) (string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	scheduleID := fmt.Sprintf("sched_%d", time.Now().UnixNano())
+	message["id"] = scheduleID
+
+	_, err = client.Collection(scheduledMessagesCollection).Doc(scheduleID).Set(ctx, message)
+	if err != nil {
+		log.Printf("予約メッセージの保存エラー: %v", err)
+		return "", err
+	}
+	return scheduleID, nil
+}
+
+// 送信者がチャットに予約している送信待ちのメッセージを取得する
+func GetPendingScheduledMessages(chatID string, senderID string) ([]domain.ScheduledMessage, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(scheduledMessagesCollection).
+		Where("chat_id", "==", chatID).
+		Where("sender_id", "==", senderID).
+		Where("status", "==", string(domain.ScheduleStatusPending)).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []domain.ScheduledMessage
+	for _, doc := range docs {
+		results = append(results, toScheduledMessage(doc.Ref.ID, doc.Data()))
+	}
+	return results, nil
+}
+
+// 送信待ちの予約メッセージを更新する（送信者本人かつ送信待ちの場合のみ）
+func UpdatePendingScheduledMessage(scheduleID string, senderID string, updates map[string]interface{}) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	ref := client.Collection(scheduledMessagesCollection).Doc(scheduleID)
+
+	return client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		doc, err := tx.Get(ref)
+		if err != nil {
+			return domain.ErrScheduleNotFound
+		}
+
+		data := doc.Data()
+		if owner, _ := data["sender_id"].(string); owner != senderID {
+			return domain.ErrScheduleNotFound
+		}
+		if status, _ := data["status"].(string); status != string(domain.ScheduleStatusPending) {
+			return domain.ErrScheduleNotPending
+		}
+
+		var fields []firestore.Update
+		for key, value := range updates {
+			fields = append(fields, firestore.Update{Path: key, Value: value})
+		}
+		fields = append(fields, firestore.Update{Path: "updated_at", Value: time.Now()})
+		return tx.Update(ref, fields)
+	})
+}
+
+// 送信時刻を過ぎた予約メッセージを送信処理中として確保する
+// 複数のサーバーから同時に実行されても、1件の予約は1度しか確保されない
+func ClaimDueScheduledMessages(now time.Time, limit int) ([]domain.ScheduledMessage, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	iter := client.Collection(scheduledMessagesCollection).
+		Where("status", "==", string(domain.ScheduleStatusPending)).
+		Where("send_at", "<=", now).
+		Limit(limit).
+		Documents(ctx)
+	defer iter.Stop()
+
+	var claimed []domain.ScheduledMessage
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return claimed, err
+		}
+
+		var data map[string]interface{}
+		err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+			snap, err := tx.Get(doc.Ref)
+			if err != nil {
+				return err
+			}
+			if status, _ := snap.Data()["status"].(string); status != string(domain.ScheduleStatusPending) {
+				data = nil
+				return nil
+			}
+			data = snap.Data()
+			return tx.Update(doc.Ref, []firestore.Update{
+				{Path: "status", Value: string(domain.ScheduleStatusSending)},
+				{Path: "claimed_at", Value: time.Now()},
+			})
+		})
+		if err != nil {
+			log.Printf("予約メッセージの確保に失敗: scheduleID=%s, error=%v", doc.Ref.ID, err)
+			continue
+		}
+		if data != nil {
+			claimed = append(claimed, toScheduledMessage(doc.Ref.ID, data))
+		}
+	}
+	return claimed, nil
+}
+
+// 送信処理中のまま一定時間経過した予約メッセージを送信待ちに戻す
+// 送信処理中にサーバーが停止した場合でも、再起動後に配信されるようにする
+func RequeueStaleScheduledMessages(claimedBefore time.Time) (int, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(scheduledMessagesCollection).
+		Where("status", "==", string(domain.ScheduleStatusSending)).
+		Where("claimed_at", "<", claimedBefore).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, doc := range docs {
+		_, err := doc.Ref.Update(ctx, []firestore.Update{
+			{Path: "status", Value: string(domain.ScheduleStatusPending)},
+		})
+		if err != nil {
+			log.Printf("予約メッセージの再登録に失敗: scheduleID=%s, error=%v", doc.Ref.ID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Firestoreの予約メッセージデータをドメインの構造体に変換する
+func toScheduledMessage(id string, data map[string]interface{}) domain.ScheduledMessage {
+	message := domain.ScheduledMessage{ID: id}
+	message.ChatID, _ = data["chat_id"].(string)
+	message.SenderID, _ = data["sender_id"].(string)
+	message.SenderName, _ = data["sender_name"].(string)
+	message.Content, _ = data["content"].(string)
+	message.SendAt, _ = data["send_at"].(time.Time)
+	message.CreatedAt, _ = data["created_at"].(time.Time)
+	message.UpdatedAt, _ = data["updated_at"].(time.Time)
+	if status, ok := data["status"].(string); ok {
+		message.Status = domain.ScheduleStatus(status)
+	}
+	return message
+}
@@ -83,3 +83,68 @@ func GetDefaultIconURL(objectPath string) (string, error) {
 	url := fmt.Sprintf("https://firebasestorage.googleapis.com/v0/b/%s/o/%s?alt=media", config.Config.StorageBucket, url.PathEscape(objectPath))
 	return url, nil
 }
+
+// Storageのメディアを削除する
+func DeleteMedia(objectPath string) error {
+	opt := option.WithCredentialsFile(config.Config.ServiceKeyPath)
+	config := &firebase.Config{
+		ProjectID:     config.Config.ProjectId,
+		StorageBucket: config.Config.StorageBucket,
+	}
+
+	app, err := firebase.NewApp(context.Background(), config, opt)
+	if err != nil {
+		return fmt.Errorf("firebaseアプリの初期化に失敗しました: %v", err)
+	}
+
+	client, err := app.Storage(context.Background())
+	if err != nil {
+		return fmt.Errorf("storageクライアントの作成に失敗しました: %v", err)
+	}
+
+	bucket, err := client.DefaultBucket()
+	if err != nil {
+		return fmt.Errorf("バケットの取得に失敗しました: %v", err)
+	}
+
+	// 既に削除済みの場合は成功として扱う
+	err = bucket.Object(objectPath).Delete(context.Background())
+	if err != nil && err != storage.ErrObjectNotExist {
+		return fmt.Errorf("メディアの削除に失敗しました: %v", err)
+	}
+	return nil
+}
+
+// Storageのメディアを別のパスに複製する（複製したメディアの公開URLを返す）
+func CopyMedia(srcPath string, dstPath string) (string, error) {
+	opt := option.WithCredentialsFile(config.Config.ServiceKeyPath)
+	config := &firebase.Config{
+		ProjectID:     config.Config.ProjectId,
+		StorageBucket: config.Config.StorageBucket,
+	}
+
+	app, err := firebase.NewApp(context.Background(), config, opt)
+	if err != nil {
+		return "", fmt.Errorf("firebaseアプリの初期化に失敗しました: %v", err)
+	}
+
+	client, err := app.Storage(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("storageクライアントの作成に失敗しました: %v", err)
+	}
+
+	bucket, err := client.DefaultBucket()
+	if err != nil {
+		return "", fmt.Errorf("バケットの取得に失敗しました: %v", err)
+	}
+
+	// 元のメディアと同じ公開設定で複製
+	copier := bucket.Object(dstPath).CopierFrom(bucket.Object(srcPath))
+	copier.ACL = []storage.ACLRule{{Entity: storage.AllUsers, Role: storage.RoleReader}}
+	attrs, err := copier.Run(context.Background())
+	if err != nil {
+		return "", fmt.Errorf("メディアの複製に失敗しました: %v", err)
+	}
+
+	return attrs.MediaLink, nil
+}
@@ -0,0 +1,270 @@
+package firebase
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+
+	"cloud.google.com/go/firestore"
+)
+
+// 送信するWebhookと配信履歴を保存するコレクション
+const (
+	webhooksCollection          = "webhooks"
+	webhookDeliveriesCollection = "webhook_deliveries"
+)
+
+// Webhookを追加する
+func AddWebhook(webhook domain.Webhook) (string, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	events := make([]string, 0, len(webhook.Events))
+	for _, e := range webhook.Events {
+		events = append(events, string(e))
+	}
+	ref := client.Collection(webhooksCollection).NewDoc()
+	_, err = ref.Set(ctx, map[string]interface{}{
+		"id":         ref.ID,
+		"chat_id":    webhook.ChatID,
+		"url":        webhook.URL,
+		"secret":     webhook.Secret,
+		"events":     events,
+		"enabled":    webhook.Enabled,
+		"created_by": webhook.CreatedBy,
+		"created_at": webhook.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("Webhookの保存エラー: %v", err)
+		return "", err
+	}
+	return ref.ID, nil
+}
+
+// Webhookを取得する
+func GetWebhook(webhookID string) (*domain.Webhook, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	doc, err := client.Collection(webhooksCollection).Doc(webhookID).Get(ctx)
+	if err != nil {
+		return nil, domain.ErrWebhookNotFound
+	}
+	webhook := toWebhook(doc.Ref.ID, doc.Data())
+	return &webhook, nil
+}
+
+// チャットに登録されたWebhookを取得する（登録した順）
+// chatIDが空の場合は全体のWebhookを取得する
+func GetWebhooksByChat(chatID string) ([]domain.Webhook, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(webhooksCollection).
+		Where("chat_id", "==", chatID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]domain.Webhook, 0, len(docs))
+	for _, doc := range docs {
+		webhooks = append(webhooks, toWebhook(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt)
+	})
+	return webhooks, nil
+}
+
+// チャットのイベントを通知するWebhookを取得する（チャットのWebhookと全体のWebhook）
+func GetWebhooksForChat(chatID string) ([]domain.Webhook, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(webhooksCollection).
+		Where("chat_id", "in", []string{chatID, ""}).
+		Where("enabled", "==", true).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := make([]domain.Webhook, 0, len(docs))
+	for _, doc := range docs {
+		webhooks = append(webhooks, toWebhook(doc.Ref.ID, doc.Data()))
+	}
+	return webhooks, nil
+}
+
+// Webhookの有効・無効を切り替える
+func SetWebhookEnabled(webhookID string, enabled bool) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	_, err = client.Collection(webhooksCollection).Doc(webhookID).Update(ctx, []firestore.Update{
+		{Path: "enabled", Value: enabled},
+	})
+	return err
+}
+
+// Webhookを削除する（配信履歴も削除する）
+func DeleteWebhook(webhookID string) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	deliveries, err := client.Collection(webhookDeliveriesCollection).
+		Where("webhook_id", "==", webhookID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return err
+	}
+
+	batch := client.Batch()
+	for _, doc := range deliveries {
+		batch.Delete(doc.Ref)
+	}
+	batch.Delete(client.Collection(webhooksCollection).Doc(webhookID))
+	_, err = batch.Commit(ctx)
+	return err
+}
+
+// 配信の結果を記録する
+// 配信履歴を追加し、Webhookの最後の配信の状態を更新する
+func RecordWebhookDelivery(delivery domain.WebhookDelivery) error {
+	client, err := InitFirebase()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	batch := client.Batch()
+	batch.Set(client.Collection(webhookDeliveriesCollection).Doc(delivery.ID), map[string]interface{}{
+		"id":           delivery.ID,
+		"webhook_id":   delivery.WebhookID,
+		"event":        string(delivery.Event),
+		"status":       string(delivery.Status),
+		"attempts":     delivery.Attempts,
+		"status_code":  delivery.StatusCode,
+		"error":        delivery.Error,
+		"created_at":   delivery.CreatedAt,
+		"completed_at": delivery.CompletedAt,
+	})
+	batch.Update(client.Collection(webhooksCollection).Doc(delivery.WebhookID), []firestore.Update{
+		{Path: "last_status", Value: string(delivery.Status)},
+		{Path: "last_status_code", Value: delivery.StatusCode},
+		{Path: "last_error", Value: delivery.Error},
+		{Path: "last_delivered_at", Value: delivery.CompletedAt},
+	})
+	if _, err := batch.Commit(ctx); err != nil {
+		log.Printf("Webhookの配信履歴の保存エラー: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Webhookの配信履歴を取得する（新しい順）
+func GetWebhookDeliveries(webhookID string, limit int) ([]domain.WebhookDelivery, error) {
+	client, err := InitFirebase()
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	docs, err := client.Collection(webhookDeliveriesCollection).
+		Where("webhook_id", "==", webhookID).
+		Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(docs))
+	for _, doc := range docs {
+		deliveries = append(deliveries, toWebhookDelivery(doc.Ref.ID, doc.Data()))
+	}
+	sort.Slice(deliveries, func(i, j int) bool {
+		return deliveries[i].CreatedAt.After(deliveries[j].CreatedAt)
+	})
+	if limit > 0 && len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+	return deliveries, nil
+}
+
+// FirestoreのWebhookのデータをドメインの構造体に変換
+func toWebhook(id string, data map[string]interface{}) domain.Webhook {
+	webhook := domain.Webhook{ID: id}
+	webhook.ChatID, _ = data["chat_id"].(string)
+	webhook.URL, _ = data["url"].(string)
+	webhook.Secret, _ = data["secret"].(string)
+	webhook.Enabled, _ = data["enabled"].(bool)
+	webhook.CreatedBy, _ = data["created_by"].(string)
+	webhook.CreatedAt, _ = data["created_at"].(time.Time)
+	webhook.LastError, _ = data["last_error"].(string)
+	webhook.LastDeliveredAt, _ = data["last_delivered_at"].(time.Time)
+	if status, ok := data["last_status"].(string); ok {
+		webhook.LastStatus = domain.WebhookDeliveryStatus(status)
+	}
+	if code, ok := data["last_status_code"].(int64); ok {
+		webhook.LastStatusCode = int(code)
+	}
+	if events, ok := data["events"].([]interface{}); ok {
+		for _, e := range events {
+			if s, ok := e.(string); ok {
+				webhook.Events = append(webhook.Events, domain.WebhookEvent(s))
+			}
+		}
+	}
+	return webhook
+}
+
+// Firestoreの配信履歴のデータをドメインの構造体に変換
+func toWebhookDelivery(id string, data map[string]interface{}) domain.WebhookDelivery {
+	delivery := domain.WebhookDelivery{ID: id}
+	delivery.WebhookID, _ = data["webhook_id"].(string)
+	delivery.Error, _ = data["error"].(string)
+	delivery.CreatedAt, _ = data["created_at"].(time.Time)
+	delivery.CompletedAt, _ = data["completed_at"].(time.Time)
+	if event, ok := data["event"].(string); ok {
+		delivery.Event = domain.WebhookEvent(event)
+	}
+	if status, ok := data["status"].(string); ok {
+		delivery.Status = domain.WebhookDeliveryStatus(status)
+	}
+	if attempts, ok := data["attempts"].(int64); ok {
+		delivery.Attempts = int(attempts)
+	}
+	if code, ok := data["status_code"].(int64); ok {
+		delivery.StatusCode = int(code)
+	}
+	return delivery
+}
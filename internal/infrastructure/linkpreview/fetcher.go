@@ -0,0 +1,315 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/netguard"
+
+	"golang.org/x/net/html"
+)
+
+// プレビューの取得に関するエラー
+var (
+	ErrUnsupportedURL     = errors.New("プレビューできないURLです")
+	ErrForbiddenAddress   = netguard.ErrForbiddenAddress
+	ErrUnsupportedContent = errors.New("HTML以外のページはプレビューできません")
+)
+
+// 本文中のURL
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// Fetcher 外部のページを安全に取得してプレビューを作成する
+type Fetcher struct {
+	client      *http.Client
+	control     dialControl   // 接続先のアドレスを検証する処理
+	maxBodySize int64         // 読み込むレスポンスの最大サイズ（バイト）
+	cacheTTL    time.Duration // 取得結果をキャッシュする期間
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// 接続先のアドレスを検証する処理（net.DialerのControlと同じ形式）
+type dialControl func(network, address string, c syscall.RawConn) error
+
+// キャッシュした取得結果
+type cacheEntry struct {
+	preview   *domain.LinkPreview
+	err       error
+	expiresAt time.Time
+}
+
+// キャッシュする最大件数
+const maxCacheEntries = 1000
+
+// NewFetcher プレビューの取得処理を生成する
+// timeoutはリダイレクトを含めた1回の取得全体の制限時間
+func NewFetcher(timeout time.Duration, maxBodySize int64, cacheTTL time.Duration) *Fetcher {
+	return newFetcher(timeout, maxBodySize, cacheTTL, netguard.DialControl("80", "443"))
+}
+
+// 接続先のアドレスの検証処理を指定して生成する（テストではループバックのサーバーに接続できるものに差し替える）
+func newFetcher(timeout time.Duration, maxBodySize int64, cacheTTL time.Duration, control dialControl) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// 名前解決後の接続先アドレスを検証する（DNSリバインディング対策）
+		Control: control,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // 環境変数のプロキシを経由させない
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 3 {
+					return errors.New("リダイレクトが多すぎます")
+				}
+				if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+					return ErrUnsupportedURL
+				}
+				return nil
+			},
+		},
+		control:     control,
+		maxBodySize: maxBodySize,
+		cacheTTL:    cacheTTL,
+		cache:       make(map[string]cacheEntry),
+	}
+}
+
+// FindURL 本文中の最初のURLを取得する（見つからない場合は空文字）
+func FindURL(content string) string {
+	found := urlPattern.FindString(content)
+	return strings.TrimRight(found, ".,:;!?)]'*_")
+}
+
+// Fetch URLのページを取得してプレビューを作成する（取得結果はキャッシュする）
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	if entry, ok := f.cached(rawURL); ok {
+		return entry.preview, entry.err
+	}
+
+	preview, err := f.fetch(ctx, rawURL)
+
+	// 失敗した場合もキャッシュして、同じURLへの取得を繰り返さない（呼び出し元による中断は除く）
+	if ctx.Err() == nil {
+		f.store(rawURL, cacheEntry{preview: preview, err: err, expiresAt: time.Now().Add(f.cacheTTL)})
+	}
+	return preview, err
+}
+
+// ページを取得してOpenGraphの情報を読み取る
+func (f *Fetcher) fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	pageURL, err := url.Parse(rawURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") || pageURL.Hostname() == "" {
+		return nil, ErrUnsupportedURL
+	}
+	// IPアドレスを直接指定した場合も接続前に拒否する
+	if ip := net.ParseIP(pageURL.Hostname()); ip != nil {
+		port := pageURL.Port()
+		if port == "" {
+			port = "80"
+			if pageURL.Scheme == "https" {
+				port = "443"
+			}
+		}
+		if err := f.control("tcp", net.JoinHostPort(ip.String(), port), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "ChatAppLinkPreview/1.0")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ページの取得に失敗しました: status=%d", resp.StatusCode)
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "text/html" && mediaType != "application/xhtml+xml") {
+		return nil, ErrUnsupportedContent
+	}
+
+	// 上限を超えた部分は読み込まない
+	preview := parseHTML(io.LimitReader(resp.Body, f.maxBodySize), resp.Request.URL)
+	preview.URL = rawURL
+	preview.FetchedAt = time.Now()
+	return preview, nil
+}
+
+// HTMLからOpenGraphのタグ（無い場合はtitleとdescription）を読み取る
+func parseHTML(r io.Reader, pageURL *url.URL) *domain.LinkPreview {
+	og := make(map[string]string)
+	var title, description string
+	inTitle := false
+
+	z := html.NewTokenizer(r)
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return buildPreview(og, title, description, pageURL)
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			switch token.Data {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "meta":
+				var property, name, content string
+				for _, attr := range token.Attr {
+					switch strings.ToLower(attr.Key) {
+					case "property":
+						property = strings.ToLower(attr.Val)
+					case "name":
+						name = strings.ToLower(attr.Val)
+					case "content":
+						content = attr.Val
+					}
+				}
+				if strings.HasPrefix(property, "og:") && og[property] == "" {
+					og[property] = content
+				}
+				if name == "description" && description == "" {
+					description = content
+				}
+			case "body":
+				// メタ情報はheadにあるため、bodyより後は読まない
+				return buildPreview(og, title, description, pageURL)
+			}
+
+		case html.TextToken:
+			if inTitle && title == "" {
+				title = string(z.Text())
+			}
+
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "title":
+				inTitle = false
+			case "head":
+				return buildPreview(og, title, description, pageURL)
+			}
+		}
+	}
+}
+
+// 読み取った情報からプレビューを作成する
+func buildPreview(og map[string]string, title string, description string, pageURL *url.URL) *domain.LinkPreview {
+	preview := &domain.LinkPreview{
+		Title:       truncate(firstNonEmpty(og["og:title"], title), 200),
+		Description: truncate(firstNonEmpty(og["og:description"], description), 500),
+		SiteName:    truncate(firstNonEmpty(og["og:site_name"], pageURL.Hostname()), 100),
+	}
+
+	// 画像のURLはページのURLを基準に解決し、http(s)のみ許可する
+	if image := strings.TrimSpace(og["og:image"]); image != "" {
+		if imageURL, err := pageURL.Parse(image); err == nil && (imageURL.Scheme == "http" || imageURL.Scheme == "https") {
+			preview.ImageURL = imageURL.String()
+		}
+	}
+	return preview
+}
+
+// キャッシュから取得結果を取り出す
+func (f *Fetcher) cached(rawURL string) (cacheEntry, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entry, ok := f.cache[rawURL]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// 取得結果をキャッシュする
+func (f *Fetcher) store(rawURL string, entry cacheEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 上限に達した場合は期限切れのものを削除し、それでも足りなければ全て削除する
+	if len(f.cache) >= maxCacheEntries {
+		now := time.Now()
+		for key, e := range f.cache {
+			if now.After(e.expiresAt) {
+				delete(f.cache, key)
+			}
+		}
+		if len(f.cache) >= maxCacheEntries {
+			f.cache = make(map[string]cacheEntry)
+		}
+	}
+	f.cache[rawURL] = entry
+}
+
+// 空でない最初の値を返す
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// 文字数（ルーン数）で切り詰める
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max]) + "…"
+}
+
+// 既定の取得処理（5秒・1MB・1時間のキャッシュ）
+var defaultFetcher = NewFetcher(5*time.Second, 1<<20, time.Hour)
+
+// Fetch 既定の取得処理でURLのプレビューを作成する
+func Fetch(ctx context.Context, rawURL string) (*domain.LinkPreview, error) {
+	return defaultFetcher.Fetch(ctx, rawURL)
+}
+
+// ToData プレビューをFirestoreに保存する形式に変換する
+func ToData(p *domain.LinkPreview) map[string]interface{} {
+	return map[string]interface{}{
+		"url":         p.URL,
+		"title":       p.Title,
+		"description": p.Description,
+		"image_url":   p.ImageURL,
+		"site_name":   p.SiteName,
+		"fetched_at":  p.FetchedAt,
+	}
+}
@@ -0,0 +1,263 @@
+package linkpreview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// ループバックのアドレスへの接続だけを許可する（テスト用のサーバーに接続するため）
+func allowLoopback(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+func newTestFetcher(maxBodySize int64) *Fetcher {
+	return newFetcher(5*time.Second, maxBodySize, time.Hour, allowLoopback)
+}
+
+func TestFetchOpenGraph(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<!DOCTYPE html>
+<html><head>
+<title>タイトル</title>
+<meta name="description" content="説明">
+<meta property="og:title" content="OGのタイトル">
+<meta property="OG:DESCRIPTION" content="OGの説明">
+<meta property="og:image" content="/images/ogp.png">
+<meta property="og:image" content="javascript:alert(1)">
+</head><body><meta property="og:site_name" content="bodyの中"></body></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL+"/page")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if preview.Title != "OGのタイトル" {
+		t.Errorf("Title = %q, want %q", preview.Title, "OGのタイトル")
+	}
+	if preview.Description != "OGの説明" {
+		t.Errorf("Description = %q, want %q", preview.Description, "OGの説明")
+	}
+	if want := server.URL + "/images/ogp.png"; preview.ImageURL != want {
+		t.Errorf("ImageURL = %q, want %q", preview.ImageURL, want)
+	}
+	// bodyより後のメタ情報は読まず、サイト名はホスト名になる
+	if preview.SiteName != "127.0.0.1" {
+		t.Errorf("SiteName = %q, want %q", preview.SiteName, "127.0.0.1")
+	}
+	if preview.URL != server.URL+"/page" {
+		t.Errorf("URL = %q, want %q", preview.URL, server.URL+"/page")
+	}
+}
+
+func TestFetchTitleFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>ページのタイトル</title><meta name="Description" content="ページの説明"></head></html>`)
+	}))
+	defer server.Close()
+
+	preview, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if preview.Title != "ページのタイトル" || preview.Description != "ページの説明" {
+		t.Errorf("Title, Description = %q, %q, want %q, %q", preview.Title, preview.Description, "ページのタイトル", "ページの説明")
+	}
+	if preview.ImageURL != "" {
+		t.Errorf("ImageURL = %q, want empty", preview.ImageURL)
+	}
+}
+
+func TestFetchBodySizeLimit(t *testing.T) {
+	const maxBodySize = 1024
+	var written atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		n, _ := fmt.Fprintf(w, `<html><head><title>先頭</title><!-- %s --><meta property="og:description" content="上限より後">`,
+			strings.Repeat("x", 2*maxBodySize))
+		written.Add(int64(n))
+	}))
+	defer server.Close()
+
+	preview, err := newTestFetcher(maxBodySize).Fetch(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if preview.Title != "先頭" {
+		t.Errorf("Title = %q, want %q", preview.Title, "先頭")
+	}
+	if preview.Description != "" {
+		t.Errorf("上限を超えた部分が読み込まれています: Description = %q", preview.Description)
+	}
+	if written.Load() <= maxBodySize {
+		t.Fatalf("テストのページが上限より小さいです: %d bytes", written.Load())
+	}
+}
+
+func TestFetchRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/redirect/", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscanf(strings.TrimPrefix(r.URL.Path, "/redirect/"), "%d", &n)
+		if n == 0 {
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><head><title>到着</title></head></html>`)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/redirect/%d", n-1), http.StatusFound)
+	})
+	mux.HandleFunc("/scheme", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "ftp://example.com/", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{"リダイレクトなし", "/redirect/0", false},
+		{"上限までのリダイレクト", "/redirect/2", false},
+		{"上限を超えるリダイレクト", "/redirect/3", true},
+		{"http(s)以外へのリダイレクト", "/scheme", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			preview, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL+tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Fetch() = %+v, want error", preview)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if preview.Title != "到着" {
+				t.Errorf("Title = %q, want %q", preview.Title, "到着")
+			}
+		})
+	}
+}
+
+func TestFetchUnsupportedContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"JSON", "application/json"},
+		{"画像", "image/png"},
+		{"Content-Typeなし", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				fmt.Fprint(w, `<html><head><title>x</title></head></html>`)
+			}))
+			defer server.Close()
+
+			_, err := newTestFetcher(1<<20).Fetch(context.Background(), server.URL)
+			if !errors.Is(err, ErrUnsupportedContent) {
+				t.Errorf("Fetch() error = %v, want %v", err, ErrUnsupportedContent)
+			}
+		})
+	}
+}
+
+func TestFetchCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>x</title></head></html>`)
+	}))
+	defer server.Close()
+
+	f := newTestFetcher(1 << 20)
+	for i := 0; i < 3; i++ {
+		if _, err := f.Fetch(context.Background(), server.URL); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("requests = %d, want 1", n)
+	}
+}
+
+func TestFetchForbiddenAddress(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html><head><title>内部</title></head></html>`)
+	}))
+	defer server.Close()
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+
+	// 既定の検証処理を使う
+	f := NewFetcher(5*time.Second, 1<<20, time.Hour)
+	urls := []string{
+		server.URL,                       // ループバックのIPアドレス
+		"http://localhost:" + port,       // 名前解決するとループバック
+		"http://127.0.0.1/",              // 許可したポートでもループバックは拒否する
+		"http://[::1]/",                  // IPv6のループバック
+		"http://10.0.0.1/",               // プライベートアドレス
+		"http://192.168.1.1/",            // プライベートアドレス
+		"http://169.254.169.254/latest/", // リンクローカル（クラウドのメタデータ）
+	}
+	for _, u := range urls {
+		t.Run(u, func(t *testing.T) {
+			_, err := f.Fetch(context.Background(), u)
+			if !errors.Is(err, ErrForbiddenAddress) {
+				t.Errorf("Fetch(%q) error = %v, want %v", u, err, ErrForbiddenAddress)
+			}
+		})
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("内部のサーバーに %d 回接続しました", n)
+	}
+}
+
+func TestFetchUnsupportedURL(t *testing.T) {
+	for _, u := range []string{"ftp://example.com/", "javascript:alert(1)", "http://", "not a url"} {
+		if _, err := newTestFetcher(1<<20).Fetch(context.Background(), u); !errors.Is(err, ErrUnsupportedURL) {
+			t.Errorf("Fetch(%q) error = %v, want %v", u, err, ErrUnsupportedURL)
+		}
+	}
+}
+
+func TestFindURL(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"見てください https://example.com/page.", "https://example.com/page"},
+		{"(https://example.com/a)", "https://example.com/a"},
+		{"URLなし", ""},
+	}
+	for _, tt := range tests {
+		if got := FindURL(tt.content); got != tt.want {
+			t.Errorf("FindURL(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// 送信するメール
+type Message struct {
+	To      string // 宛先のメールアドレス
+	Subject string // 件名
+	Text    string // テキストの本文
+	HTML    string // HTMLの本文（空の場合はテキストのみ）
+}
+
+// SMTPSender SMTPサーバーを経由してメールを送信する
+type SMTPSender struct {
+	addr     string
+	host     string
+	from     string
+	username string
+	password string
+}
+
+// NewSMTPSender SMTPでの送信処理を生成する
+// usernameが空の場合は認証せずに送信する
+func NewSMTPSender(host string, port string, username string, password string, from string) *SMTPSender {
+	return &SMTPSender{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		from:     from,
+		username: username,
+		password: password,
+	}
+}
+
+// Send メールを送信する
+// サーバーが対応していればSTARTTLSで暗号化する（PlainAuthは暗号化されていない接続では認証しない）
+func (s *SMTPSender) Send(msg Message) error {
+	from, err := mail.ParseAddress(s.from)
+	if err != nil {
+		return fmt.Errorf("送信元のメールアドレスが正しくありません: %w", err)
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("宛先のメールアドレスが正しくありません: %w", err)
+	}
+
+	body, err := Build(from, to, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	return smtp.SendMail(s.addr, auth, from.Address, []string{to.Address}, body)
+}
+
+// Build メールをMIME形式に組み立てる
+// HTMLの本文がある場合はテキストとHTMLのmultipart/alternativeにする
+func Build(from *mail.Address, to *mail.Address, msg Message, date time.Time) ([]byte, error) {
+	// ヘッダーインジェクションを防ぐ
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, errors.New("件名に改行を含めることはできません")
+	}
+
+	var b bytes.Buffer
+	writeHeader := func(key, value string) {
+		b.WriteString(key + ": " + value + "\r\n")
+	}
+	writeHeader("From", from.String())
+	writeHeader("To", to.String())
+	writeHeader("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	writeHeader("Date", date.Format(time.RFC1123Z))
+	writeHeader("Message-ID", messageID(from.Address))
+	writeHeader("MIME-Version", "1.0")
+
+	if msg.HTML == "" {
+		writeHeader("Content-Type", `text/plain; charset="UTF-8"`)
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQuotedPrintable(&b, msg.Text); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+
+	boundary, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	writeHeader("Content-Type", `multipart/alternative; boundary="`+boundary+`"`)
+	b.WriteString("\r\n")
+	for _, part := range []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		b.WriteString("--" + boundary + "\r\n")
+		writeHeader("Content-Type", part.contentType+`; charset="UTF-8"`)
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		b.WriteString("\r\n")
+		if err := writeQuotedPrintable(&b, part.body); err != nil {
+			return nil, err
+		}
+		b.WriteString("\r\n")
+	}
+	b.WriteString("--" + boundary + "--\r\n")
+	return b.Bytes(), nil
+}
+
+// 本文をquoted-printableで書き込む（改行はCRLFに揃える）
+func writeQuotedPrintable(b *bytes.Buffer, body string) error {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+	w := quotedprintable.NewWriter(b)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// メッセージIDを生成する
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndex(from, "@"); i >= 0 {
+		domain = from[i+1:]
+	}
+	id, err := randomHex(16)
+	if err != nil {
+		id = fmt.Sprint(time.Now().UnixNano())
+	}
+	return "<" + id + "@" + domain + ">"
+}
+
+// ランダムな16進数の文字列を生成する
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,216 @@
+package mail
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testDate = time.Date(2026, 10, 19, 21, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+func parseMail(t *testing.T, raw []byte) *mail.Message {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v\n%s", err, raw)
+	}
+	return msg
+}
+
+func decodeHeader(t *testing.T, value string) string {
+	t.Helper()
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		t.Fatalf("DecodeHeader(%q) error = %v", value, err)
+	}
+	return decoded
+}
+
+func readQuotedPrintable(t *testing.T, r io.Reader) string {
+	t.Helper()
+	b, err := io.ReadAll(quotedprintable.NewReader(r))
+	if err != nil {
+		t.Fatalf("quoted-printableのデコードに失敗: %v", err)
+	}
+	return string(b)
+}
+
+func TestBuildMultipart(t *testing.T) {
+	from := &mail.Address{Name: "チャットアプリ", Address: "noreply@example.com"}
+	to := &mail.Address{Name: "山田 太郎", Address: "taro@example.com"}
+	text := "山田さん\n未読のメッセージが3件あります。" + strings.Repeat("長い行", 40)
+	html := "<p>未読のメッセージが3件あります。</p>"
+
+	raw, err := Build(from, to, Message{To: to.Address, Subject: "未読のメッセージが3件あります", Text: text, HTML: html}, testDate)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	for i, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 998 {
+			t.Errorf("%d行目が998バイトを超えています", i+1)
+		}
+		if strings.Contains(line, "\n") {
+			t.Errorf("%d行目の改行がCRLFになっていません", i+1)
+		}
+	}
+
+	msg := parseMail(t, raw)
+	if got := decodeHeader(t, msg.Header.Get("Subject")); got != "未読のメッセージが3件あります" {
+		t.Errorf("Subject = %q", got)
+	}
+	if !strings.HasPrefix(msg.Header.Get("Subject"), "=?UTF-8?b?") {
+		t.Errorf("件名がUTF-8でエンコードされていません: %q", msg.Header.Get("Subject"))
+	}
+	for key, want := range map[string]string{"From": from.String(), "To": to.String()} {
+		addr, err := mail.ParseAddress(msg.Header.Get(key))
+		if err != nil || addr.String() != want {
+			t.Errorf("%s = %q (%v), want %q", key, msg.Header.Get(key), err, want)
+		}
+	}
+	if date, err := msg.Header.Date(); err != nil || !date.Equal(testDate) {
+		t.Errorf("Date = %v (%v), want %v", date, err, testDate)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q", id)
+	}
+	if msg.Header.Get("MIME-Version") != "1.0" {
+		t.Errorf("MIME-Version = %q, want 1.0", msg.Header.Get("MIME-Version"))
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v), want multipart/alternative", msg.Header.Get("Content-Type"), err)
+	}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for _, want := range []struct {
+		mediaType string
+		body      string
+	}{
+		{"text/plain", strings.ReplaceAll(text, "\n", "\r\n")},
+		{"text/html", html},
+	} {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatalf("NextRawPart() error = %v", err)
+		}
+		mediaType, params, err := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		if err != nil || mediaType != want.mediaType || params["charset"] != "UTF-8" {
+			t.Errorf("Content-Type = %q, want %s; charset=UTF-8", part.Header.Get("Content-Type"), want.mediaType)
+		}
+		if part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+			t.Errorf("Content-Transfer-Encoding = %q", part.Header.Get("Content-Transfer-Encoding"))
+		}
+		if got := readQuotedPrintable(t, part); got != want.body {
+			t.Errorf("%sの本文 = %q, want %q", want.mediaType, got, want.body)
+		}
+	}
+	if _, err := reader.NextPart(); err != io.EOF {
+		t.Errorf("余分なパートがあります: %v", err)
+	}
+}
+
+func TestBuildTextOnly(t *testing.T) {
+	from := &mail.Address{Address: "noreply@example.com"}
+	to := &mail.Address{Address: "taro@example.com"}
+
+	raw, err := Build(from, to, Message{Subject: "お知らせ", Text: "本文です"}, testDate)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	msg := parseMail(t, raw)
+	if ct := msg.Header.Get("Content-Type"); ct != `text/plain; charset="UTF-8"` {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if got := readQuotedPrintable(t, msg.Body); got != "本文です" {
+		t.Errorf("本文 = %q, want %q", got, "本文です")
+	}
+}
+
+func TestBuildHeaderInjection(t *testing.T) {
+	from := &mail.Address{Address: "noreply@example.com"}
+	to := &mail.Address{Address: "taro@example.com"}
+	for _, subject := range []string{"件名\r\nBcc: evil@example.com", "件名\nBcc: evil@example.com"} {
+		if _, err := Build(from, to, Message{Subject: subject, Text: "x"}, testDate); err == nil {
+			t.Errorf("Build(Subject=%q) error = nil, want error", subject)
+		}
+	}
+}
+
+// 受け取ったメールを記録する最小限のSMTPサーバー
+func startSMTPServer(t *testing.T) (addr string, received <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "MAIL", "RCPT", "RSET", "NOOP":
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 Start mail input")
+				data, _ := io.ReadAll(bufio.NewReader(tp.DotReader()))
+				ch <- string(data)
+				tp.PrintfLine("250 OK")
+			case "QUIT":
+				tp.PrintfLine("221 Bye")
+				return
+			default:
+				tp.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+	return ln.Addr().String(), ch
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	addr, received := startSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+
+	sender := NewSMTPSender(host, port, "", "", "チャットアプリ <noreply@example.com>")
+	if err := sender.Send(Message{To: "taro@example.com", Subject: "未読のメッセージ", Text: "本文"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	select {
+	case data := <-received:
+		msg := parseMail(t, []byte(data))
+		if got := decodeHeader(t, msg.Header.Get("Subject")); got != "未読のメッセージ" {
+			t.Errorf("Subject = %q", got)
+		}
+		if to := msg.Header.Get("To"); to != "<taro@example.com>" {
+			t.Errorf("To = %q", to)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTPサーバーがメールを受け取っていません")
+	}
+
+	if err := sender.Send(Message{To: "not an address", Subject: "x", Text: "x"}); err == nil {
+		t.Error("Send(不正な宛先) error = nil, want error")
+	}
+}
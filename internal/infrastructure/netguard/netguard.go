@@ -0,0 +1,76 @@
+package netguard
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// 内部ネットワークへの接続を拒否したことを表すエラー
+var ErrForbiddenAddress = errors.New("内部ネットワークのアドレスにはアクセスできません")
+
+// SSRF対策のため拒否するアドレス範囲（net.IPのメソッドで判定できないもの）
+var forbiddenNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 「このネットワーク」
+	"100.64.0.0/10", // キャリアグレードNAT
+	"192.0.0.0/24",  // IETFプロトコル割り当て
+	"198.18.0.0/15", // ベンチマーク用
+	"240.0.0.0/4",   // 予約済み
+	"64:ff9b::/96",  // NAT64
+)
+
+// 接続を拒否するアドレスかどうか（ループバック・プライベート・リンクローカルなど）
+func IsForbiddenIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// net.DialerのControlに設定し、名前解決後の接続先アドレスを検証する（DNSリバインディング対策）
+// 許可したポート以外と、内部ネットワークのアドレスへの接続を拒否する
+func DialControl(allowedPorts ...string) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		allowed := false
+		for _, p := range allowedPorts {
+			if port == p {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return ErrForbiddenAddress
+		}
+		if ip := net.ParseIP(host); ip == nil || IsForbiddenIP(ip) {
+			return ErrForbiddenAddress
+		}
+		return nil
+	}
+}
+
+// CIDR表記のアドレス範囲を解析する
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
@@ -16,6 +16,8 @@ func SetupRouter(chatUsecase domain.ChatUsecase) *http.ServeMux {
 	httpRouter.Handle("/css/", http.StripPrefix("/css/", http.FileServer(http.Dir(rootDir+"css"))))
 	httpRouter.Handle("/js/", http.StripPrefix("/js/", http.FileServer(http.Dir(rootDir+"js"))))
 	httpRouter.Handle("/images/", http.StripPrefix("/images/", http.FileServer(http.Dir(rootDir+"images"))))
+	// Service Worker（サイト全体を対象にするためルートで配信する）
+	httpRouter.Handle("/sw.js", http.HandlerFunc(handler.ServiceWorkerHandler))
 	// ルーティング
 	httpRouter.Handle("/", middleware.Middleware(http.HandlerFunc(handler.SearchHandler)))
 	httpRouter.Handle("/login", http.HandlerFunc(handler.LoginHandler))
@@ -26,11 +28,61 @@ func SetupRouter(chatUsecase domain.ChatUsecase) *http.ServeMux {
 	httpRouter.Handle("/profile", middleware.Middleware(http.HandlerFunc(handler.ProfileHandler)))
 	httpRouter.Handle("/profile/", middleware.Middleware(http.HandlerFunc(handler.ProfileHandler)))
 	httpRouter.Handle("/profile/icon", middleware.Middleware(http.HandlerFunc(handler.ProfileIconHandler)))
+	httpRouter.Handle("/profile/verify", middleware.Middleware(http.HandlerFunc(handler.KeyVerificationHandler)))
 	httpRouter.Handle("/chat/", middleware.Middleware(http.HandlerFunc(handler.StartChatHandler)))
 	httpRouter.Handle("/chat", middleware.Middleware(http.HandlerFunc(handler.ChatHandler)))
+	httpRouter.Handle("/chat/draft", middleware.Middleware(http.HandlerFunc(handler.SaveDraftHandler)))
+	httpRouter.Handle("/chat/export", middleware.Middleware(http.HandlerFunc(handler.ChatExportHandler)))
+	httpRouter.Handle("/chat/import", middleware.Middleware(http.HandlerFunc(handler.ChatImportHandler)))
+	httpRouter.Handle("/chat/forward", middleware.Middleware(http.HandlerFunc(handler.ForwardMessageHandler)))
+	httpRouter.Handle("/chat/link-preview", middleware.Middleware(http.HandlerFunc(handler.LinkPreviewHandler)))
+	httpRouter.Handle("/chat/pin", middleware.Middleware(http.HandlerFunc(handler.PinMessageHandler)))
+	httpRouter.Handle("/chat/unpin", middleware.Middleware(http.HandlerFunc(handler.UnpinMessageHandler)))
+	httpRouter.Handle("/chat/settings", middleware.Middleware(http.HandlerFunc(handler.ChatSettingsHandler)))
+	httpRouter.Handle("/chat/retention", middleware.Middleware(http.HandlerFunc(handler.ChatRetentionHandler)))
+	httpRouter.Handle("/chat/schedule", middleware.Middleware(http.HandlerFunc(handler.ScheduleMessageHandler)))
+	httpRouter.Handle("/chat/schedule/edit", middleware.Middleware(http.HandlerFunc(handler.EditScheduledMessageHandler)))
+	httpRouter.Handle("/chat/schedule/cancel", middleware.Middleware(http.HandlerFunc(handler.CancelScheduledMessageHandler)))
+	httpRouter.Handle("/chat/poll/vote", middleware.Middleware(http.HandlerFunc(handler.PollVoteHandler)))
+	httpRouter.Handle("/chat/bots/add", middleware.Middleware(http.HandlerFunc(handler.AddChatBotHandler)))
+	httpRouter.Handle("/chat/bots/remove", middleware.Middleware(http.HandlerFunc(handler.RemoveChatBotHandler)))
+	httpRouter.Handle("/chat/e2ee", middleware.Middleware(http.HandlerFunc(handler.EnableE2EEHandler)))
+	httpRouter.Handle("/keys", middleware.Middleware(http.HandlerFunc(handler.IdentityKeyHandler)))
+	httpRouter.Handle("/block", middleware.Middleware(http.HandlerFunc(handler.BlockUserHandler)))
+	httpRouter.Handle("/unblock", middleware.Middleware(http.HandlerFunc(handler.UnblockUserHandler)))
+	httpRouter.Handle("/report", middleware.Middleware(http.HandlerFunc(handler.ReportHandler)))
+	httpRouter.Handle("/admin/reports", middleware.Middleware(http.HandlerFunc(handler.ModerationHandler)))
+	httpRouter.Handle("/admin/reports/action", middleware.Middleware(http.HandlerFunc(handler.ModerationActionHandler)))
+	httpRouter.Handle("/admin/filters", middleware.Middleware(http.HandlerFunc(handler.ContentFilterHandler)))
+	httpRouter.Handle("/admin/filters/toggle", middleware.Middleware(http.HandlerFunc(handler.ToggleContentFilterHandler)))
+	httpRouter.Handle("/admin/filters/delete", middleware.Middleware(http.HandlerFunc(handler.DeleteContentFilterHandler)))
+	httpRouter.Handle("/admin/webhooks", middleware.Middleware(http.HandlerFunc(handler.AdminWebhooksHandler)))
+	httpRouter.Handle("/chat/webhooks", middleware.Middleware(http.HandlerFunc(handler.ChatWebhooksHandler)))
+	httpRouter.Handle("/webhooks/toggle", middleware.Middleware(http.HandlerFunc(handler.ToggleWebhookHandler)))
+	httpRouter.Handle("/webhooks/delete", middleware.Middleware(http.HandlerFunc(handler.DeleteWebhookHandler)))
+	httpRouter.Handle("/chat/webhooks/incoming", middleware.Middleware(http.HandlerFunc(handler.CreateIncomingWebhookHandler)))
+	httpRouter.Handle("/chat/webhooks/incoming/revoke", middleware.Middleware(http.HandlerFunc(handler.RevokeIncomingWebhookHandler)))
+	httpRouter.Handle("/contacts", middleware.Middleware(handler.ContactsHandler(chatUsecase)))
+	httpRouter.Handle("/contacts/request", middleware.Middleware(http.HandlerFunc(handler.ContactRequestHandler)))
+	httpRouter.Handle("/contacts/accept", middleware.Middleware(http.HandlerFunc(handler.AcceptContactHandler)))
+	httpRouter.Handle("/contacts/decline", middleware.Middleware(http.HandlerFunc(handler.DeclineContactHandler)))
+	httpRouter.Handle("/contacts/cancel", middleware.Middleware(http.HandlerFunc(handler.CancelContactHandler)))
+	httpRouter.Handle("/contacts/remove", middleware.Middleware(http.HandlerFunc(handler.RemoveContactHandler)))
+	httpRouter.Handle("/contacts/settings", middleware.Middleware(http.HandlerFunc(handler.ContactsOnlySettingsHandler)))
+	httpRouter.Handle("/mentions", middleware.Middleware(http.HandlerFunc(handler.MentionsHandler)))
 	httpRouter.Handle("/search", middleware.Middleware(http.HandlerFunc(handler.SearchHandler)))
 	httpRouter.Handle("/settings", middleware.Middleware(http.HandlerFunc(handler.SettingsHandler)))
 	httpRouter.Handle("/settings/username", middleware.Middleware(http.HandlerFunc(handler.SettingsHandler)))
+	httpRouter.Handle("/settings/bots", middleware.Middleware(http.HandlerFunc(handler.CreateBotHandler)))
+	httpRouter.Handle("/settings/bots/delete", middleware.Middleware(http.HandlerFunc(handler.DeleteBotHandler)))
+	httpRouter.Handle("/settings/email-digest", middleware.Middleware(http.HandlerFunc(handler.EmailDigestSettingsHandler)))
+	httpRouter.Handle("/settings/profile", middleware.Middleware(http.HandlerFunc(handler.ProfileSettingsHandler)))
+	httpRouter.Handle("/settings/availability", middleware.Middleware(http.HandlerFunc(handler.AvailabilitySettingsHandler)))
+	httpRouter.Handle("/push/subscribe", middleware.Middleware(http.HandlerFunc(handler.PushSubscribeHandler)))
+	httpRouter.Handle("/push/unsubscribe", middleware.Middleware(http.HandlerFunc(handler.PushUnsubscribeHandler)))
+	// ボットのAPI（セッションではなくAPIトークンで認証する）
+	httpRouter.Handle("/api/bot/messages", http.HandlerFunc(handler.BotMessageAPIHandler))
+	httpRouter.Handle("/api/hooks/", http.HandlerFunc(handler.IncomingWebhookAPIHandler))
 
 	return httpRouter
 }
@@ -0,0 +1,188 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/infrastructure/netguard"
+)
+
+// 配信時に付与するヘッダー
+const (
+	HeaderSignature = "X-Webhook-Signature" // 署名（sha256=<HMAC-SHA256の16進数>）
+	HeaderTimestamp = "X-Webhook-Timestamp" // 署名したUNIX時間（秒）
+	HeaderEvent     = "X-Webhook-Event"     // イベントの種類
+	HeaderDelivery  = "X-Webhook-Delivery"  // 配信のID（再試行しても変わらない）
+)
+
+// 署名の接頭辞
+const signaturePrefix = "sha256="
+
+// レスポンスの本文として読み込む最大サイズ（接続を再利用するために読み捨てる）
+const maxResponseBodySize = 64 << 10
+
+// 配信の内容
+type Request struct {
+	URL        string // 通知先のURL
+	Secret     string // 署名用のシークレット
+	Event      string // イベントの種類
+	DeliveryID string // 配信のID
+	Body       []byte // 送信するJSON
+}
+
+// Sender Webhookを署名して送信する
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender Webhookの送信処理を生成する
+// timeoutは1回の送信全体の制限時間
+func NewSender(timeout time.Duration) *Sender {
+	return NewSenderWithClient(NewHTTPClient(timeout))
+}
+
+// NewSenderWithClient HTTPクライアントを指定して送信処理を生成する
+// 通常はNewHTTPClientで生成したものを指定する（テストでは偽の通知先に接続するものに差し替える）
+func NewSenderWithClient(client *http.Client) *Sender {
+	return &Sender{client: client}
+}
+
+// NewHTTPClient Webhookの通知先に接続するHTTPクライアントを生成する
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		// 名前解決後の接続先アドレスを検証する（DNSリバインディング対策）
+		Control: netguard.DialControl("443"),
+	}
+
+	transport := &http.Transport{
+		Proxy:                 nil, // 環境変数のプロキシを経由させない
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		// リダイレクト先は検証できないため追跡しない（3xxは失敗として扱う）
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Send Webhookを1回送信し、HTTPステータスコードを返す
+// 2xx以外の応答はエラーとして返す（接続できなかった場合のステータスコードは0）
+func (s *Sender) Send(ctx context.Context, req Request) (int, error) {
+	if !strings.HasPrefix(req.URL, "https://") {
+		return 0, errors.New("https以外のURLには送信できません")
+	}
+
+	timestamp := time.Now().Unix()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "SecurityChatApp-Webhook/1.0")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("通知先が%dを返しました", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign 本文を署名する
+// 再送による攻撃を防げるように「タイムスタンプ.本文」をHMAC-SHA256で署名する
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 署名を検証する（受信側の実装例を兼ねる）
+// タイムスタンプが現在からtolerance以上ずれている場合も不正とする
+func Verify(secret string, signature string, timestamp string, body []byte, tolerance time.Duration) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	diff := time.Since(time.Unix(ts, 0))
+	if diff < -tolerance || diff > tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, ts, body)))
+}
+
+// Result 再試行を含めた配信の結果
+type Result struct {
+	Attempts   int   // 送信した回数
+	StatusCode int   // 最後の応答のHTTPステータスコード（接続できなかった場合は0）
+	Err        error // 最後の送信のエラー（成功した場合はnil）
+}
+
+// Deliver 送信に成功するか再試行しない失敗になるまで、最大maxAttempts回送信する
+// 再試行の間隔はbaseDelayから始めて倍にしていく
+// sendは1回の送信を行う関数（同時に送信する数の制限などは呼び出し元で行う）
+func Deliver(send func() (int, error), maxAttempts int, baseDelay time.Duration) Result {
+	var result Result
+	delay := baseDelay
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result.Attempts = attempt
+		result.StatusCode, result.Err = send()
+		if result.Err == nil || !IsRetryable(result.StatusCode, result.Err) || attempt == maxAttempts {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return result
+}
+
+// IsRetryable 再試行する失敗かどうか
+// 接続できなかった場合と、5xx・429・408の応答は一時的な失敗として再試行する
+func IsRetryable(statusCode int, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, netguard.ErrForbiddenAddress) {
+		return false
+	}
+	switch {
+	case statusCode == 0:
+		return true
+	case statusCode >= 500:
+		return true
+	case statusCode == http.StatusTooManyRequests, statusCode == http.StatusRequestTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"security_chat_app/internal/infrastructure/netguard"
+)
+
+// 受信した配信を記録する偽の通知先
+type fakeReceiver struct {
+	server   *httptest.Server
+	mu       sync.Mutex
+	requests []*receivedRequest
+	calls    atomic.Int32
+	status   func(call int) int // 呼び出し回数（1から）ごとの応答
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+	at     time.Time
+}
+
+func newFakeReceiver(t *testing.T, status func(call int) int) *fakeReceiver {
+	t.Helper()
+	r := &fakeReceiver{status: status}
+	r.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.requests = append(r.requests, &receivedRequest{header: req.Header.Clone(), body: body, at: time.Now()})
+		r.mu.Unlock()
+		w.WriteHeader(r.status(int(r.calls.Add(1))))
+	}))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// 偽の通知先に接続する送信処理（テスト用の証明書を信頼する）
+func (r *fakeReceiver) sender() *Sender {
+	return NewSenderWithClient(r.server.Client())
+}
+
+func (r *fakeReceiver) received() []*receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*receivedRequest(nil), r.requests...)
+}
+
+func always(status int) func(int) int {
+	return func(int) int { return status }
+}
+
+func testRequest(url string) Request {
+	return Request{
+		URL:        url,
+		Secret:     "whsec_test",
+		Event:      "message.created",
+		DeliveryID: "delivery-1",
+		Body:       []byte(`{"id":"delivery-1","event":"message.created"}`),
+	}
+}
+
+func TestSendSignature(t *testing.T) {
+	receiver := newFakeReceiver(t, always(http.StatusOK))
+	req := testRequest(receiver.server.URL)
+
+	status, err := receiver.sender().Send(context.Background(), req)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Send() = %d, %v, want 200, nil", status, err)
+	}
+
+	got := receiver.received()
+	if len(got) != 1 {
+		t.Fatalf("received %d requests, want 1", len(got))
+	}
+	header, body := got[0].header, got[0].body
+	if string(body) != string(req.Body) {
+		t.Errorf("body = %s, want %s", body, req.Body)
+	}
+	if header.Get(HeaderEvent) != req.Event || header.Get(HeaderDelivery) != req.DeliveryID {
+		t.Errorf("event, delivery = %q, %q, want %q, %q", header.Get(HeaderEvent), header.Get(HeaderDelivery), req.Event, req.DeliveryID)
+	}
+	if header.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", header.Get("Content-Type"))
+	}
+
+	// 受信側と同じ手順で署名を計算して比較する
+	timestamp := header.Get(HeaderTimestamp)
+	mac := hmac.New(sha256.New, []byte(req.Secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if header.Get(HeaderSignature) != want {
+		t.Errorf("signature = %q, want %q", header.Get(HeaderSignature), want)
+	}
+	if !Verify(req.Secret, header.Get(HeaderSignature), timestamp, body, 5*time.Minute) {
+		t.Error("Verify() = false, want true")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"a":1}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+	signature := Sign("secret", now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		want      bool
+	}{
+		{"正しい署名", "secret", signature, ts, body, true},
+		{"異なるシークレット", "other", signature, ts, body, false},
+		{"改ざんされた本文", "secret", signature, ts, []byte(`{"a":2}`), false},
+		{"異なるタイムスタンプ", "secret", signature, strconv.FormatInt(now+1, 10), body, false},
+		{"古いタイムスタンプ", "secret", Sign("secret", now-600, body), strconv.FormatInt(now-600, 10), body, false},
+		{"タイムスタンプの形式が不正", "secret", signature, "abc", body, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, 5*time.Minute); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendErrors(t *testing.T) {
+	receiver := newFakeReceiver(t, always(http.StatusBadRequest))
+
+	status, err := receiver.sender().Send(context.Background(), testRequest(receiver.server.URL))
+	if err == nil || status != http.StatusBadRequest {
+		t.Errorf("Send() = %d, %v, want 400 and error", status, err)
+	}
+
+	// https以外のURLには送信しない
+	if _, err := receiver.sender().Send(context.Background(), testRequest("http://example.com/")); err == nil {
+		t.Error("Send(http://) error = nil, want error")
+	}
+
+	// 既定の送信処理はループバックの通知先に接続しない
+	_, err = NewSender(5*time.Second).Send(context.Background(), testRequest(receiver.server.URL))
+	if !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("Send(loopback) error = %v, want %v", err, netguard.ErrForbiddenAddress)
+	}
+	if IsRetryable(0, err) {
+		t.Error("内部ネットワークへの接続の拒否は再試行しません")
+	}
+	if n := receiver.calls.Load(); n != 1 {
+		t.Errorf("receiver calls = %d, want 1", n)
+	}
+}
+
+func TestDeliverRetry(t *testing.T) {
+	const maxAttempts = 4
+	const baseDelay = 10 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		status       func(call int) int
+		wantAttempts int
+		wantStatus   int
+		wantErr      bool
+	}{
+		{"成功", always(http.StatusOK), 1, http.StatusOK, false},
+		{"5xxの後に成功", func(call int) int {
+			if call < 3 {
+				return http.StatusServiceUnavailable
+			}
+			return http.StatusNoContent
+		}, 3, http.StatusNoContent, false},
+		{"429は再試行する", func(call int) int {
+			if call == 1 {
+				return http.StatusTooManyRequests
+			}
+			return http.StatusOK
+		}, 2, http.StatusOK, false},
+		{"4xxは再試行しない", always(http.StatusNotFound), 1, http.StatusNotFound, true},
+		{"3xxは再試行しない", always(http.StatusFound), 1, http.StatusFound, true},
+		{"最大回数で諦める", always(http.StatusInternalServerError), maxAttempts, http.StatusInternalServerError, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := newFakeReceiver(t, tt.status)
+			sender := receiver.sender()
+			req := testRequest(receiver.server.URL)
+
+			result := Deliver(func() (int, error) {
+				return sender.Send(context.Background(), req)
+			}, maxAttempts, baseDelay)
+
+			if result.Attempts != tt.wantAttempts || result.StatusCode != tt.wantStatus || (result.Err != nil) != tt.wantErr {
+				t.Errorf("Deliver() = %+v, want attempts=%d status=%d err=%v", result, tt.wantAttempts, tt.wantStatus, tt.wantErr)
+			}
+			got := receiver.received()
+			if len(got) != tt.wantAttempts {
+				t.Fatalf("received %d requests, want %d", len(got), tt.wantAttempts)
+			}
+
+			// 再試行しても配信のIDは変わらず、間隔は倍になっていく
+			for i := 1; i < len(got); i++ {
+				if got[i].header.Get(HeaderDelivery) != req.DeliveryID {
+					t.Errorf("delivery ID = %q, want %q", got[i].header.Get(HeaderDelivery), req.DeliveryID)
+				}
+				minDelay := baseDelay << (i - 1)
+				if gap := got[i].at.Sub(got[i-1].at); gap < minDelay {
+					t.Errorf("%d回目の再試行までの間隔 = %v, want >= %v", i, gap, minDelay)
+				}
+			}
+		})
+	}
+}
+
+func TestDeliverConnectionError(t *testing.T) {
+	calls := 0
+	result := Deliver(func() (int, error) {
+		calls++
+		return 0, errors.New("connection refused")
+	}, 3, time.Millisecond)
+	if calls != 3 || result.Attempts != 3 || result.Err == nil {
+		t.Errorf("Deliver() = %+v (calls=%d), want 3 attempts with error", result, calls)
+	}
+}
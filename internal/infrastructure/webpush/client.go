@@ -0,0 +1,99 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/netguard"
+)
+
+// プッシュサービスが通知を保持する期間（この間に端末がオンラインにならなければ破棄される）
+const DefaultTTL = 24 * time.Hour
+
+// レスポンスの本文として読み込む最大サイズ（接続を再利用するために読み捨てる）
+const maxResponseBodySize = 64 << 10
+
+// Client 暗号化した通知をプッシュサービスに送信する
+type Client struct {
+	client *http.Client
+	vapid  *VAPID
+	ttl    time.Duration
+}
+
+// NewClient プッシュ通知の送信処理を生成する
+// httpClientにはNewHTTPClientで生成したものを指定する（テストでは偽のプッシュサービスに接続するものに差し替える）
+func NewClient(vapid *VAPID, httpClient *http.Client) *Client {
+	return &Client{
+		client: httpClient,
+		vapid:  vapid,
+		ttl:    DefaultTTL,
+	}
+}
+
+// NewHTTPClient プッシュサービスに接続するHTTPクライアントを生成する
+// エンドポイントはブラウザから受け取るURLのため、Webhookと同様に内部ネットワークへの接続を拒否する
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: netguard.DialControl("443"),
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:                 nil, // 環境変数のプロキシを経由させない
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		Timeout: timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// Send 購読に通知を1回送信する
+// 購読が無効になっている場合（404・410）はdomain.ErrPushSubscriptionExpiredを返す
+func (c *Client) Send(ctx context.Context, sub domain.PushSubscription, payload []byte) error {
+	body, err := Encrypt(sub.P256dh, sub.Auth, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.vapid.Authorization(sub.Endpoint, time.Now())
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(c.ttl.Seconds())))
+	req.Header.Set("Urgency", "high")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return fmt.Errorf("%w（ステータス%d）", domain.ErrPushSubscriptionExpired, resp.StatusCode)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("プッシュサービスが%dを返しました", resp.StatusCode)
+	}
+	return nil
+}
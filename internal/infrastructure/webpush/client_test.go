@@ -0,0 +1,324 @@
+package webpush
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/netguard"
+)
+
+// 購読したブラウザの鍵
+type browser struct {
+	key  *ecdh.PrivateKey
+	auth []byte
+}
+
+func newBrowser(t *testing.T) *browser {
+	t.Helper()
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	return &browser{key: key, auth: auth}
+}
+
+func (b *browser) subscription(endpoint string) domain.PushSubscription {
+	return domain.PushSubscription{
+		ID:       "sub-1",
+		UserID:   "u1",
+		Endpoint: endpoint,
+		P256dh:   base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes()),
+		Auth:     base64.RawURLEncoding.EncodeToString(b.auth),
+	}
+}
+
+func hkdfKey(t *testing.T, secret, salt, info []byte, length int) []byte {
+	t.Helper()
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), key); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// ブラウザと同じ手順で通知を復号する（RFC 8291・RFC 8188）
+func (b *browser) decrypt(t *testing.T, body []byte) []byte {
+	t.Helper()
+	if len(body) < 21 {
+		t.Fatalf("本文が短すぎます: %d bytes", len(body))
+	}
+	salt := body[:16]
+	rs := binary.BigEndian.Uint32(body[16:20])
+	idlen := int(body[20])
+	if rs != 4096 || len(body) < 21+idlen {
+		t.Fatalf("ヘッダーが正しくありません: rs=%d, idlen=%d", rs, idlen)
+	}
+	asPublicBytes := body[21 : 21+idlen]
+	ciphertext := body[21+idlen:]
+
+	asPublic, err := ecdh.P256().NewPublicKey(asPublicBytes)
+	if err != nil {
+		t.Fatalf("サーバーの公開鍵が正しくありません: %v", err)
+	}
+	shared, err := b.key.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := append([]byte("WebPush: info\x00"), b.key.PublicKey().Bytes()...)
+	info = append(info, asPublicBytes...)
+	ikm := hkdfKey(t, shared, b.auth, info, 32)
+	cek := hkdfKey(t, ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdfKey(t, ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+	record, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("復号に失敗: %v", err)
+	}
+	// 最後のレコードの区切り（0x02）とパディングを取り除く
+	record = bytes.TrimRight(record, "\x00")
+	if len(record) == 0 || record[len(record)-1] != 0x02 {
+		t.Fatalf("最後のレコードの区切りがありません: %x", record)
+	}
+	return record[:len(record)-1]
+}
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// RFC 8291 Appendix Aの例と同じ暗号文になる
+func TestEncryptRFC8291(t *testing.T) {
+	serverKey, err := ecdh.P256().NewPrivateKey(mustDecode(t, "yfWPiYE-n46HLnH0KqZOF1fJJU3MYrct3AELtAQ-oRw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := encrypt(
+		"BCVxsr7N_eNgVRqvHtD0zTZsEc6-VV-JvLexhqUzORcxaOzi6-AYWXvTBHm4bjyPjs7Vd8pZGH6SRpkNtoIAiw4",
+		"BTBZMqHH6r4Tts7J_aSIgg",
+		[]byte("When I grow up, I want to be a watermelon"),
+		serverKey,
+		mustDecode(t, "DGv6ra1nlYgDCS1FRnbzlw"),
+	)
+	if err != nil {
+		t.Fatalf("encrypt() error = %v", err)
+	}
+	want := "DGv6ra1nlYgDCS1FRnbzlwAAEABBBP4z9KsN6nGRTbVYI_c7VJSPQTBtkgcy27mlmlMoZIIgDll6e3vCYLocInmYWAmS6TlzAC8wEqKK6PBru3jl7A_yl95bQpu6cVPTpK4Mqgkf1CXztLVBSt2Ks3oZwbuwXPXLWyouBWLVWGNWQexSgSxsj_Qulcy4a-fN"
+	if base64.RawURLEncoding.EncodeToString(got) != want {
+		t.Errorf("encrypt() = %s, want %s", base64.RawURLEncoding.EncodeToString(got), want)
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	b := newBrowser(t)
+	sub := b.subscription("https://push.example.com/x")
+	payload := []byte(`{"title":"こんにちは"}`)
+
+	first, err := Encrypt(sub.P256dh, sub.Auth, payload)
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if got := b.decrypt(t, first); string(got) != string(payload) {
+		t.Errorf("decrypt() = %q, want %q", got, payload)
+	}
+	// 送信のたびに鍵とsaltを生成する
+	second, _ := Encrypt(sub.P256dh, sub.Auth, payload)
+	if bytes.Equal(first[:16], second[:16]) || bytes.Equal(first, second) {
+		t.Error("同じsaltで暗号化しています")
+	}
+
+	if _, err := Encrypt(sub.P256dh, sub.Auth, make([]byte, MaxPayloadSize+1)); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("Encrypt(大きすぎる本文) error = %v, want %v", err, ErrPayloadTooLarge)
+	}
+	if _, err := Encrypt("invalid", sub.Auth, payload); err == nil {
+		t.Error("Encrypt(不正な公開鍵) error = nil, want error")
+	}
+	if _, err := Encrypt(sub.P256dh, "c2hvcnQ", payload); err == nil {
+		t.Error("Encrypt(短い認証用シークレット) error = nil, want error")
+	}
+}
+
+// 受け取った通知を記録する偽のプッシュサービス
+type fakePushService struct {
+	server *httptest.Server
+	status int
+	mu     sync.Mutex
+	header http.Header
+	body   []byte
+}
+
+func newFakePushService(t *testing.T, status int) *fakePushService {
+	t.Helper()
+	s := &fakePushService{status: status}
+	s.server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		s.header, s.body = r.Header.Clone(), body
+		s.mu.Unlock()
+		w.WriteHeader(s.status)
+	}))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func newTestVAPID(t *testing.T) *VAPID {
+	t.Helper()
+	publicKey, privateKey, err := GenerateVAPIDKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vapid, err := ParseVAPID(publicKey, privateKey, "mailto:admin@example.com")
+	if err != nil {
+		t.Fatalf("ParseVAPID() error = %v", err)
+	}
+	return vapid
+}
+
+// VAPIDのJWTを検証してクレームを返す
+func verifyVAPID(t *testing.T, authorization string, publicKey string) map[string]interface{} {
+	t.Helper()
+	var token, k string
+	for _, part := range strings.Split(strings.TrimPrefix(authorization, "vapid "), ", ") {
+		switch {
+		case strings.HasPrefix(part, "t="):
+			token = strings.TrimPrefix(part, "t=")
+		case strings.HasPrefix(part, "k="):
+			k = strings.TrimPrefix(part, "k=")
+		}
+	}
+	if !strings.HasPrefix(authorization, "vapid ") || token == "" || k != publicKey {
+		t.Fatalf("Authorization = %q", authorization)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("JWTの形式が正しくありません: %q", token)
+	}
+	var header map[string]string
+	json.Unmarshal(mustDecode(t, parts[0]), &header)
+	if header["alg"] != "ES256" {
+		t.Errorf("alg = %q, want ES256", header["alg"])
+	}
+	x, y := elliptic.Unmarshal(elliptic.P256(), mustDecode(t, k))
+	sig := mustDecode(t, parts[2])
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	if len(sig) != 64 || !ecdsa.Verify(pub, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+		t.Fatal("VAPIDの署名を検証できません")
+	}
+	var claims map[string]interface{}
+	json.Unmarshal(mustDecode(t, parts[1]), &claims)
+	return claims
+}
+
+func TestSend(t *testing.T) {
+	service := newFakePushService(t, http.StatusCreated)
+	vapid := newTestVAPID(t)
+	b := newBrowser(t)
+	payload := []byte(`{"type":"message","title":"Bob","body":"こんにちは"}`)
+
+	client := NewClient(vapid, service.server.Client())
+	if err := client.Send(context.Background(), b.subscription(service.server.URL+"/push/abc"), payload); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"Content-Type":     "application/octet-stream",
+		"Content-Encoding": "aes128gcm",
+		"TTL":              "86400",
+		"Urgency":          "high",
+	} {
+		if got := service.header.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	claims := verifyVAPID(t, service.header.Get("Authorization"), vapid.PublicKey())
+	if claims["aud"] != service.server.URL {
+		t.Errorf("aud = %v, want %q", claims["aud"], service.server.URL)
+	}
+	if claims["sub"] != "mailto:admin@example.com" {
+		t.Errorf("sub = %v", claims["sub"])
+	}
+	exp, _ := claims["exp"].(float64)
+	if d := time.Until(time.Unix(int64(exp), 0)); d <= 0 || d > 24*time.Hour {
+		t.Errorf("exp = %v, 24時間以内である必要があります", exp)
+	}
+
+	if got := b.decrypt(t, service.body); string(got) != string(payload) {
+		t.Errorf("復号した本文 = %q, want %q", got, payload)
+	}
+}
+
+func TestSendStatus(t *testing.T) {
+	tests := []struct {
+		status      int
+		wantErr     bool
+		wantExpired bool
+	}{
+		{http.StatusCreated, false, false},
+		{http.StatusOK, false, false},
+		{http.StatusNotFound, true, true},
+		{http.StatusGone, true, true},
+		{http.StatusBadRequest, true, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusInternalServerError, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			service := newFakePushService(t, tt.status)
+			client := NewClient(newTestVAPID(t), service.server.Client())
+
+			err := client.Send(context.Background(), newBrowser(t).subscription(service.server.URL), []byte("{}"))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, domain.ErrPushSubscriptionExpired) != tt.wantExpired {
+				t.Errorf("Send() error = %v, 購読の無効化を表すかどうか want %v", err, tt.wantExpired)
+			}
+		})
+	}
+}
+
+func TestSendForbiddenAddress(t *testing.T) {
+	service := newFakePushService(t, http.StatusCreated)
+
+	// 既定のクライアントはループバックのプッシュサービスに接続しない
+	client := NewClient(newTestVAPID(t), NewHTTPClient(5*time.Second))
+	err := client.Send(context.Background(), newBrowser(t).subscription(service.server.URL), []byte("{}"))
+	if !errors.Is(err, netguard.ErrForbiddenAddress) {
+		t.Errorf("Send() error = %v, want %v", err, netguard.ErrForbiddenAddress)
+	}
+	if service.body != nil {
+		t.Error("ループバックのプッシュサービスに送信しました")
+	}
+}
@@ -0,0 +1,116 @@
+package webpush
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// 暗号化したメッセージの1レコードの大きさ（RFC 8188）
+const recordSize = 4096
+
+// 暗号化の前に送信できる本文の最大サイズ
+// ヘッダー（salt 16 + rs 4 + idlen 1 + 公開鍵 65）と区切り1バイト・認証タグ16バイトを含めて4096バイトに収める
+const MaxPayloadSize = recordSize - 86 - 1 - 16
+
+// 本文が大きすぎることを表すエラー
+var ErrPayloadTooLarge = errors.New("プッシュ通知の本文が大きすぎます")
+
+// Encrypt ブラウザの鍵で本文を暗号化する（RFC 8291、Content-Encoding: aes128gcm）
+// p256dhとauthは購読時にブラウザが生成したBase64URLの鍵
+func Encrypt(p256dh string, auth string, plaintext []byte) ([]byte, error) {
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encrypt(p256dh, auth, plaintext, serverKey, salt)
+}
+
+// 送信のたびに生成するサーバーの鍵とsaltを指定して暗号化する
+func encrypt(p256dh string, auth string, plaintext []byte, serverKey *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	if len(plaintext) > MaxPayloadSize {
+		return nil, ErrPayloadTooLarge
+	}
+	uaPublicBytes, err := decodeKey(p256dh)
+	if err != nil {
+		return nil, errors.New("ブラウザの公開鍵が正しくありません")
+	}
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicBytes)
+	if err != nil {
+		return nil, errors.New("ブラウザの公開鍵が正しくありません")
+	}
+	authSecret, err := decodeKey(auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, errors.New("認証用のシークレットが正しくありません")
+	}
+
+	sharedSecret, err := serverKey.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := serverKey.PublicKey().Bytes()
+
+	// IKM = HKDF(auth_secret, ecdh_secret, "WebPush: info" || 0x00 || ua_public || as_public, 32)
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublicBytes...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm, err := deriveKey(sharedSecret, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+	// コンテンツの暗号化鍵とナンス（RFC 8188）
+	cek, err := deriveKey(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := deriveKey(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// ヘッダー: salt(16) || rs(4) || idlen(1) || keyid（サーバーの公開鍵）
+	header := make([]byte, 0, 21+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	// 1レコードのみのため、最後のレコードを表す区切り（0x02）を付けて暗号化する
+	record := append(append([]byte{}, plaintext...), 0x02)
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// HKDF-SHA256で鍵を導出する
+func deriveKey(secret []byte, salt []byte, info []byte, length int) ([]byte, error) {
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Base64URLの鍵をデコードする（パディングの有無はブラウザによって異なる）
+func decodeKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
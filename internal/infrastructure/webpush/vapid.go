@@ -0,0 +1,102 @@
+package webpush
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// VAPIDの署名の有効期間（RFC 8292では24時間以内）
+const vapidTokenLifetime = 12 * time.Hour
+
+// VAPID アプリケーションサーバーの鍵（RFC 8292）
+// プッシュサービスに送信元のサーバーを証明するために使用する
+type VAPID struct {
+	privateKey *ecdsa.PrivateKey
+	publicKey  string // 非圧縮形式の公開鍵（Base64URL、ブラウザの購読時に指定する）
+	subject    string // 連絡先（mailto:またはhttps:のURL）
+}
+
+// ParseVAPID Base64URLの秘密鍵からVAPIDの鍵を生成する
+// 公開鍵は秘密鍵から計算し、設定された公開鍵と一致するかを確認する
+func ParseVAPID(publicKey string, privateKey string, subject string) (*VAPID, error) {
+	d, err := decodeKey(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("VAPIDの秘密鍵が正しくありません")
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, errors.New("VAPIDの秘密鍵が正しくありません")
+	}
+	pub := key.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(pub) != publicKey {
+		return nil, errors.New("VAPIDの公開鍵が秘密鍵と一致しません")
+	}
+	u, err := url.Parse(subject)
+	if err != nil || (u.Scheme != "mailto" && u.Scheme != "https") {
+		return nil, errors.New("VAPIDのsubjectはmailto:またはhttps:で指定してください")
+	}
+
+	x, y := elliptic.Unmarshal(elliptic.P256(), pub)
+	return &VAPID{
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y},
+			D:         new(big.Int).SetBytes(d),
+		},
+		publicKey: publicKey,
+		subject:   subject,
+	}, nil
+}
+
+// GenerateVAPIDKeys VAPIDの鍵の組を生成する（Base64URLの公開鍵と秘密鍵）
+func GenerateVAPIDKeys() (publicKey string, privateKey string, err error) {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(key.Bytes()), nil
+}
+
+// PublicKey ブラウザの購読時に指定する公開鍵
+func (v *VAPID) PublicKey() string {
+	return v.publicKey
+}
+
+// Authorization プッシュサービスに送信するAuthorizationヘッダーの値
+// 宛先はエンドポイントのオリジンで、ES256で署名したJWTと公開鍵を含む
+func (v *VAPID) Authorization(endpoint string, now time.Time) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.New("エンドポイントのURLが正しくありません")
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": now.Add(vapidTokenLifetime).Unix(),
+		"sub": v.subject,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, v.privateKey, digest[:])
+	if err != nil {
+		return "", err
+	}
+	// JWSのES256の署名はrとsをそれぞれ32バイトにして連結する
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)
+	return "vapid t=" + token + ", k=" + v.publicKey, nil
+}
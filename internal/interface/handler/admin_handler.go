@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/markup"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// 管理画面に表示する監査ログの件数
+const auditLogDisplayLimit = 50
+
+// 通報の管理ページのデータ構造体
+type ModerationPageData struct {
+	IsLoggedIn bool                  // ログイン状態
+	User       *domain.User          // 管理者のユーザー情報
+	Status     domain.ReportStatus   // 表示している通報の状態
+	Statuses   []domain.ReportStatus // 切り替えられる通報の状態
+	Reports    []domain.Report       // 通報の一覧
+	AuditLogs  []domain.AuditLog     // 最近の監査ログ
+	Error      string                // 操作に失敗した場合のエラー
+}
+
+// 通報の管理ページのハンドラ
+func ModerationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	status := domain.ReportStatus(r.URL.Query().Get("status"))
+	switch status {
+	case domain.ReportStatusOpen, domain.ReportStatusDismissed, domain.ReportStatusResolved:
+	default:
+		status = domain.ReportStatusOpen
+	}
+
+	reports, err := firebase.GetReports(status)
+	if err != nil {
+		log.Printf("通報の取得に失敗: status=%s, error=%v", status, err)
+		http.Error(w, "通報の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	auditLogs, err := firebase.GetAuditLogs(auditLogDisplayLimit)
+	if err != nil {
+		log.Printf("監査ログの取得に失敗: error=%v", err)
+	}
+
+	data := ModerationPageData{
+		IsLoggedIn: true,
+		User:       admin,
+		Status:     status,
+		Statuses:   []domain.ReportStatus{domain.ReportStatusOpen, domain.ReportStatusDismissed, domain.ReportStatusResolved},
+		Reports:    reports,
+		AuditLogs:  auditLogs,
+		Error:      r.URL.Query().Get("error"),
+	}
+	markup.GenerateHTML(w, data, "layout", "header", "admin_nav", "admin_reports", "footer")
+}
+
+// 通報に対する操作のハンドラ
+func ModerationActionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	reportID := r.FormValue("report_id")
+	action := domain.ModerationAction(r.FormValue("action"))
+	if reportID == "" {
+		http.Error(w, "通報IDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	// 先に対応済みにして、同じ通報に対する操作の重複を防ぐ
+	report, err := firebase.ClaimReport(reportID, action, admin.ID)
+	if err != nil {
+		redirectModeration(w, r, err)
+		return
+	}
+
+	detail, err := applyModeration(report, action)
+	if err != nil {
+		log.Printf("通報への対応に失敗: reportID=%s, action=%s, error=%v", reportID, action, err)
+		if err := firebase.ReopenReport(reportID); err != nil {
+			log.Printf("通報の状態の復元に失敗: reportID=%s, error=%v", reportID, err)
+		}
+		redirectModeration(w, r, errors.New("通報への対応に失敗しました"))
+		return
+	}
+
+	// 監査ログを記録
+	err = firebase.AddAuditLog(domain.AuditLog{
+		Action:       action.AuditAction(),
+		ActorID:      admin.ID,
+		ActorName:    admin.Name,
+		ReportID:     report.ID,
+		TargetUserID: report.ReportedUserID,
+		ChatID:       report.ChatID,
+		MessageID:    report.MessageID,
+		Detail:       detail,
+		CreatedAt:    report.HandledAt,
+	})
+	if err != nil {
+		log.Printf("監査ログの保存に失敗: reportID=%s, error=%v", reportID, err)
+	}
+
+	redirectModeration(w, r, nil)
+}
+
+// 通報に対する操作を実行し、監査ログの補足情報を返す
+func applyModeration(report *domain.Report, action domain.ModerationAction) (string, error) {
+	switch action {
+	case domain.ModerationDeleteMessage:
+		message, err := firebase.DeleteChatMessage(report.ChatID, report.MessageID)
+		if err != nil {
+			return "", err
+		}
+		go chat.NotifyWebhooks(domain.WebhookMessageDeleted, report.ChatID, chat.WebhookMessageFromData(message))
+
+		// 添付されたメディアとピン留めも削除
+		if mediaPath, ok := message["media_path"].(string); ok && mediaPath != "" {
+			if err := firebase.DeleteMedia(mediaPath); err != nil {
+				log.Printf("メディアの削除に失敗: chatID=%s, path=%s, error=%v", report.ChatID, mediaPath, err)
+			}
+		}
+		if err := firebase.UnpinChatMessage(report.ChatID, report.MessageID); err != nil && !errors.Is(err, domain.ErrNotPinned) {
+			log.Printf("ピン留めの解除に失敗: chatID=%s, messageID=%s, error=%v", report.ChatID, report.MessageID, err)
+		}
+		return report.Reason.Label(), nil
+	case domain.ModerationSuspendUser:
+		if err := firebase.SuspendUser(report.ReportedUserID); err != nil {
+			return "", err
+		}
+		return report.ReportedUserName + "（" + report.Reason.Label() + "）", nil
+	default:
+		return report.Reason.Label(), nil
+	}
+}
+
+// 管理者かどうかを確認する
+// 権限はセッションではなく最新のユーザー情報で判定する
+func requireAdmin(w http.ResponseWriter, r *http.Request) (*domain.User, bool) {
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, false
+	}
+
+	user, err := repository.GetUserByID(session.User.ID)
+	if err != nil {
+		log.Printf("ユーザー情報の取得に失敗: userID=%s, error=%v", session.User.ID, err)
+		http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !user.IsAdmin || user.IsSuspended {
+		http.Error(w, domain.ErrAdminRequired.Error(), http.StatusForbidden)
+		return nil, false
+	}
+	return user, true
+}
+
+// 通報の管理ページに戻る
+func redirectModeration(w http.ResponseWriter, r *http.Request, err error) {
+	target := "/admin/reports"
+	if err != nil {
+		target += "?error=" + url.QueryEscape(err.Error())
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
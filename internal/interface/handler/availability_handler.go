@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// 在席状況とおやすみモードの設定ハンドラ（設定ページ）
+func AvailabilitySettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	schedule := domain.DNDSchedule{
+		Enabled:  r.FormValue("dnd_enabled") == "on",
+		Start:    r.FormValue("dnd_start"),
+		End:      r.FormValue("dnd_end"),
+		TimeZone: r.FormValue("time_zone"),
+	}
+	availability, err := domain.ParseAvailability(r.FormValue("availability"))
+	if err == nil {
+		err = domain.ValidateDNDSchedule(schedule)
+	}
+	if err == nil {
+		if err = firebase.UpdateAvailability(session.User.ID, availability, schedule); err != nil {
+			log.Printf("在席状況の更新に失敗: userID=%s, error=%v", session.User.ID, err)
+			err = errors.New("在席状況の更新に失敗しました")
+		}
+	}
+	if err != nil {
+		renderSettings(w, SettingsPageData{
+			IsLoggedIn:         true,
+			User:               session.User,
+			Availability:       availability,
+			DNDSchedule:        schedule,
+			AvailabilityErrors: []string{err.Error()},
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/settings?success=在席状況を更新しました", http.StatusSeeOther)
+}
+
+// Firestoreのユーザーデータから在席状況とおやすみモードのスケジュールを取得する
+func convertAvailability(userData map[string]interface{}) (domain.Availability, domain.DNDSchedule) {
+	value, _ := userData["Availability"].(string)
+	availability, err := domain.ParseAvailability(value)
+	if err != nil {
+		log.Printf("在席状況の取得に失敗: value=%s, error=%v", value, err)
+	}
+
+	var schedule domain.DNDSchedule
+	if data, ok := userData["DNDSchedule"].(map[string]interface{}); ok {
+		schedule.Enabled, _ = data["Enabled"].(bool)
+		schedule.Start, _ = data["Start"].(string)
+		schedule.End, _ = data["End"].(string)
+		schedule.TimeZone, _ = data["TimeZone"].(string)
+	}
+	return availability, schedule
+}
+
+// Firestoreのユーザーデータから他のユーザーに表示する在席状況を取得する
+func presenceOf(userData map[string]interface{}, now time.Time) domain.Availability {
+	user := domain.User{}
+	user.IsOnline, _ = userData["IsOnline"].(bool)
+	user.Availability, user.DNDSchedule = convertAvailability(userData)
+	return user.PresenceAt(now)
+}
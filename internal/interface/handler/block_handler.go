@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// ユーザーのブロックハンドラ
+func BlockUserHandler(w http.ResponseWriter, r *http.Request) {
+	updateBlock(w, r, true)
+}
+
+// ユーザーのブロック解除ハンドラ
+func UnblockUserHandler(w http.ResponseWriter, r *http.Request) {
+	updateBlock(w, r, false)
+}
+
+// ブロックの状態を更新する
+func updateBlock(w http.ResponseWriter, r *http.Request, block bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	userID := r.FormValue("userID")
+	if userID == "" {
+		http.Error(w, "ユーザーIDが必要です", http.StatusBadRequest)
+		return
+	}
+	if userID == session.User.ID {
+		http.Error(w, domain.ErrCannotBlockSelf.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if block {
+		if _, err := GetUserData(userID); err != nil {
+			http.Error(w, "ユーザーが見つかりません", http.StatusNotFound)
+			return
+		}
+		err = firebase.BlockUser(session.User.ID, userID)
+		if err == nil {
+			// ブロックした相手とは連絡先の関係も解消する
+			chat.ClearContact(session.User.ID, userID)
+		}
+	} else {
+		err = firebase.UnblockUser(session.User.ID, userID)
+	}
+	if err != nil {
+		log.Printf("ブロックの更新に失敗: userID=%s, targetUserID=%s, block=%t, error=%v", session.User.ID, userID, block, err)
+		http.Error(w, "ブロックの更新に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"blocked": block,
+	})
+}
+
+// ブロックしているユーザーのIDの集合を取得する
+func getBlockedUserSet(userID string) (map[string]bool, error) {
+	ids, err := firebase.GetBlockedUserIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		blocked[id] = true
+	}
+	return blocked, nil
+}
+
+// 送信者がチャットの相手にブロックされていないかどうか
+func canSendToChat(chatID string, senderID string) (bool, error) {
+	participants, err := firebase.GetChatParticipants(chatID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range participants {
+		if p == senderID {
+			continue
+		}
+		blocked, err := firebase.IsBlocked(p, senderID)
+		if err != nil {
+			return false, err
+		}
+		if blocked {
+			return false, nil
+		}
+	}
+	return true, nil
+}
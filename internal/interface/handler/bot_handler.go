@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// ボットの作成ハンドラ（設定ページ）
+// APIトークンは作成時にしか表示できないため、リダイレクトせずに設定ページを表示する
+func CreateBotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	data := SettingsPageData{
+		IsLoggedIn: true,
+		User:       session.User,
+	}
+
+	name := r.FormValue("name")
+	bot, token, err := chat.CreateBot(session.User, name)
+	if err != nil {
+		if !isBotValidationError(err) {
+			log.Printf("ボットの作成に失敗: userID=%s, error=%v", session.User.ID, err)
+			err = errors.New("ボットの作成に失敗しました")
+		}
+		data.NewBotName = name
+		data.BotErrors = []string{err.Error()}
+		renderSettings(w, data)
+		return
+	}
+
+	data.CreatedBot = bot
+	data.CreatedBotToken = token
+	renderSettings(w, data)
+}
+
+// ボットの削除ハンドラ（設定ページ）
+func DeleteBotHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	botID := r.FormValue("botID")
+	bot, err := firebase.GetBot(botID)
+	if err != nil || bot.OwnerID != session.User.ID {
+		http.Error(w, domain.ErrBotNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err := firebase.DeleteBot(bot.ID); err != nil {
+		log.Printf("ボットの削除に失敗: botID=%s, error=%v", bot.ID, err)
+		http.Error(w, "ボットの削除に失敗しました", http.StatusInternalServerError)
+		return
+	}
+	chat.UnregisterBot(bot.ID)
+
+	http.Redirect(w, r, "/settings?success=ボットを削除しました", http.StatusSeeOther)
+}
+
+// チャットへのボットの追加ハンドラ
+// 追加できるのは自分が作成したボットだけ
+func AddChatBotHandler(w http.ResponseWriter, r *http.Request) {
+	updateChatBot(w, r, true)
+}
+
+// チャットからのボットの削除ハンドラ
+// チャットの参加者であれば、誰が追加したボットでも外せる
+func RemoveChatBotHandler(w http.ResponseWriter, r *http.Request) {
+	updateChatBot(w, r, false)
+}
+
+// チャットに参加しているボットを更新する
+func updateChatBot(w http.ResponseWriter, r *http.Request, add bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	botID := r.FormValue("botID")
+	if chatID == "" || botID == "" {
+		http.Error(w, "チャットIDとボットIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者であることを確認
+	if ok, err := isChatParticipant(chatID, session.User.ID); err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	bot, err := firebase.GetBot(botID)
+	if err != nil || (add && bot.OwnerID != session.User.ID) {
+		http.Error(w, domain.ErrBotNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	bots, err := firebase.GetChatBots(chatID)
+	if err != nil {
+		log.Printf("チャットのボットの取得に失敗: chatID=%s, error=%v", chatID, err)
+		http.Error(w, "ボットの更新に失敗しました", http.StatusInternalServerError)
+		return
+	}
+	joined := false
+	for _, id := range bots {
+		if id == botID {
+			joined = true
+			break
+		}
+	}
+
+	var notice string
+	if add {
+		if joined {
+			http.Error(w, domain.ErrBotAlreadyInChat.Error(), http.StatusConflict)
+			return
+		}
+		// ボットはメッセージを復号できないため、暗号化されたチャットには追加できない
+		if e2ee, err := firebase.IsChatE2EE(chatID); err != nil || e2ee {
+			http.Error(w, domain.ErrE2EENotSupported.Error(), http.StatusBadRequest)
+			return
+		}
+		err = firebase.AddChatBot(chatID, botID)
+		notice = fmt.Sprintf("%sさんがボット「%s」を追加しました", session.User.Name, bot.Name)
+	} else {
+		if !joined {
+			http.Error(w, domain.ErrBotNotInChat.Error(), http.StatusNotFound)
+			return
+		}
+		err = firebase.RemoveChatBot(chatID, botID)
+		notice = fmt.Sprintf("%sさんがボット「%s」を外しました", session.User.Name, bot.Name)
+	}
+	if err != nil {
+		log.Printf("チャットのボットの更新に失敗: chatID=%s, botID=%s, add=%t, error=%v", chatID, botID, add, err)
+		http.Error(w, "ボットの更新に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	if err := addSystemMessage(chatID, notice); err != nil {
+		log.Printf("システムメッセージの追加に失敗: chatID=%s, error=%v", chatID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"bot_id": botID,
+		"joined": add,
+	})
+}
+
+// ボットのメッセージ送信API
+// Authorization: Bearer <APIトークン> で認証し、JSONで {"chat_id": "...", "content": "..."} を受け取る
+func BotMessageAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		http.Error(w, domain.ErrInvalidBotToken.Error(), http.StatusUnauthorized)
+		return
+	}
+	bot, err := chat.AuthenticateBot(strings.TrimSpace(token))
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidBotToken) {
+			log.Printf("ボットの認証に失敗: error=%v", err)
+		}
+		http.Error(w, domain.ErrInvalidBotToken.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		ChatID  string `json:"chat_id"`
+		Content string `json:"content"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "リクエストの形式が正しくありません", http.StatusBadRequest)
+		return
+	}
+	if req.ChatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	msg, err := chat.PostAsBot(bot.ID, req.ChatID, req.Content)
+	if err != nil {
+		var rejected *domain.MessageRejectedError
+		switch {
+		case errors.Is(err, domain.ErrBotNotInChat):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.As(err, &rejected):
+			status := http.StatusBadRequest
+			if errors.Is(err, domain.ErrMessageNotAllowed) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, rejected.Error(), status)
+		default:
+			log.Printf("ボットのメッセージの送信に失敗: botID=%s, chatID=%s, error=%v", bot.ID, req.ChatID, err)
+			http.Error(w, "メッセージの送信に失敗しました", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         msg.ID,
+		"chat_id":    msg.ChatID,
+		"content":    msg.Content,
+		"created_at": msg.CreatedAt,
+	})
+}
+
+// チャットに参加しているボットを取得する（取得できないボットは除く）
+func getChatBots(chatID string) []domain.Bot {
+	ids, err := firebase.GetChatBots(chatID)
+	if err != nil {
+		log.Printf("チャットのボットの取得に失敗: chatID=%s, error=%v", chatID, err)
+		return nil
+	}
+	var bots []domain.Bot
+	for _, id := range ids {
+		bot, err := firebase.GetBot(id)
+		if err != nil {
+			continue
+		}
+		bots = append(bots, *bot)
+	}
+	return bots
+}
+
+// 利用者に表示するボットのエラーかどうか
+func isBotValidationError(err error) bool {
+	return errors.Is(err, domain.ErrBotNameInvalid) || errors.Is(err, domain.ErrBotLimitExceeded)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"security_chat_app/internal/infrastructure/repository"
 	"security_chat_app/internal/interface/markup"
 	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
 )
 
 // チャット開始ハンドラ
@@ -51,6 +53,29 @@ func StartChatHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// 相手にブロックされている場合はチャットを開始できない（ブロックされていることは伝えない）
+	blocked, err := firebase.IsBlocked(targetUserID, user.ID)
+	if err != nil {
+		log.Printf("ブロックの確認に失敗: userID=%s, targetUserID=%s, error=%v", user.ID, targetUserID, err)
+		http.Error(w, "チャットの開始に失敗しました", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		http.Error(w, domain.ErrChatUnavailable.Error(), http.StatusForbidden)
+		return
+	}
+
+	// 相手が連絡先からのチャットのみ受け付けている場合は、連絡先でなければ開始できない
+	if err := chat.CheckChatAllowed(user.ID, targetUserID); err != nil {
+		if errors.Is(err, domain.ErrContactsOnly) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		log.Printf("チャットの開始の確認に失敗: userID=%s, targetUserID=%s, error=%v", user.ID, targetUserID, err)
+		http.Error(w, "チャットの開始に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
 	// チャットを開始
 	chatID, err := firebase.StartChat(user.ID, targetUserID)
 	if err != nil {
@@ -85,51 +110,97 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 		chatID := r.FormValue("chatID")
 		content := r.FormValue("content")
 
-		if chatID == "" || content == "" {
-			log.Fatalf("チャットIDとメッセージ内容が必要です")
+		if chatID == "" {
+			http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
 			return
 		}
 
-		// メッセージIDを生成
-		messageID := generateMessageID()
+		// メッセージの検証・変換・保存・通知はパイプラインで行う
+		msg := &chat.OutgoingMessage{
+			ChatID:        chatID,
+			SenderID:      user.ID,
+			SenderName:    user.Name,
+			Content:       content,
+			AllowCommands: true,
+		}
 
-		// メッセージを作成
-		message := map[string]interface{}{
-			"id":         messageID,
-			"sender_id":  user.ID,
-			"sender_name": user.Name,
-			"content":    content,
-			"created_at": time.Now(),
-			"is_read":    false,
-			"type":       "text",
+		// 暗号化されたチャットでは、クライアントで暗号化した暗号文を受け取る
+		if encrypted := encryptedEnvelopeFromForm(r); encrypted != nil {
+			msg.Type = domain.MessageTypeEncrypted
+			msg.Encrypted = encrypted
+			msg.AllowCommands = false
+		}
+		if err := chat.SendMessage(msg); err != nil {
+			var rejected *domain.MessageRejectedError
+			if errors.As(err, &rejected) {
+				status := http.StatusBadRequest
+				if errors.Is(err, domain.ErrNotChatParticipant) || errors.Is(err, domain.ErrMessageNotAllowed) {
+					status = http.StatusForbidden
+				}
+				// 相手の鍵が更新された場合は、クライアントが鍵を取得し直して暗号化し直す
+				if errors.Is(err, domain.ErrE2EEKeyOutdated) {
+					status = http.StatusConflict
+				}
+				http.Error(w, rejected.Error(), status)
+				return
+			}
+			log.Printf("メッセージの送信に失敗: chatID=%s, error=%v", chatID, err)
+			http.Error(w, "メッセージの送信に失敗しました", http.StatusInternalServerError)
+			return
 		}
 
-		// メッセージを保存
-		err = firebase.AddChatMessage(chatID, message)
-		if err != nil {
-			log.Fatalf("メッセージの送信に失敗: %v", err)
+		// 送信したメッセージの下書きを削除
+		clearDraft(chatID, user.ID)
+
+		// コマンドで処理が完了した場合は、送信者だけに応答を返す
+		if msg.Handled {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"handled": true,
+				"reply":   msg.Reply,
+			})
 			return
 		}
 
-		// チャットの最終更新時刻を更新
-		err = firebase.UpdateField("chats", chatID, "updated_at", time.Now())
-		if err != nil {
-			log.Printf("チャットの更新時刻の更新に失敗: %v", err)
+		// 保持期間が設定されている場合は削除される日時を返す
+		expiresAt := ""
+		if chatData, err := firebase.GetData("chats", chatID); err == nil {
+			retention, _ := domain.ParseRetentionPolicy(getRetentionValue(chatData))
+			if t := retention.ExpiresAt(msg.CreatedAt); !t.IsZero() {
+				expiresAt = t.Format(time.RFC3339)
+			}
 		}
 
 		// JSONレスポンスを返す
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"id":         messageID,
-			"content":    content,
+			"id":         msg.ID,
+			"type":       string(msg.Type),
+			"content":    msg.Content,
 			"sender_id":  user.ID,
 			"sender_name": user.Name,
-			"created_at": time.Now().Format("15:04"),
+			"created_at": msg.CreatedAt.Format("15:04"),
 			"is_read":    false,
+			"expires_at": expiresAt,
+			"mentions":   mentionsToData(msg.Mentions),
+			"html":       markup.RenderMessage(msg.Content, msg.Mentions),
+			"has_link":   msg.LinkURL != "",
 		})
 		return
 	}
 
+	// URLからチャットIDを取得
+	chatID := r.URL.Query().Get("chat_id")
+
+	// 開いたチャットのメッセージを既読にする
+	if chatID != "" {
+		if ok, err := isChatParticipant(chatID, user.ID); err == nil && ok {
+			if err := firebase.MarkChatMessagesAsRead(chatID, user.ID); err != nil {
+				log.Printf("メッセージの既読化に失敗: chatID=%s, error=%v", chatID, err)
+			}
+		}
+	}
+
 	// チャット履歴を取得
 	chats, err := getChatHistory(user)
 	if err != nil {
@@ -137,17 +208,19 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// URLからチャットIDを取得
-	chatID := r.URL.Query().Get("chat_id")
+	// 一覧に表示するチャットとアーカイブしたチャットに振り分ける
+	activeChats, archivedChats := splitChatList(chats)
+
 	if chatID == "" {
 		// チャットIDがない場合は、チャット一覧を表示
 		data := domain.TemplateData{
-			IsLoggedIn: true,
-			User:       user,
-			Chats:      chats,
-			ChatID:     "", // 空のチャットIDを設定
+			IsLoggedIn:    true,
+			User:          user,
+			Chats:         activeChats,
+			ArchivedChats: archivedChats,
+			ChatID:        "", // 空のチャットIDを設定
 		}
-		markup.GenerateHTML(w, data, "layout", "header", "chat", "footer")
+		markup.GenerateHTML(w, data, "layout", "header", "chat", "report_dialog", "footer")
 		return
 	}
 
@@ -202,49 +275,7 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 	// メッセージの型変換
 	var messages []domain.Message
 	for _, msg := range messagesData {
-		// 各フィールドを安全に取得する関数
-		getString := func(key string) string {
-			if val, exists := msg[key]; exists && val != nil {
-				if str, ok := val.(string); ok {
-					return str
-				}
-			}
-			// 大文字のキーも試す
-			if val, exists := msg[strings.ToUpper(key)]; exists && val != nil {
-				if str, ok := val.(string); ok {
-					return str
-				}
-			}
-			return ""
-		}
-
-		// 時刻の取得
-		var createdAt time.Time
-		if t, ok := msg["created_at"].(time.Time); ok {
-			createdAt = t
-		} else if t, ok := msg["CreatedAt"].(time.Time); ok {
-			createdAt = t
-		} else {
-			createdAt = time.Now() // デフォルト値
-		}
-
-		// 既読状態の取得
-		isRead := false
-		if r, ok := msg["is_read"].(bool); ok {
-			isRead = r
-		} else if r, ok := msg["IsRead"].(bool); ok {
-			isRead = r
-		}
-
-		message := domain.Message{
-			ID:         getString("id"),
-			Content:    getString("content"),
-			SenderID:   getString("sender_id"),
-			SenderName: getString("sender_name"),
-			CreatedAt:  createdAt,
-			IsRead:     isRead,
-		}
-		messages = append(messages, message)
+		messages = append(messages, convertMessage(msg))
 	}
 
 	// 現在のチャットを特定
@@ -256,19 +287,47 @@ func ChatHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// チャットに参加しているボットと、追加できる自分のボットを取得
+	if currentChat != nil {
+		currentChat.Bots = getChatBots(chatID)
+	}
+
+	// 暗号化されたチャットでは、相手の鍵を確認済みかどうかを表示する
+	if currentChat != nil && currentChat.E2EE {
+		_, verified, err := chat.GetSafetyNumber(user.ID, currentChat.Contact.ID)
+		if err != nil && !errors.Is(err, domain.ErrIdentityKeyNotFound) {
+			log.Printf("安全番号の取得に失敗: chatID=%s, error=%v", chatID, err)
+		}
+		currentChat.KeyVerified = verified
+	}
+	ownBots, err := firebase.GetBotsByOwner(user.ID)
+	if err != nil {
+		log.Printf("ボットの取得に失敗: userID=%s, error=%v", user.ID, err)
+	}
+
+	// 自分が予約している送信待ちのメッセージを取得
+	scheduledMessages, err := getPendingScheduledMessages(chatID, user.ID)
+	if err != nil {
+		log.Printf("予約メッセージの取得に失敗: chatID=%s, error=%v", chatID, err)
+	}
+
 	// チャットページのデータを取得
 	data := domain.TemplateData{
 		IsLoggedIn:  true,
 		User:        user,
 		Messages:    messages,
-		Contacts:    []domain.Contact{{ID: targetUser.ID, Username: targetUser.Name, Icon: targetUser.Icon}},
-		Chats:       chats,
-		CurrentChat: currentChat,
-		ChatID:      chatID,
+		Contacts:      []domain.Contact{{ID: targetUser.ID, Username: targetUser.Name, Icon: targetUser.Icon}},
+		Chats:         activeChats,
+		ArchivedChats: archivedChats,
+		CurrentChat:   currentChat,
+		ChatID:        chatID,
+
+		ScheduledMessages: scheduledMessages,
+		OwnBots:           ownBots,
 	}
 
 	// テンプレートのレンダリング
-	markup.GenerateHTML(w, data, "layout", "header", "chat", "footer")
+	markup.GenerateHTML(w, data, "layout", "header", "chat", "report_dialog", "footer")
 }
 
 // チャット履歴を取得
@@ -282,6 +341,18 @@ func getChatHistory(user *domain.User) ([]domain.Chat, error) {
 	var chatHistory []domain.Chat
 	seenChats := make(map[string]bool) // 重複チェック用のマップ
 
+	// 下書きの取得（取得に失敗しても一覧は表示する）
+	drafts, err := firebase.GetDrafts(user.ID)
+	if err != nil {
+		log.Printf("下書きの取得に失敗: userID=%s, error=%v", user.ID, err)
+	}
+
+	// ブロックしているユーザーの取得
+	blockedUsers, err := getBlockedUserSet(user.ID)
+	if err != nil {
+		log.Printf("ブロックしているユーザーの取得に失敗: userID=%s, error=%v", user.ID, err)
+	}
+
 	for _, chatData := range chats {
 		// チャットIDの取得
 		chatID, ok := chatData["id"].(string)
@@ -325,60 +396,53 @@ func getChatHistory(user *domain.User) ([]domain.Chat, error) {
 			continue
 		}
 
+		// ピン留めされたメッセージの取得
+		pinnedMessages := convertPinnedMessages(chatData["pinned_messages"])
+		pinnedIDs := make(map[string]bool)
+		for _, pm := range pinnedMessages {
+			pinnedIDs[pm.MessageID] = true
+		}
+
+		// メッセージの保持期間の取得
+		retention, err := domain.ParseRetentionPolicy(getRetentionValue(chatData))
+		if err != nil {
+			log.Printf("保持期間の取得に失敗: chatID=%s, error=%v", chatID, err)
+		}
+
 		// メッセージの型変換
 		var messages []domain.Message
 		var lastMessageTime time.Time
+		unreadCount := 0
+		mentionCount := 0
+		now := time.Now()
 		for _, msg := range messagesData {
-			// 各フィールドを安全に取得する関数
-			getString := func(key string) string {
-				if val, exists := msg[key]; exists && val != nil {
-					if str, ok := val.(string); ok {
-						return str
-					}
-				}
-				// 大文字のキーも試す
-				if val, exists := msg[strings.ToUpper(key)]; exists && val != nil {
-					if str, ok := val.(string); ok {
-						return str
-					}
-				}
-				return ""
-			}
+			message := convertMessage(msg)
+			message.IsPinned = pinnedIDs[message.ID]
 
-			// 時刻の取得
-			var createdAt time.Time
-			if t, ok := msg["created_at"].(time.Time); ok {
-				createdAt = t
-			} else if t, ok := msg["CreatedAt"].(time.Time); ok {
-				createdAt = t
-			} else {
-				createdAt = time.Now() // デフォルト値
-			}
-
-			// 既読状態の取得
-			isRead := false
-			if r, ok := msg["is_read"].(bool); ok {
-				isRead = r
-			} else if r, ok := msg["IsRead"].(bool); ok {
-				isRead = r
+			// 保持期間を過ぎたメッセージは削除前でも表示しない
+			message.ExpiresAt = retention.ExpiresAt(message.CreatedAt)
+			if !message.ExpiresAt.IsZero() && now.After(message.ExpiresAt) {
+				continue
 			}
+			messages = append(messages, message)
 
-			message := domain.Message{
-				ID:         getString("id"),
-				Content:    getString("content"),
-				SenderID:   getString("sender_id"),
-				SenderName: getString("sender_name"),
-				CreatedAt:  createdAt,
-				IsRead:     isRead,
+			// 相手から届いた未読メッセージを数える
+			if !message.IsRead && message.SenderID != user.ID && message.Type != domain.MessageTypeSystem {
+				unreadCount++
+				if message.MentionsUser(user.ID) {
+					mentionCount++
+				}
 			}
-			messages = append(messages, message)
 
 			// 最新のメッセージ時刻を更新
-			if createdAt.After(lastMessageTime) {
-				lastMessageTime = createdAt
+			if message.CreatedAt.After(lastMessageTime) {
+				lastMessageTime = message.CreatedAt
 			}
 		}
 
+		// エンドツーエンド暗号化が有効かどうか
+		e2ee, _ := chatData["e2ee_enabled"].(bool)
+
 		// チャット相手の情報を取得
 		targetUser, err := GetUserData(targetUserID)
 		if err != nil {
@@ -386,7 +450,8 @@ func getChatHistory(user *domain.User) ([]domain.Chat, error) {
 			continue
 		}
 
-		// チャット履歴に追加
+		// チャット履歴に追加（オフライン表示の相手はオフラインとして表示する）
+		presence := targetUser.PresenceAt(now)
 		chatHistory = append(chatHistory, domain.Chat{
 			ID: chatID,
 			Contact: domain.Contact{
@@ -394,10 +459,20 @@ func getChatHistory(user *domain.User) ([]domain.Chat, error) {
 				Username: targetUser.Name,
 				Icon:     targetUser.Icon,
 				LastSeen: time.Now(),
-				IsOnline: targetUser.IsOnline,
+				IsOnline: presence != domain.AvailabilityOffline,
+				Presence: presence,
+				Profile:  targetUser.Profile,
 			},
-			Messages:  messages,
-			UpdatedAt: lastMessageTime,
+			Messages:       messages,
+			UpdatedAt:      lastMessageTime,
+			PinnedMessages: pinnedMessages,
+			Settings:       convertChatSettings(chatData["participant_settings"], user.ID),
+			UnreadCount:    unreadCount,
+			Retention:      retention,
+			MentionCount:   mentionCount,
+			Draft:          drafts[chatID].Content,
+			IsBlocked:      blockedUsers[targetUserID],
+			E2EE:           e2ee,
 		})
 	}
 
@@ -409,6 +484,162 @@ func getChatHistory(user *domain.User) ([]domain.Chat, error) {
 	return chatHistory, nil
 }
 
+// Firestoreのメッセージデータをドメインの構造体に変換
+func convertMessage(msg map[string]interface{}) domain.Message {
+	// 各フィールドを安全に取得する関数
+	getString := func(key string) string {
+		if val, exists := msg[key]; exists && val != nil {
+			if str, ok := val.(string); ok {
+				return str
+			}
+		}
+		// 大文字のキーも試す
+		if val, exists := msg[strings.ToUpper(key)]; exists && val != nil {
+			if str, ok := val.(string); ok {
+				return str
+			}
+		}
+		return ""
+	}
+
+	// 時刻の取得
+	var createdAt time.Time
+	if t, ok := msg["created_at"].(time.Time); ok {
+		createdAt = t
+	} else if t, ok := msg["CreatedAt"].(time.Time); ok {
+		createdAt = t
+	} else {
+		createdAt = time.Now() // デフォルト値
+	}
+
+	// 既読状態の取得
+	isRead := false
+	if r, ok := msg["is_read"].(bool); ok {
+		isRead = r
+	} else if r, ok := msg["IsRead"].(bool); ok {
+		isRead = r
+	}
+
+	// ボットが送信したメッセージかどうか
+	isBot, _ := msg["is_bot"].(bool)
+	isIntegration, _ := msg["is_integration"].(bool)
+
+	// メッセージの種類の取得（未設定の場合は通常のメッセージ）
+	messageType := domain.MessageType(getString("type"))
+	if messageType == "" {
+		messageType = domain.MessageTypeText
+	}
+
+	return domain.Message{
+		ID:            getString("id"),
+		Content:       getString("content"),
+		SenderID:      getString("sender_id"),
+		SenderName:    getString("sender_name"),
+		MediaURL:      getString("media_url"),
+		MediaPath:     getString("media_path"),
+		CreatedAt:     createdAt,
+		IsRead:        isRead,
+		Type:          messageType,
+		Mentions:      convertMentions(msg["mentions"]),
+		LinkPreview:   convertLinkPreview(msg["link_preview"]),
+		ForwardedFrom: convertForwardedFrom(msg["forwarded_from"]),
+		Poll:          firebase.ToPoll(msg["poll"]),
+		IsBot:         isBot,
+		IsIntegration: isIntegration,
+		Attachments:   firebase.ToAttachments(msg["attachments"]),
+		Encrypted:     firebase.ToEncryptedEnvelope(msg["e2ee"]),
+	}
+}
+
+// Firestoreのピン留めデータをドメインの構造体に変換
+func convertPinnedMessages(data interface{}) []domain.PinnedMessage {
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var pinnedMessages []domain.PinnedMessage
+	for _, item := range items {
+		pm, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		pinned := domain.PinnedMessage{}
+		pinned.MessageID, _ = pm["message_id"].(string)
+		pinned.Content, _ = pm["content"].(string)
+		pinned.SenderName, _ = pm["sender_name"].(string)
+		pinned.PinnedBy, _ = pm["pinned_by"].(string)
+		pinned.PinnedByName, _ = pm["pinned_by_name"].(string)
+		pinned.PinnedAt, _ = pm["pinned_at"].(time.Time)
+		pinnedMessages = append(pinnedMessages, pinned)
+	}
+	return pinnedMessages
+}
+
+// Firestoreのチャット設定データからログインユーザーの設定を取り出す
+func convertChatSettings(data interface{}, userID string) domain.ChatSettings {
+	settings := domain.ChatSettings{}
+	allSettings, ok := data.(map[string]interface{})
+	if !ok {
+		return settings
+	}
+	userSettings, ok := allSettings[userID].(map[string]interface{})
+	if !ok {
+		return settings
+	}
+
+	settings.Muted, _ = userSettings["muted"].(bool)
+	settings.Archived, _ = userSettings["archived"].(bool)
+	settings.ArchivedAt, _ = userSettings["archived_at"].(time.Time)
+	settings.Hidden, _ = userSettings["hidden"].(bool)
+	settings.HiddenAt, _ = userSettings["hidden_at"].(time.Time)
+	return settings
+}
+
+// チャット一覧を表示用とアーカイブ用に振り分ける（非表示のチャットは除外する）
+func splitChatList(chats []domain.Chat) ([]domain.Chat, []domain.Chat) {
+	var activeChats, archivedChats []domain.Chat
+	for _, chat := range chats {
+		switch {
+		case chat.Settings.IsHidden(chat.UpdatedAt):
+			continue
+		case chat.Settings.IsArchived(chat.UpdatedAt):
+			archivedChats = append(archivedChats, chat)
+		default:
+			activeChats = append(activeChats, chat)
+		}
+	}
+	return activeChats, archivedChats
+}
+
+// ユーザーがチャットの参加者かどうかを確認
+func isChatParticipant(chatID string, userID string) (bool, error) {
+	participants, err := firebase.GetChatParticipants(chatID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range participants {
+		if p == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// システムメッセージをチャットに追加
+func addSystemMessage(chatID string, content string) error {
+	message := map[string]interface{}{
+		"id":          generateMessageID(),
+		"sender_id":   "",
+		"sender_name": "",
+		"content":     content,
+		"created_at":  time.Now(),
+		"is_read":     true,
+		"type":        string(domain.MessageTypeSystem),
+	}
+	return firebase.AddChatMessage(chatID, message)
+}
+
 // ChatControllerImpl チャットコントローラーの実装
 type ChatControllerImpl struct {
 	chatUsecase domain.ChatUsecase
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// チャット設定（ミュート・アーカイブ・非表示）の更新ハンドラ
+func ChatSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	action := domain.ChatSettingsAction(r.FormValue("action"))
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみ設定を変更できる
+	ok, err := isChatParticipant(chatID, session.User.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	// 操作に応じて更新する値を決定
+	var settings map[string]interface{}
+	switch action {
+	case domain.ChatSettingsMute:
+		settings = map[string]interface{}{"muted": true}
+	case domain.ChatSettingsUnmute:
+		settings = map[string]interface{}{"muted": false}
+	case domain.ChatSettingsArchive:
+		settings = map[string]interface{}{"archived": true, "archived_at": time.Now()}
+	case domain.ChatSettingsUnarchive:
+		settings = map[string]interface{}{"archived": false}
+	case domain.ChatSettingsHide:
+		settings = map[string]interface{}{"hidden": true, "hidden_at": time.Now()}
+	default:
+		http.Error(w, "不正な操作です", http.StatusBadRequest)
+		return
+	}
+
+	if err := firebase.UpdateChatSettings(chatID, session.User.ID, settings); err != nil {
+		log.Printf("チャット設定の更新に失敗: chatID=%s, action=%s, error=%v", chatID, action, err)
+		http.Error(w, "チャット設定の更新に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chat_id": chatID,
+		"action":  action,
+	})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/markup"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// 連絡先ページのデータ構造体
+type ContactsPageData struct {
+	IsLoggedIn   bool                    // ログイン状態
+	User         *domain.User            // ユーザー情報
+	Contacts     []domain.Contact        // 連絡先
+	Received     []domain.ContactRequest // 受け取った承認待ちの申請
+	Sent         []domain.ContactRequest // 送信した承認待ちの申請
+	ContactsOnly bool                    // 連絡先からのチャットのみ受け付けるかどうか
+	Error        string                  // エラー
+	Success      string                  // 完了したことを伝えるメッセージ
+}
+
+// 連絡先の操作
+type contactAction string
+
+const (
+	contactActionRequest contactAction = "request" // 申請
+	contactActionAccept  contactAction = "accept"  // 承認
+	contactActionDecline contactAction = "decline" // 拒否
+	contactActionCancel  contactAction = "cancel"  // 申請の取り消し
+	contactActionRemove  contactAction = "remove"  // 連絡先から削除
+)
+
+// 連絡先ページのハンドラ
+// 連絡先の一覧はチャットのユースケースから取得する
+func ContactsHandler(chatUsecase domain.ChatUsecase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// セッションの検証
+		session, err := middleware.ValidateSession(w, r)
+		if err != nil {
+			http.Redirect(w, r, "/login", http.StatusSeeOther)
+			return
+		}
+
+		contacts, err := chatUsecase.GetContacts(session.User)
+		if err != nil {
+			log.Printf("連絡先の取得に失敗: userID=%s, error=%v", session.User.ID, err)
+			http.Error(w, "連絡先の取得に失敗しました", http.StatusInternalServerError)
+			return
+		}
+		received, err := firebase.GetContactRequestsTo(session.User.ID)
+		if err != nil {
+			log.Printf("受け取った連絡先の申請の取得に失敗: userID=%s, error=%v", session.User.ID, err)
+		}
+		sent, err := firebase.GetContactRequestsFrom(session.User.ID)
+		if err != nil {
+			log.Printf("送信した連絡先の申請の取得に失敗: userID=%s, error=%v", session.User.ID, err)
+		}
+
+		// ブロックしているユーザーからの申請は表示しない
+		blockedUsers, err := getBlockedUserSet(session.User.ID)
+		if err != nil {
+			log.Printf("ブロックしているユーザーの取得に失敗: userID=%s, error=%v", session.User.ID, err)
+		}
+		data := ContactsPageData{
+			IsLoggedIn: true,
+			User:       session.User,
+			Contacts:   contacts,
+			Sent:       sent,
+			Error:      r.URL.Query().Get("error"),
+			Success:    r.URL.Query().Get("success"),
+		}
+		for _, req := range received {
+			if !blockedUsers[req.FromID] {
+				data.Received = append(data.Received, req)
+			}
+		}
+		if userData, err := firebase.GetData("users", session.User.ID); err == nil {
+			data.ContactsOnly, _ = userData["ContactsOnly"].(bool)
+		}
+
+		markup.GenerateHTML(w, data, "layout", "header", "contacts", "footer")
+	}
+}
+
+// 連絡先の申請ハンドラ
+func ContactRequestHandler(w http.ResponseWriter, r *http.Request) {
+	updateContact(w, r, contactActionRequest)
+}
+
+// 連絡先の申請の承認ハンドラ
+func AcceptContactHandler(w http.ResponseWriter, r *http.Request) {
+	updateContact(w, r, contactActionAccept)
+}
+
+// 連絡先の申請の拒否ハンドラ
+func DeclineContactHandler(w http.ResponseWriter, r *http.Request) {
+	updateContact(w, r, contactActionDecline)
+}
+
+// 連絡先の申請の取り消しハンドラ
+func CancelContactHandler(w http.ResponseWriter, r *http.Request) {
+	updateContact(w, r, contactActionCancel)
+}
+
+// 連絡先の削除ハンドラ
+func RemoveContactHandler(w http.ResponseWriter, r *http.Request) {
+	updateContact(w, r, contactActionRemove)
+}
+
+// 連絡先を更新して連絡先ページに戻る
+func updateContact(w http.ResponseWriter, r *http.Request, action contactAction) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	userID := r.FormValue("userID")
+	if userID == "" {
+		http.Error(w, "ユーザーIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	var message string
+	switch action {
+	case contactActionRequest:
+		var accepted bool
+		accepted, err = chat.SendContactRequest(session.User, userID)
+		message = "連絡先の申請を送信しました"
+		if accepted {
+			message = "相手からの申請を承認し、連絡先に追加しました"
+		}
+	case contactActionAccept:
+		err = chat.AcceptContactRequest(session.User, userID)
+		message = "連絡先に追加しました"
+	case contactActionDecline:
+		err = chat.DeclineContactRequest(session.User, userID)
+		message = "連絡先の申請を拒否しました"
+	case contactActionCancel:
+		err = chat.CancelContactRequest(session.User, userID)
+		message = "連絡先の申請を取り消しました"
+	case contactActionRemove:
+		err = chat.RemoveContact(session.User, userID)
+		message = "連絡先から削除しました"
+	}
+
+	query := url.Values{}
+	if err != nil {
+		if !isContactError(err) {
+			log.Printf("連絡先の更新に失敗: userID=%s, targetUserID=%s, action=%s, error=%v", session.User.ID, userID, action, err)
+			err = errors.New("連絡先の更新に失敗しました")
+		}
+		query.Set("error", err.Error())
+	} else {
+		query.Set("success", message)
+	}
+	http.Redirect(w, r, "/contacts?"+query.Encode(), http.StatusSeeOther)
+}
+
+// 連絡先のユーザーからのチャットのみ受け付ける設定のハンドラ（連絡先ページ）
+func ContactsOnlySettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	enabled := r.FormValue("contacts_only") == "on"
+	if err := firebase.UpdateField("users", session.User.ID, "ContactsOnly", enabled); err != nil {
+		log.Printf("連絡先の設定の更新に失敗: userID=%s, error=%v", session.User.ID, err)
+		http.Error(w, "連絡先の設定の更新に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	message := "全てのユーザーからのチャットを受け付けます"
+	if enabled {
+		message = "連絡先のユーザーからのチャットのみ受け付けます"
+	}
+	http.Redirect(w, r, "/contacts?"+url.Values{"success": {message}}.Encode(), http.StatusSeeOther)
+}
+
+// ユーザーに表示する連絡先のエラーかどうか
+func isContactError(err error) bool {
+	for _, target := range []error{
+		domain.ErrCannotAddSelfContact,
+		domain.ErrAlreadyContact,
+		domain.ErrContactRequestExists,
+		domain.ErrContactRequestNotFound,
+		domain.ErrContactRequestLimitExceeded,
+		domain.ErrContactRequestUnavailable,
+		domain.ErrNotContact,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/markup"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// コンテンツフィルターの管理ページのデータ構造体
+type ContentFilterPageData struct {
+	IsLoggedIn bool                // ログイン状態
+	User       *domain.User        // 管理者のユーザー情報
+	Rules      []domain.FilterRule // フィルターのルールの一覧
+	Error      string              // 操作に失敗した場合のエラー
+}
+
+// コンテンツフィルターの管理ページとルールの追加のハンドラ
+func ContentFilterHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := firebase.GetFilterRules()
+		if err != nil {
+			log.Printf("フィルターのルールの取得に失敗: error=%v", err)
+			http.Error(w, "フィルターのルールの取得に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		data := ContentFilterPageData{
+			IsLoggedIn: true,
+			User:       admin,
+			Rules:      rules,
+			Error:      r.URL.Query().Get("error"),
+		}
+		markup.GenerateHTML(w, data, "layout", "header", "admin_nav", "admin_filters", "footer")
+	case http.MethodPost:
+		pattern := strings.TrimSpace(r.FormValue("pattern"))
+		matchType := domain.FilterMatchType(r.FormValue("match_type"))
+		action := domain.FilterAction(r.FormValue("action"))
+		if err := domain.ValidateFilterRule(pattern, matchType, action); err != nil {
+			redirectContentFilter(w, r, err)
+			return
+		}
+
+		now := time.Now()
+		rule := domain.FilterRule{
+			Pattern:   pattern,
+			MatchType: matchType,
+			Action:    action,
+			Note:      strings.TrimSpace(r.FormValue("note")),
+			Enabled:   true,
+			CreatedBy: admin.ID,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		ruleID, err := firebase.AddFilterRule(rule)
+		if err != nil {
+			log.Printf("フィルターのルールの追加に失敗: error=%v", err)
+			redirectContentFilter(w, r, errors.New("ルールの追加に失敗しました"))
+			return
+		}
+
+		rule.ID = ruleID
+		recordFilterRuleChange(admin, domain.AuditFilterRuleCreated, rule)
+		redirectContentFilter(w, r, nil)
+	default:
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+	}
+}
+
+// フィルターのルールの有効・無効の切り替えハンドラ
+func ToggleContentFilterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := firebase.GetFilterRule(r.FormValue("rule_id"))
+	if err != nil {
+		redirectContentFilter(w, r, err)
+		return
+	}
+
+	rule.Enabled = !rule.Enabled
+	if err := firebase.SetFilterRuleEnabled(rule.ID, rule.Enabled); err != nil {
+		log.Printf("フィルターのルールの更新に失敗: ruleID=%s, error=%v", rule.ID, err)
+		redirectContentFilter(w, r, errors.New("ルールの更新に失敗しました"))
+		return
+	}
+
+	recordFilterRuleChange(admin, domain.AuditFilterRuleUpdated, *rule)
+	redirectContentFilter(w, r, nil)
+}
+
+// フィルターのルールの削除ハンドラ
+func DeleteContentFilterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := firebase.GetFilterRule(r.FormValue("rule_id"))
+	if err != nil {
+		redirectContentFilter(w, r, err)
+		return
+	}
+
+	if err := firebase.DeleteFilterRule(rule.ID); err != nil {
+		log.Printf("フィルターのルールの削除に失敗: ruleID=%s, error=%v", rule.ID, err)
+		redirectContentFilter(w, r, errors.New("ルールの削除に失敗しました"))
+		return
+	}
+
+	recordFilterRuleChange(admin, domain.AuditFilterRuleDeleted, *rule)
+	redirectContentFilter(w, r, nil)
+}
+
+// ルールの変更を監査ログに記録し、このサーバーのフィルターにすぐ反映する
+// 他のサーバーには定期的な読み込みで反映される
+func recordFilterRuleChange(admin *domain.User, action domain.AuditAction, rule domain.FilterRule) {
+	detail := rule.MatchType.Label() + "「" + rule.Pattern + "」: " + rule.Action.Label()
+	if action == domain.AuditFilterRuleUpdated {
+		if rule.Enabled {
+			detail += "（有効）"
+		} else {
+			detail += "（無効）"
+		}
+	}
+
+	err := firebase.AddAuditLog(domain.AuditLog{
+		Action:    action,
+		ActorID:   admin.ID,
+		ActorName: admin.Name,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("監査ログの保存に失敗: ruleID=%s, error=%v", rule.ID, err)
+	}
+
+	if err := chat.DefaultContentFilter.Reload(); err != nil {
+		log.Printf("フィルターのルールの読み込みに失敗: %v", err)
+	}
+}
+
+// コンテンツフィルターの管理ページに戻る
+func redirectContentFilter(w http.ResponseWriter, r *http.Request, err error) {
+	target := "/admin/filters"
+	if err != nil {
+		target += "?error=" + url.QueryEscape(err.Error())
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
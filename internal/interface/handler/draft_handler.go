@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// 下書きの保存ハンドラ（入力中に一定間隔で自動保存される）
+// 内容が空の場合は下書きを削除する
+func SaveDraftHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	content := r.FormValue("content")
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+	if err := domain.ValidateDraft(content); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみ下書きを保存できる
+	ok, err := isChatParticipant(chatID, session.User.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	// 暗号化されたチャットでは下書きの本文をサーバーに保存しない（削除のみ受け付ける）
+	if strings.TrimSpace(content) != "" {
+		if e2ee, err := firebase.IsChatE2EE(chatID); err != nil || e2ee {
+			http.Error(w, domain.ErrPlaintextInE2EEChat.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var updatedAt time.Time
+	if strings.TrimSpace(content) == "" {
+		err = firebase.DeleteDraft(chatID, session.User.ID)
+	} else {
+		updatedAt, err = firebase.SaveDraft(chatID, session.User.ID, content)
+	}
+	if err != nil {
+		log.Printf("下書きの保存に失敗: chatID=%s, userID=%s, error=%v", chatID, session.User.ID, err)
+		http.Error(w, "下書きの保存に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chat_id":    chatID,
+		"saved":      !updatedAt.IsZero(),
+		"updated_at": updatedAt,
+	})
+}
+
+// メッセージの送信後に下書きを削除する
+func clearDraft(chatID string, userID string) {
+	if err := firebase.DeleteDraft(chatID, userID); err != nil {
+		log.Printf("下書きの削除に失敗: chatID=%s, userID=%s, error=%v", chatID, userID, err)
+	}
+}
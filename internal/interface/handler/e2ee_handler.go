@@ -0,0 +1,223 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// 公開鍵のディレクトリのハンドラ
+// GET: user_idを指定するとそのユーザーの現在の鍵を、key_idを指定するとその鍵（古い鍵を含む）を返す
+// POST: JSONで {"public_key": "..."} を受け取り、ログインユーザーの現在の鍵として登録する
+func IdentityKeyHandler(w http.ResponseWriter, r *http.Request) {
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		var key *domain.IdentityKey
+		if keyID := r.URL.Query().Get("key_id"); keyID != "" {
+			key, err = firebase.GetIdentityKey(keyID)
+		} else if userID := r.URL.Query().Get("user_id"); userID != "" {
+			key, err = firebase.GetCurrentIdentityKey(userID)
+		} else {
+			http.Error(w, "ユーザーIDまたは鍵のIDが必要です", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			if !errors.Is(err, domain.ErrIdentityKeyNotFound) {
+				log.Printf("公開鍵の取得に失敗: error=%v", err)
+			}
+			http.Error(w, domain.ErrIdentityKeyNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(identityKeyResponse(*key))
+
+	case http.MethodPost:
+		// セッションからユーザー情報を取得
+		user, err := repository.GetUserByID(session.User.ID)
+		if err != nil {
+			log.Printf("ユーザー情報の取得に失敗: %v", err)
+			http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		var req struct {
+			PublicKey string `json:"public_key"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10)).Decode(&req); err != nil {
+			http.Error(w, "リクエストの形式が正しくありません", http.StatusBadRequest)
+			return
+		}
+
+		key, previous, err := chat.PublishIdentityKey(user, req.PublicKey)
+		if err != nil {
+			if errors.Is(err, domain.ErrIdentityKeyInvalid) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			log.Printf("公開鍵の登録に失敗: userID=%s, error=%v", user.ID, err)
+			http.Error(w, "公開鍵の登録に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		// 鍵が変更された場合は、暗号化されたチャットに変更を記録して相手が気付けるようにする
+		if previous != nil {
+			notifyIdentityKeyChanged(user)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(identityKeyResponse(*key))
+
+	default:
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+	}
+}
+
+// チャットのエンドツーエンド暗号化を有効にするハンドラ
+func EnableE2EEHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	if err := chat.EnableE2EE(session.User, chatID); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrNotChatParticipant):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, domain.ErrE2EEAlreadyEnabled):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, domain.ErrE2EENotSupported), errors.Is(err, domain.ErrE2EEUnavailable), errors.Is(err, domain.ErrIdentityKeyNotFound):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("チャットの暗号化に失敗: chatID=%s, error=%v", chatID, err)
+			http.Error(w, "チャットの暗号化に失敗しました", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := addSystemMessage(chatID, fmt.Sprintf("%sさんがエンドツーエンド暗号化を有効にしました。以降のメッセージは送信者と受信者の端末でのみ読むことができます", session.User.Name)); err != nil {
+		log.Printf("システムメッセージの追加に失敗: chatID=%s, error=%v", chatID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chat_id": chatID,
+		"e2ee":    true,
+	})
+}
+
+// 鍵の変更をユーザーが参加している暗号化されたチャットにシステムメッセージとして記録する
+func notifyIdentityKeyChanged(user *domain.User) {
+	chatIDs, err := chat.E2EEChatIDs(user.ID)
+	if err != nil {
+		log.Printf("暗号化されたチャットの取得に失敗: userID=%s, error=%v", user.ID, err)
+		return
+	}
+	content := fmt.Sprintf("%sさんの暗号化の鍵が変更されました。%sさんのプロフィールで安全番号を確認してください", user.Name, user.Name)
+	for _, chatID := range chatIDs {
+		if err := addSystemMessage(chatID, content); err != nil {
+			log.Printf("システムメッセージの追加に失敗: chatID=%s, error=%v", chatID, err)
+		}
+	}
+}
+
+// 相手の鍵を確認済みにする（または確認を取り消す）ハンドラ
+// フォームでuserID、safety_number、action（verify / unverify）を受け取り、相手のプロフィールに戻る
+func KeyVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	targetID := r.FormValue("userID")
+	if targetID == "" || targetID == session.User.ID {
+		http.Error(w, "ユーザーIDが正しくありません", http.StatusBadRequest)
+		return
+	}
+	profileURL := "/profile/" + url.PathEscape(targetID)
+
+	if r.FormValue("action") == "unverify" {
+		if err := chat.UnverifyIdentityKey(session.User.ID, targetID); err != nil {
+			log.Printf("鍵の確認の取り消しに失敗: userID=%s, targetID=%s, error=%v", session.User.ID, targetID, err)
+			http.Redirect(w, r, profileURL+"?error="+url.QueryEscape("確認の取り消しに失敗しました"), http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, profileURL+"?success="+url.QueryEscape("安全番号の確認を取り消しました"), http.StatusSeeOther)
+		return
+	}
+
+	if err := chat.VerifyIdentityKey(session.User.ID, targetID, r.FormValue("safety_number")); err != nil {
+		message := "安全番号の確認に失敗しました"
+		switch {
+		case errors.Is(err, domain.ErrSafetyNumberMismatch), errors.Is(err, domain.ErrIdentityKeyNotFound):
+			message = err.Error()
+		default:
+			log.Printf("安全番号の確認に失敗: userID=%s, targetID=%s, error=%v", session.User.ID, targetID, err)
+		}
+		http.Redirect(w, r, profileURL+"?error="+url.QueryEscape(message), http.StatusSeeOther)
+		return
+	}
+	http.Redirect(w, r, profileURL+"?success="+url.QueryEscape("安全番号を確認済みにしました"), http.StatusSeeOther)
+}
+
+// フォームから暗号化されたメッセージの暗号文を取得する（暗号化されたメッセージでない場合はnil）
+func encryptedEnvelopeFromForm(r *http.Request) *domain.EncryptedEnvelope {
+	if r.FormValue("type") != string(domain.MessageTypeEncrypted) {
+		return nil
+	}
+	version, _ := strconv.Atoi(r.FormValue("version"))
+	return &domain.EncryptedEnvelope{
+		Version:        version,
+		SenderKeyID:    r.FormValue("sender_key_id"),
+		RecipientKeyID: r.FormValue("recipient_key_id"),
+		Nonce:          r.FormValue("nonce"),
+		Ciphertext:     r.FormValue("ciphertext"),
+	}
+}
+
+// 公開鍵のレスポンス
+func identityKeyResponse(key domain.IdentityKey) map[string]interface{} {
+	return map[string]interface{}{
+		"key_id":     key.KeyID,
+		"user_id":    key.UserID,
+		"public_key": key.PublicKey,
+		"created_at": key.CreatedAt,
+		"current":    key.Current,
+	}
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// 未読メッセージのメール通知の設定ハンドラ（設定ページ）
+func EmailDigestSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	enabled := r.FormValue("email_digest") == "on"
+	if err := firebase.UpdateField("users", session.User.ID, "EmailDigest", enabled); err != nil {
+		log.Printf("メール通知の設定の更新に失敗: userID=%s, error=%v", session.User.ID, err)
+		http.Error(w, "メール通知の設定の更新に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	message := "メール通知を停止しました"
+	if enabled {
+		message = "メール通知を有効にしました"
+	}
+	http.Redirect(w, r, "/settings?success="+message, http.StatusSeeOther)
+}
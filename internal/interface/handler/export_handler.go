@@ -0,0 +1,321 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/markup"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// エクスポート時に一度に読み込むメッセージの件数
+const exportPageSize = 200
+
+// エクスポートで表示する日時の形式
+const exportTimeLayout = "2006/01/02 15:04:05"
+
+// チャットのエクスポートハンドラ
+func ChatExportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.URL.Query().Get("chat_id")
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+	format, err := domain.ParseExportFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみエクスポートできる
+	ok, err := isChatParticipant(chatID, session.User.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	chatData, err := firebase.GetData("chats", chatID)
+	if err != nil {
+		log.Printf("チャットの取得に失敗: chatID=%s, error=%v", chatID, err)
+		http.Error(w, "チャットの取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+	retention, _ := domain.ParseRetentionPolicy(getRetentionValue(chatData))
+
+	participants, err := getExportParticipants(chatID)
+	if err != nil {
+		log.Printf("チャットの参加者の取得に失敗: chatID=%s, error=%v", chatID, err)
+		http.Error(w, "チャットの参加者の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	exporter := newChatExporter(format, w, chatID)
+	filename := fmt.Sprintf("chat_%s_%s.%s", chatID, time.Now().Format("20060102"), format)
+	w.Header().Set("Content-Type", exporter.contentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	// ここから先はレスポンスを書き始めるため、エラーはログに記録して中断する
+	if err := exporter.begin(participants); err != nil {
+		log.Printf("エクスポートの書き込みに失敗: chatID=%s, error=%v", chatID, err)
+		return
+	}
+
+	now := time.Now()
+	flusher, _ := w.(http.Flusher)
+	err = firebase.IterateChatMessages(chatID, exportPageSize, func(page []map[string]interface{}) error {
+		for _, data := range page {
+			message := convertMessage(data)
+			// 保持期間を過ぎたメッセージは削除前でも出力しない
+			if expiresAt := retention.ExpiresAt(message.CreatedAt); !expiresAt.IsZero() && now.After(expiresAt) {
+				continue
+			}
+			if err := exporter.write(message); err != nil {
+				return err
+			}
+		}
+		// ページごとにクライアントへ送信する
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("メッセージのエクスポートに失敗: chatID=%s, error=%v", chatID, err)
+		return
+	}
+
+	if err := exporter.end(); err != nil {
+		log.Printf("エクスポートの書き込みに失敗: chatID=%s, error=%v", chatID, err)
+	}
+}
+
+// エクスポートするチャットの参加者を取得
+func getExportParticipants(chatID string) ([]domain.ExportParticipant, error) {
+	participantIDs, err := firebase.GetChatParticipants(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []domain.ExportParticipant
+	for _, id := range participantIDs {
+		user, err := GetUserData(id)
+		if err != nil {
+			// 退会したユーザーなどはIDのみ出力する
+			log.Printf("参加者の情報の取得に失敗: userID=%s, error=%v", id, err)
+			participants = append(participants, domain.ExportParticipant{ID: id})
+			continue
+		}
+		participants = append(participants, domain.ExportParticipant{ID: user.ID, Name: user.Name, Email: user.Email})
+	}
+	return participants, nil
+}
+
+// チャットをファイルの形式ごとに書き出す処理
+type chatExporter interface {
+	contentType() string
+	begin(participants []domain.ExportParticipant) error
+	write(message domain.Message) error
+	end() error
+}
+
+// 形式に合わせたエクスポートの処理を生成
+func newChatExporter(format domain.ExportFormat, w io.Writer, chatID string) chatExporter {
+	switch format {
+	case domain.ExportFormatHTML:
+		return &htmlChatExporter{w: w, chatID: chatID}
+	case domain.ExportFormatText:
+		return &textChatExporter{w: w, chatID: chatID}
+	default:
+		return &jsonChatExporter{w: w, chatID: chatID}
+	}
+}
+
+// JSON形式のエクスポート（インポートで読み込める形式）
+type jsonChatExporter struct {
+	w      io.Writer
+	chatID string
+	count  int
+}
+
+func (e *jsonChatExporter) contentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// 先頭部分を出力し、messagesの配列を開く
+func (e *jsonChatExporter) begin(participants []domain.ExportParticipant) error {
+	if participants == nil {
+		participants = []domain.ExportParticipant{}
+	}
+	header, err := json.Marshal(domain.ChatExport{
+		Version:      domain.ExportVersion,
+		ChatID:       e.chatID,
+		ExportedAt:   time.Now(),
+		Participants: participants,
+	})
+	if err != nil {
+		return err
+	}
+	// 末尾の "messages":null} を配列の開始に置き換える
+	header = []byte(strings.TrimSuffix(string(header), `"messages":null}`))
+	_, err = fmt.Fprintf(e.w, "%s\"messages\":[\n", header)
+	return err
+}
+
+func (e *jsonChatExporter) write(message domain.Message) error {
+	data, err := json.Marshal(domain.NewExportMessage(message))
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ",\n"); err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonChatExporter) end() error {
+	_, err := io.WriteString(e.w, "\n]}\n")
+	return err
+}
+
+// プレーンテキスト形式のエクスポート
+type textChatExporter struct {
+	w      io.Writer
+	chatID string
+}
+
+func (e *textChatExporter) contentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e *textChatExporter) begin(participants []domain.ExportParticipant) error {
+	var names []string
+	for _, p := range participants {
+		names = append(names, p.Name)
+	}
+	_, err := fmt.Fprintf(e.w, "チャット: %s\n参加者: %s\nエクスポート日時: %s\n\n",
+		e.chatID, strings.Join(names, ", "), time.Now().Format(exportTimeLayout))
+	return err
+}
+
+func (e *textChatExporter) write(message domain.Message) error {
+	var b strings.Builder
+	createdAt := message.CreatedAt.Local().Format(exportTimeLayout)
+	if message.Type == domain.MessageTypeSystem {
+		fmt.Fprintf(&b, "[%s] * %s\n", createdAt, message.Content)
+	} else {
+		fmt.Fprintf(&b, "[%s] %s:", createdAt, message.SenderName)
+		if message.ForwardedFrom != nil {
+			fmt.Fprintf(&b, " (転送: %sさんのメッセージ)", message.ForwardedFrom.SenderName)
+		}
+		// 複数行のメッセージは字下げして続ける
+		fmt.Fprintf(&b, " %s\n", strings.ReplaceAll(message.Content, "\n", "\n    "))
+		if message.MediaURL != "" {
+			fmt.Fprintf(&b, "    添付ファイル: %s\n", message.MediaURL)
+		}
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func (e *textChatExporter) end() error {
+	return nil
+}
+
+// 単体で閲覧できるHTML形式のエクスポート（スタイルは埋め込み、外部のファイルを読み込まない）
+type htmlChatExporter struct {
+	w      io.Writer
+	chatID string
+}
+
+// HTML形式のエクスポートの先頭部分
+var exportHTMLHeader = template.Must(template.New("exportHeader").Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1.0">
+<title>チャットのエクスポート - {{ .ChatID }}</title>
+<style>
+body { max-width: 800px; margin: 0 auto; padding: 24px; font-family: sans-serif; color: #333; background: #f5f5f5; }
+header { margin-bottom: 24px; }
+h1 { font-size: 20px; }
+.meta { font-size: 13px; color: #666; }
+.message { margin-bottom: 12px; padding: 10px 14px; background: #fff; border-radius: 8px; }
+.message.system { font-size: 13px; color: #666; text-align: center; background: none; }
+.sender { font-weight: bold; }
+.time { margin-left: 8px; font-size: 12px; color: #999; }
+.forwarded { font-size: 12px; color: #666; }
+.content p { margin: 4px 0; }
+.content pre { padding: 8px; overflow-x: auto; color: #f8f8f2; background: #2d2d2d; border-radius: 6px; }
+.content code { font-family: monospace; }
+.attachment { font-size: 13px; }
+</style>
+</head>
+<body>
+<header>
+<h1>チャットのエクスポート</h1>
+<p class="meta">参加者: {{ range $i, $p := .Participants }}{{ if $i }}, {{ end }}{{ $p.Name }}{{ end }}</p>
+<p class="meta">エクスポート日時: {{ .ExportedAt }}</p>
+</header>
+<main>
+`))
+
+// HTML形式のエクスポートのメッセージ部分
+var exportHTMLMessage = template.Must(template.New("exportMessage").Parse(`{{ if .System }}<div class="message system">{{ .Message.Content }} <span class="time">{{ .CreatedAt }}</span></div>
+{{ else }}<div class="message">
+<div><span class="sender">{{ .Message.SenderName }}</span><span class="time">{{ .CreatedAt }}</span></div>
+{{ if .Message.ForwardedFrom }}<div class="forwarded">転送: {{ .Message.ForwardedFrom.SenderName }}さんのメッセージ</div>
+{{ end }}<div class="content">{{ .Content }}</div>
+{{ if .Message.MediaURL }}<div class="attachment"><a href="{{ .Message.MediaURL }}">添付ファイル</a></div>
+{{ end }}</div>
+{{ end }}`))
+
+func (e *htmlChatExporter) contentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (e *htmlChatExporter) begin(participants []domain.ExportParticipant) error {
+	return exportHTMLHeader.Execute(e.w, map[string]interface{}{
+		"ChatID":       e.chatID,
+		"Participants": participants,
+		"ExportedAt":   time.Now().Format(exportTimeLayout),
+	})
+}
+
+func (e *htmlChatExporter) write(message domain.Message) error {
+	return exportHTMLMessage.Execute(e.w, map[string]interface{}{
+		"Message":   message,
+		"System":    message.Type == domain.MessageTypeSystem,
+		"CreatedAt": message.CreatedAt.Local().Format(exportTimeLayout),
+		"Content":   markup.RenderMessage(message.Content, message.Mentions),
+	})
+}
+
+func (e *htmlChatExporter) end() error {
+	_, err := io.WriteString(e.w, "</main>\n</body>\n</html>\n")
+	return err
+}
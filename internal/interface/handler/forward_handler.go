@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// メッセージの転送ハンドラ
+func ForwardMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	// セッションからユーザー情報を取得
+	user, err := repository.GetUserByID(session.User.ID)
+	if err != nil {
+		log.Printf("ユーザー情報の取得に失敗: %v", err)
+		http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "フォームの解析に失敗しました", http.StatusBadRequest)
+		return
+	}
+	chatID := r.FormValue("chatID")
+	messageID := r.FormValue("messageID")
+	if chatID == "" || messageID == "" {
+		http.Error(w, "チャットIDとメッセージIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	targets, err := domain.ValidateForwardTargets(chatID, r.Form["targetChatID"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// 転送元のチャットの参加者のみ転送できる
+	ok, err := isChatParticipant(chatID, user.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	// 転送先のチャットも全て参加しているもので、相手にブロックされていないものに限る
+	for _, targetID := range targets {
+		ok, err := isChatParticipant(targetID, user.ID)
+		if err != nil || !ok {
+			http.Error(w, "転送先のチャットにはアクセスできません", http.StatusForbidden)
+			return
+		}
+		if ok, err := canSendToChat(targetID, user.ID); err != nil || !ok {
+			http.Error(w, domain.ErrMessageNotAllowed.Error(), http.StatusForbidden)
+			return
+		}
+		// 転送は本文をそのまま複製するため、暗号化されたチャットには転送できない
+		if e2ee, err := firebase.IsChatE2EE(targetID); err != nil || e2ee {
+			http.Error(w, domain.ErrPlaintextInE2EEChat.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	messageData, err := firebase.GetChatMessage(chatID, messageID)
+	if err != nil {
+		http.Error(w, "メッセージが見つかりません", http.StatusNotFound)
+		return
+	}
+	original := convertMessage(messageData)
+	// 暗号化されたメッセージは転送元のチャットの鍵でしか復号できず、投票は投票のデータが転送元のチャットに属するため転送できない
+	if original.Type == domain.MessageTypeSystem || original.Type == domain.MessageTypeEncrypted || original.Type == domain.MessageTypePoll {
+		http.Error(w, domain.ErrForwardNotAllowed.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var forwarded []map[string]string
+	var failed []string
+	var rejected *domain.MessageRejectedError
+	for _, targetID := range targets {
+		newMessageID, err := forwardMessage(original, chatID, messageData, targetID, user)
+		if err != nil {
+			// コンテンツフィルターなどで拒否された場合は理由を返す
+			if !errors.As(err, &rejected) {
+				log.Printf("メッセージの転送に失敗: chatID=%s, messageID=%s, targetChatID=%s, error=%v", chatID, messageID, targetID, err)
+			}
+			failed = append(failed, targetID)
+			continue
+		}
+		forwarded = append(forwarded, map[string]string{"chat_id": targetID, "message_id": newMessageID})
+	}
+
+	if len(forwarded) == 0 {
+		if rejected != nil {
+			status := http.StatusBadRequest
+			if errors.Is(rejected, domain.ErrNotChatParticipant) || errors.Is(rejected, domain.ErrMessageNotAllowed) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, rejected.Error(), status)
+			return
+		}
+		http.Error(w, "メッセージの転送に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"forwarded": forwarded,
+		"failed":    failed,
+	})
+}
+
+// メッセージを転送先のチャットに複製する
+// 通常の送信と同じパイプラインで送信するため、ブロック・コンテンツフィルター・通知・Webhookなどの処理も行われる
+func forwardMessage(original domain.Message, sourceChatID string, originalData map[string]interface{}, targetChatID string, user *domain.User) (string, error) {
+	// 転送されたメッセージを更に転送する場合は、最初の送信者の情報を引き継ぐ
+	from := domain.ForwardedFrom{
+		ChatID:     sourceChatID,
+		MessageID:  original.ID,
+		SenderID:   original.SenderID,
+		SenderName: original.SenderName,
+		SentAt:     original.CreatedAt,
+	}
+	if original.ForwardedFrom != nil {
+		from = *original.ForwardedFrom
+	}
+
+	// 転送した本文はコマンドとして解釈しない
+	msg := &chat.OutgoingMessage{
+		ID:         generateMessageID(),
+		ChatID:     targetChatID,
+		SenderID:   user.ID,
+		SenderName: user.Name,
+		Content:    original.Content,
+		Type:       original.Type,
+	}
+	msg.Set("forwarded_from", map[string]interface{}{
+		"chat_id":     from.ChatID,
+		"message_id":  from.MessageID,
+		"sender_id":   from.SenderID,
+		"sender_name": from.SenderName,
+		"sent_at":     from.SentAt,
+	})
+
+	// 添付メディアは転送先のチャット用に複製する（保持期間による削除が元のメッセージに影響しないようにする）
+	var mediaPath string
+	if original.MediaPath != "" {
+		mediaPath = fmt.Sprintf("chats/%s/%s%s", targetChatID, msg.ID, path.Ext(original.MediaPath))
+		mediaURL, err := firebase.CopyMedia(original.MediaPath, mediaPath)
+		if err != nil {
+			return "", err
+		}
+		msg.Set("media_path", mediaPath)
+		msg.Set("media_url", mediaURL)
+	} else if original.MediaURL != "" {
+		msg.Set("media_url", original.MediaURL)
+	}
+
+	// 取得済みのリンクのプレビューも引き継ぐ
+	if preview, ok := originalData["link_preview"]; ok {
+		msg.Set("link_preview", preview)
+	}
+
+	if err := chat.SendMessage(msg); err != nil {
+		// 複製したメディアが残らないようにする
+		if mediaPath != "" {
+			if err := firebase.DeleteMedia(mediaPath); err != nil {
+				log.Printf("複製したメディアの削除に失敗: path=%s, error=%v", mediaPath, err)
+			}
+		}
+		return "", err
+	}
+	return msg.ID, nil
+}
+
+// Firestoreの転送元データをドメインの構造体に変換
+func convertForwardedFrom(data interface{}) *domain.ForwardedFrom {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	from := &domain.ForwardedFrom{}
+	from.ChatID, _ = m["chat_id"].(string)
+	from.MessageID, _ = m["message_id"].(string)
+	from.SenderID, _ = m["sender_id"].(string)
+	from.SenderName, _ = m["sender_name"].(string)
+	from.SentAt, _ = m["sent_at"].(time.Time)
+	return from
+}
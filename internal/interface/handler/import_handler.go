@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// インポートするファイルの最大サイズ（バイト）
+const maxImportFileSize = 32 << 20
+
+// チャットのインポートハンドラ
+// 取り込むのはログインユーザー自身が送信したメッセージのみ
+// dry_runを指定した場合は保存せずに取り込む予定の内容を返す
+func ChatImportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize+1<<20)
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "ファイルが大きすぎるか、フォームの解析に失敗しました", http.StatusBadRequest)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+	source, err := domain.ParseImportSource(r.FormValue("source"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun := r.FormValue("dry_run") == "true" || r.FormValue("dry_run") == "1"
+
+	// チャットの参加者のみインポートできる
+	ok, err := isChatParticipant(chatID, session.User.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "インポートするファイルが必要です", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxImportFileSize+1))
+	if err != nil {
+		http.Error(w, "ファイルの読み込みに失敗しました", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxImportFileSize {
+		http.Error(w, "ファイルが大きすぎます", http.StatusBadRequest)
+		return
+	}
+
+	// メッセージの送信者と対応付けるため、メールアドレスを含むユーザー情報を取得する
+	user, err := repository.GetUserByID(session.User.ID)
+	if err != nil {
+		log.Printf("ユーザー情報の取得に失敗: %v", err)
+		http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	report, err := chat.ImportChat(user, chatID, source, data, r.FormValue("channel"), dryRun)
+	if err != nil {
+		var rejected *domain.MessageRejectedError
+		if errors.Is(err, domain.ErrNotChatParticipant) || errors.As(err, &rejected) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if isImportInputError(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("メッセージのインポートに失敗: chatID=%s, source=%s, error=%v", chatID, source, err)
+		http.Error(w, "メッセージのインポートに失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ファイルの内容に起因するエラーかどうか
+func isImportInputError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.Is(err, domain.ErrUnsupportedExport) ||
+		errors.Is(err, domain.ErrImportChannelRequired) ||
+		errors.Is(err, domain.ErrImportChannelNotFound) ||
+		errors.Is(err, domain.ErrImportMessagesNotFound) ||
+		errors.Is(err, domain.ErrPlaintextInE2EEChat) ||
+		errors.Is(err, zip.ErrFormat) ||
+		errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// 受信WebhookのURLのパス（続けてトークンを指定する）
+const incomingWebhookPath = "/api/hooks/"
+
+// 受信Webhookの作成ハンドラ
+// URLは作成時にしか表示できないため、リダイレクトせずに管理ページを表示する
+func CreateIncomingWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+	if ok, err := isChatParticipant(chatID, session.User.ID); err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	webhook, token, err := chat.CreateIncomingWebhook(session.User, chatID, r.FormValue("name"))
+	if err != nil {
+		if !errors.Is(err, domain.ErrIncomingWebhookNameInvalid) && !errors.Is(err, domain.ErrIncomingWebhookLimitExceeded) {
+			log.Printf("受信Webhookの作成に失敗: chatID=%s, error=%v", chatID, err)
+			err = errors.New("受信Webhookの作成に失敗しました")
+		}
+		redirectWebhooks(w, r, chatID, err)
+		return
+	}
+
+	notice := fmt.Sprintf("%sさんが受信Webhook「%s」を追加しました", session.User.Name, webhook.Name)
+	if err := addSystemMessage(chatID, notice); err != nil {
+		log.Printf("システムメッセージの追加に失敗: chatID=%s, error=%v", chatID, err)
+	}
+
+	renderWebhooks(w, WebhooksPageData{
+		IsLoggedIn:             true,
+		User:                   session.User,
+		ChatID:                 chatID,
+		CreatedIncomingWebhook: webhook,
+		CreatedIncomingURL:     incomingWebhookURL(r, token),
+	})
+}
+
+// 受信Webhookの無効化ハンドラ
+// 削除したWebhookのURLには以降投稿できない（投稿済みのメッセージは残す）
+func RevokeIncomingWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	webhook, err := firebase.GetIncomingWebhook(r.FormValue("webhookID"))
+	if err != nil {
+		http.Error(w, domain.ErrIncomingWebhookNotFound.Error(), http.StatusNotFound)
+		return
+	}
+	if ok, err := isChatParticipant(webhook.ChatID, session.User.ID); err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	if err := firebase.DeleteIncomingWebhook(webhook.ID); err != nil {
+		log.Printf("受信Webhookの削除に失敗: webhookID=%s, error=%v", webhook.ID, err)
+		redirectWebhooks(w, r, webhook.ChatID, errors.New("受信Webhookの無効化に失敗しました"))
+		return
+	}
+
+	notice := fmt.Sprintf("%sさんが受信Webhook「%s」を無効化しました", session.User.Name, webhook.Name)
+	if err := addSystemMessage(webhook.ChatID, notice); err != nil {
+		log.Printf("システムメッセージの追加に失敗: chatID=%s, error=%v", webhook.ChatID, err)
+	}
+	redirectWebhooks(w, r, webhook.ChatID, nil)
+}
+
+// 受信Webhookへの投稿API
+// POST /api/hooks/<トークン> にJSONで {"text": "...", "attachments": [...]} を受け取る
+func IncomingWebhookAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := strings.TrimPrefix(r.URL.Path, incomingWebhookPath)
+	webhook, err := chat.AuthenticateIncomingWebhook(token)
+	if err != nil {
+		if !errors.Is(err, domain.ErrInvalidIncomingWebhookToken) {
+			log.Printf("受信Webhookの認証に失敗: error=%v", err)
+		}
+		http.Error(w, domain.ErrInvalidIncomingWebhookToken.Error(), http.StatusNotFound)
+		return
+	}
+
+	var req struct {
+		Text        string `json:"text"`
+		Attachments []struct {
+			Title     string `json:"title"`
+			TitleLink string `json:"title_link"`
+			Text      string `json:"text"`
+			Color     string `json:"color"`
+		} `json:"attachments"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "リクエストの形式が正しくありません", http.StatusBadRequest)
+		return
+	}
+
+	post := chat.IncomingWebhookPost{Text: req.Text}
+	for _, a := range req.Attachments {
+		post.Attachments = append(post.Attachments, domain.MessageAttachment{
+			Title:     a.Title,
+			TitleLink: a.TitleLink,
+			Text:      a.Text,
+			Color:     a.Color,
+		})
+	}
+
+	msg, err := chat.PostIncomingWebhook(webhook, post)
+	if err != nil {
+		var rejected *domain.MessageRejectedError
+		switch {
+		case errors.Is(err, domain.ErrIncomingWebhookRateLimited):
+			w.Header().Set("Retry-After", strconv.Itoa(domain.IncomingWebhookRateWindowSec))
+			http.Error(w, err.Error(), http.StatusTooManyRequests)
+		case isAttachmentValidationError(err):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.As(err, &rejected):
+			status := http.StatusBadRequest
+			if errors.Is(err, domain.ErrMessageNotAllowed) {
+				status = http.StatusForbidden
+			}
+			http.Error(w, rejected.Error(), status)
+		default:
+			log.Printf("受信Webhookの投稿に失敗: webhookID=%s, chatID=%s, error=%v", webhook.ID, webhook.ChatID, err)
+			http.Error(w, "メッセージの送信に失敗しました", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":         msg.ID,
+		"chat_id":    msg.ChatID,
+		"created_at": msg.CreatedAt,
+	})
+}
+
+// 受信WebhookのURLを組み立てる（アクセスされたホストを使用する）
+func incomingWebhookURL(r *http.Request, token string) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + incomingWebhookPath + token
+}
+
+// 投稿元に返す添付のエラーかどうか
+func isAttachmentValidationError(err error) bool {
+	for _, target := range []error{
+		domain.ErrTooManyAttachments,
+		domain.ErrAttachmentEmpty,
+		domain.ErrAttachmentTitleTooLong,
+		domain.ErrAttachmentTextTooLong,
+		domain.ErrAttachmentLinkInvalid,
+		domain.ErrAttachmentColorInvalid,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
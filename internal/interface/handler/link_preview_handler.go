@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// メッセージのURLのプレビュー取得ハンドラ（送信後にバックグラウンドで取得したものを返す）
+func LinkPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.URL.Query().Get("chat_id")
+	messageID := r.URL.Query().Get("message_id")
+	if chatID == "" || messageID == "" {
+		http.Error(w, "チャットIDとメッセージIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみ取得できる
+	ok, err := isChatParticipant(chatID, session.User.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	message, err := firebase.GetChatMessage(chatID, messageID)
+	if err != nil {
+		http.Error(w, "メッセージが見つかりません", http.StatusNotFound)
+		return
+	}
+
+	// まだ取得できていない場合は内容なしで返す
+	preview := convertLinkPreview(message["link_preview"])
+	if preview.IsEmpty() {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"url":         preview.URL,
+		"title":       preview.Title,
+		"description": preview.Description,
+		"image_url":   preview.ImageURL,
+		"site_name":   preview.SiteName,
+	})
+}
+
+// Firestoreのプレビューデータをドメインの構造体に変換
+func convertLinkPreview(data interface{}) *domain.LinkPreview {
+	m, ok := data.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	preview := &domain.LinkPreview{}
+	preview.URL, _ = m["url"].(string)
+	preview.Title, _ = m["title"].(string)
+	preview.Description, _ = m["description"].(string)
+	preview.ImageURL, _ = m["image_url"].(string)
+	preview.SiteName, _ = m["site_name"].(string)
+	preview.FetchedAt, _ = m["fetched_at"].(time.Time)
+	return preview
+}
@@ -73,6 +73,17 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// 利用が停止されたアカウントはログインできない
+		if user.IsSuspended {
+			data := domain.TemplateData{
+				IsLoggedIn:       false,
+				LoginForm:        domain.LoginForm{Email: form.Email, Password: form.Password},
+				ValidationErrors: []string{domain.ErrAccountSuspended.Error()},
+			}
+			markup.GenerateHTML(w, data, "layout", "header", "login", "footer")
+			return
+		}
+
 		// セッションの作成
 		session, err := middleware.CreateSession(user)
 		if err != nil {
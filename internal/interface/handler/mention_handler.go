@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"sort"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/markup"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// 自分宛てのメンション一覧ページのハンドラ
+func MentionsHandler(w http.ResponseWriter, r *http.Request) {
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	// セッションからユーザー情報を取得
+	user, err := repository.GetUserByID(session.User.ID)
+	if err != nil {
+		log.Printf("ユーザー情報の取得に失敗: %v", err)
+		http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	notifications, err := firebase.GetNotifications(user.ID, domain.NotificationTypeMention)
+	if err != nil {
+		log.Printf("メンションの取得に失敗: userID=%s, error=%v", user.ID, err)
+		http.Error(w, "メンションの取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	// 新しい順に並べる
+	sort.Slice(notifications, func(i, j int) bool {
+		return notifications[i].CreatedAt.After(notifications[j].CreatedAt)
+	})
+
+	data := domain.TemplateData{
+		IsLoggedIn:    true,
+		User:          user,
+		Notifications: notifications,
+	}
+	markup.GenerateHTML(w, data, "layout", "header", "mentions", "footer")
+
+	// 一覧を表示したメンションは既読にする
+	if err := firebase.MarkNotificationsAsRead(user.ID); err != nil {
+		log.Printf("メンションの既読化に失敗: userID=%s, error=%v", user.ID, err)
+	}
+}
+
+// メンションをFirestoreに保存する形式に変換
+func mentionsToData(mentions []domain.Mention) []map[string]interface{} {
+	data := make([]map[string]interface{}, 0, len(mentions))
+	for _, m := range mentions {
+		data = append(data, map[string]interface{}{
+			"user_id":  m.UserID,
+			"username": m.Username,
+			"start":    m.Start,
+			"end":      m.End,
+		})
+	}
+	return data
+}
+
+// Firestoreのメンションデータをドメインの構造体に変換
+func convertMentions(data interface{}) []domain.Mention {
+	items, ok := data.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var mentions []domain.Mention
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		mention := domain.Mention{}
+		mention.UserID, _ = m["user_id"].(string)
+		mention.Username, _ = m["username"].(string)
+		// Firestoreの整数はint64として返される
+		start, _ := m["start"].(int64)
+		end, _ := m["end"].(int64)
+		mention.Start = int(start)
+		mention.End = int(end)
+		mentions = append(mentions, mention)
+	}
+	return mentions
+}
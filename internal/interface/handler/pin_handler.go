@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// メッセージのピン留めハンドラ
+func PinMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	// セッションからユーザー情報を取得
+	user, err := repository.GetUserByID(session.User.ID)
+	if err != nil {
+		log.Printf("ユーザー情報の取得に失敗: %v", err)
+		http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	messageID := r.FormValue("messageID")
+	if chatID == "" || messageID == "" {
+		http.Error(w, "チャットIDとメッセージIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみピン留めできる
+	ok, err := isChatParticipant(chatID, user.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	// ピン留め対象のメッセージを取得
+	messageData, err := firebase.GetChatMessage(chatID, messageID)
+	if err != nil {
+		log.Printf("メッセージの取得に失敗: chatID=%s, messageID=%s, error=%v", chatID, messageID, err)
+		http.Error(w, "メッセージが見つかりません", http.StatusNotFound)
+		return
+	}
+	message := convertMessage(messageData)
+	if message.Type == domain.MessageTypeSystem {
+		http.Error(w, "システムメッセージはピン留めできません", http.StatusBadRequest)
+		return
+	}
+	// ピン留めは本文をチャットに保存するため、暗号化されたメッセージはピン留めできない
+	if message.Type == domain.MessageTypeEncrypted {
+		http.Error(w, "暗号化されたメッセージはピン留めできません", http.StatusBadRequest)
+		return
+	}
+
+	// ピン留めを保存
+	pin := map[string]interface{}{
+		"message_id":     message.ID,
+		"content":        message.Content,
+		"sender_name":    message.SenderName,
+		"pinned_by":      user.ID,
+		"pinned_by_name": user.Name,
+		"pinned_at":      time.Now(),
+	}
+	err = firebase.PinChatMessage(chatID, pin, domain.MaxPinnedMessages)
+	if err != nil {
+		if errors.Is(err, domain.ErrPinLimitExceeded) || errors.Is(err, domain.ErrAlreadyPinned) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("ピン留めに失敗: chatID=%s, messageID=%s, error=%v", chatID, messageID, err)
+		http.Error(w, "ピン留めに失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	// ピン留めのイベントをシステムメッセージとして記録
+	if err := addSystemMessage(chatID, fmt.Sprintf("%sさんがメッセージをピン留めしました", user.Name)); err != nil {
+		log.Printf("システムメッセージの追加に失敗: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message_id": message.ID,
+		"is_pinned":  true,
+	})
+}
+
+// メッセージのピン留め解除ハンドラ
+func UnpinMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	messageID := r.FormValue("messageID")
+	if chatID == "" || messageID == "" {
+		http.Error(w, "チャットIDとメッセージIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみピン留めを解除できる
+	ok, err := isChatParticipant(chatID, session.User.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	err = firebase.UnpinChatMessage(chatID, messageID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotPinned) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		log.Printf("ピン留めの解除に失敗: chatID=%s, messageID=%s, error=%v", chatID, messageID, err)
+		http.Error(w, "ピン留めの解除に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message_id": messageID,
+		"is_pinned":  false,
+	})
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// 投票への投票ハンドラ
+// 同じ選択肢にもう一度投票すると取り消し、別の選択肢に投票すると投票先を変更する
+func PollVoteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	messageID := r.FormValue("messageID")
+	option, err := strconv.Atoi(r.FormValue("option"))
+	if chatID == "" || messageID == "" || err != nil {
+		http.Error(w, "チャットID・メッセージID・選択肢が必要です", http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者であることを確認
+	if ok, err := isChatParticipant(chatID, session.User.ID); err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	poll, err := firebase.VotePoll(chatID, messageID, session.User.ID, option)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrPollNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, domain.ErrNotPoll), errors.Is(err, domain.ErrInvalidPollOption):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("投票に失敗: chatID=%s, messageID=%s, error=%v", chatID, messageID, err)
+			http.Error(w, "投票に失敗しました", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	options := make([]map[string]interface{}, 0, len(poll.Options))
+	for i, o := range poll.Options {
+		options = append(options, map[string]interface{}{
+			"text":    o.Text,
+			"votes":   len(o.Votes),
+			"percent": poll.Percent(i),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message_id": messageID,
+		"voted":      poll.VotedOption(session.User.ID),
+		"total":      poll.TotalVotes(),
+		"options":    options,
+	})
+}
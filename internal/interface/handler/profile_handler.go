@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -16,6 +17,7 @@ import (
 	"security_chat_app/internal/infrastructure/repository"
 	"security_chat_app/internal/interface/markup"
 	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
 	"security_chat_app/internal/utils/icons"
 )
 
@@ -24,6 +26,10 @@ type ProfileData struct {
 	IsLoggedIn     bool
 	LoggedInUserID string
 	User           *domain.User
+	SafetyNumber   domain.SafetyNumber // 自分と相手の安全番号（どちらかが公開鍵を登録していない場合は空）
+	KeyVerified    bool                // 相手の鍵を確認済みかどうか
+	Success        string
+	Error          string
 }
 
 // プロフィールページの表示
@@ -88,10 +94,22 @@ func ProfileHandler(w http.ResponseWriter, r *http.Request) {
 		IsLoggedIn:     true,
 		LoggedInUserID: session.User.ID,
 		User:           user,
+		Success:        r.URL.Query().Get("success"),
+		Error:          r.URL.Query().Get("error"),
+	}
+
+	// 他のユーザーのプロフィールでは、暗号化の鍵を確認するための安全番号を表示する
+	if targetUserID != session.User.ID {
+		number, verified, err := chat.GetSafetyNumber(session.User.ID, targetUserID)
+		if err != nil && !errors.Is(err, domain.ErrIdentityKeyNotFound) {
+			log.Printf("安全番号の取得に失敗: userID=%s, targetID=%s, error=%v", session.User.ID, targetUserID, err)
+		}
+		data.SafetyNumber = number
+		data.KeyVerified = verified
 	}
 
 	// テンプレートを描画
-	markup.GenerateHTML(w, data, "layout", "header", "profile", "footer")
+	markup.GenerateHTML(w, data, "layout", "header", "profile", "report_dialog", "footer")
 }
 
 // アイコンアップロードハンドラ
@@ -206,3 +224,97 @@ func ProfileIconHandler(w http.ResponseWriter, r *http.Request) {
 	// プロフィールページにリダイレクト
 	http.Redirect(w, r, "/profile", http.StatusSeeOther)
 }
+
+// プロフィールの更新ハンドラ（設定ページ）
+func ProfileSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	profile := domain.Profile{
+		Bio:         r.FormValue("bio"),
+		StatusEmoji: r.FormValue("status_emoji"),
+		StatusText:  r.FormValue("status_text"),
+		Department:  r.FormValue("department"),
+		JobTitle:    r.FormValue("job_title"),
+	}
+	labels, urls := r.Form["link_label"], r.Form["link_url"]
+	for i := range urls {
+		link := domain.ProfileLink{URL: urls[i]}
+		if i < len(labels) {
+			link.Label = labels[i]
+		}
+		profile.Links = append(profile.Links, link)
+	}
+
+	// 有効期限は現在のステータスを基準に計算する（「変更しない」の場合に必要）
+	expiry, err := domain.ParseStatusExpiry(r.FormValue("status_expiry"))
+	if err == nil {
+		current, er := repository.GetUserByID(session.User.ID)
+		if er != nil {
+			log.Printf("ユーザー情報の取得に失敗: userID=%s, error=%v", session.User.ID, er)
+			err = errors.New("プロフィールの更新に失敗しました")
+		} else {
+			profile.StatusExpiresAt = expiry.ExpiresAt(time.Now(), current.StatusExpiresAt)
+			err = domain.ValidateProfile(&profile)
+		}
+	}
+	if err == nil {
+		if err = firebase.UpdateProfile(session.User.ID, profile); err != nil {
+			log.Printf("プロフィールの更新に失敗: userID=%s, error=%v", session.User.ID, err)
+			err = errors.New("プロフィールの更新に失敗しました")
+		}
+	}
+	if err != nil {
+		renderSettings(w, SettingsPageData{
+			IsLoggedIn:    true,
+			User:          session.User,
+			Profile:       profile,
+			StatusExpiry:  expiry,
+			ProfileErrors: []string{err.Error()},
+		})
+		return
+	}
+
+	http.Redirect(w, r, "/settings?success=プロフィールを更新しました", http.StatusSeeOther)
+}
+
+// Firestoreのユーザーデータからプロフィールを取得する
+func convertProfile(userData map[string]interface{}) domain.Profile {
+	var profile domain.Profile
+	profile.Bio, _ = userData["Bio"].(string)
+	profile.StatusEmoji, _ = userData["StatusEmoji"].(string)
+	profile.StatusText, _ = userData["StatusText"].(string)
+	profile.StatusExpiresAt, _ = userData["StatusExpiresAt"].(time.Time)
+	profile.Department, _ = userData["Department"].(string)
+	profile.JobTitle, _ = userData["JobTitle"].(string)
+
+	links, _ := userData["Links"].([]interface{})
+	for _, l := range links {
+		link, ok := l.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		label, _ := link["Label"].(string)
+		url, _ := link["URL"].(string)
+		if url != "" {
+			profile.Links = append(profile.Links, domain.ProfileLink{Label: label, URL: url})
+		}
+	}
+	return profile
+}
+
+// 設定ページのリンクの入力欄（登録済みのリンクの後に空の入力欄を追加する）
+func profileLinkInputs(links []domain.ProfileLink) []domain.ProfileLink {
+	inputs := make([]domain.ProfileLink, domain.MaxProfileLinks)
+	copy(inputs, links)
+	return inputs
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
+)
+
+// Service Workerのファイル（サイト全体を対象にするため/sw.jsで配信する）
+const serviceWorkerFile = "internal/web/js/sw.js"
+
+// プッシュ通知の購読ハンドラ
+// ブラウザのPushSubscription.toJSON()の形式でJSONを受け取る
+func PushSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+	if chat.DefaultPushNotifier == nil {
+		http.Error(w, domain.ErrPushUnavailable.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Endpoint       string `json:"endpoint"`
+		ExpirationTime *int64 `json:"expirationTime"` // ミリ秒のUNIX時間（期限がない場合はnull）
+		Keys           struct {
+			P256dh string `json:"p256dh"`
+			Auth   string `json:"auth"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<10)).Decode(&req); err != nil {
+		http.Error(w, "リクエストの形式が正しくありません", http.StatusBadRequest)
+		return
+	}
+
+	sub := domain.PushSubscription{
+		Endpoint:  req.Endpoint,
+		P256dh:    req.Keys.P256dh,
+		Auth:      req.Keys.Auth,
+		UserAgent: r.UserAgent(),
+	}
+	if req.ExpirationTime != nil {
+		sub.ExpiresAt = time.UnixMilli(*req.ExpirationTime)
+	}
+	if err := chat.SubscribePush(session.User.ID, sub); err != nil {
+		if errors.Is(err, domain.ErrPushSubscriptionInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("プッシュ通知の購読に失敗: userID=%s, error=%v", session.User.ID, err)
+		http.Error(w, "プッシュ通知の購読に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscribed": true,
+	})
+}
+
+// プッシュ通知の購読解除ハンドラ
+// JSONで {"endpoint": "..."} を受け取る
+func PushUnsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Endpoint string `json:"endpoint"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<10)).Decode(&req); err != nil || req.Endpoint == "" {
+		http.Error(w, "リクエストの形式が正しくありません", http.StatusBadRequest)
+		return
+	}
+
+	// 登録されていない購読の解除は成功として扱う（ブラウザ側の解除を妨げない）
+	if err := chat.UnsubscribePush(session.User.ID, req.Endpoint); err != nil && !errors.Is(err, domain.ErrPushSubscriptionNotFound) {
+		log.Printf("プッシュ通知の購読の解除に失敗: userID=%s, error=%v", session.User.ID, err)
+		http.Error(w, "プッシュ通知の購読の解除に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscribed": false,
+	})
+}
+
+// Service Workerの配信ハンドラ
+// 更新がすぐに反映されるようにキャッシュさせない
+func ServiceWorkerHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFile(w, r, serviceWorkerFile)
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// メッセージ・ユーザーの通報ハンドラ
+func ReportHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	target := domain.ReportTarget(r.FormValue("target_type"))
+	reason := domain.ReportReason(r.FormValue("reason"))
+	comment := strings.TrimSpace(r.FormValue("comment"))
+	if err := domain.ValidateReport(target, reason, comment); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report := domain.Report{
+		TargetType:   target,
+		ReporterID:   session.User.ID,
+		ReporterName: session.User.Name,
+		Reason:       reason,
+		Comment:      comment,
+		CreatedAt:    time.Now(),
+	}
+
+	// 通報時点の内容を保存する
+	switch target {
+	case domain.ReportTargetMessage:
+		if !fillMessageReport(w, &report, r.FormValue("chatID"), r.FormValue("messageID")) {
+			return
+		}
+	case domain.ReportTargetUser:
+		if !fillUserReport(w, &report, r.FormValue("userID")) {
+			return
+		}
+	}
+
+	reportID, err := firebase.AddReport(report)
+	if err != nil {
+		log.Printf("通報の保存に失敗: userID=%s, target=%s, error=%v", session.User.ID, target, err)
+		http.Error(w, "通報に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	// 監査ログを記録
+	err = firebase.AddAuditLog(domain.AuditLog{
+		Action:       domain.AuditReportCreated,
+		ActorID:      session.User.ID,
+		ActorName:    session.User.Name,
+		ReportID:     reportID,
+		TargetUserID: report.ReportedUserID,
+		ChatID:       report.ChatID,
+		MessageID:    report.MessageID,
+		Detail:       reason.Label(),
+		CreatedAt:    report.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("監査ログの保存に失敗: reportID=%s, error=%v", reportID, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"report_id": reportID,
+	})
+}
+
+// 通報されたメッセージの内容を設定する
+// 設定できない場合はエラーを書き込んでfalseを返す
+func fillMessageReport(w http.ResponseWriter, report *domain.Report, chatID string, messageID string) bool {
+	if chatID == "" || messageID == "" {
+		http.Error(w, "チャットIDとメッセージIDが必要です", http.StatusBadRequest)
+		return false
+	}
+
+	ok, err := isChatParticipant(chatID, report.ReporterID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return false
+	}
+
+	data, err := firebase.GetChatMessage(chatID, messageID)
+	if err != nil {
+		http.Error(w, "メッセージが見つかりません", http.StatusNotFound)
+		return false
+	}
+	message := convertMessage(data)
+	if message.Type == domain.MessageTypeSystem {
+		http.Error(w, domain.ErrInvalidReportTarget.Error(), http.StatusBadRequest)
+		return false
+	}
+	if message.SenderID == report.ReporterID {
+		http.Error(w, domain.ErrCannotReportSelf.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	sender, err := GetUserData(message.SenderID)
+	if err != nil {
+		http.Error(w, "ユーザーが見つかりません", http.StatusNotFound)
+		return false
+	}
+
+	report.ChatID = chatID
+	report.MessageID = messageID
+	report.ReportedUserID = sender.ID
+	report.ReportedUserName = sender.Name
+	report.Snapshot = message.Content
+	report.SnapshotMediaURL = message.MediaURL
+	return true
+}
+
+// 通報されたユーザーの内容を設定する
+// 設定できない場合はエラーを書き込んでfalseを返す
+func fillUserReport(w http.ResponseWriter, report *domain.Report, userID string) bool {
+	if userID == "" {
+		http.Error(w, "ユーザーIDが必要です", http.StatusBadRequest)
+		return false
+	}
+	if userID == report.ReporterID {
+		http.Error(w, domain.ErrCannotReportSelf.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	user, err := GetUserData(userID)
+	if err != nil {
+		http.Error(w, "ユーザーが見つかりません", http.StatusNotFound)
+		return false
+	}
+
+	report.ReportedUserID = user.ID
+	report.ReportedUserName = user.Name
+	report.Snapshot = user.Name
+	report.SnapshotMediaURL = user.Icon
+	return true
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// チャットのメッセージ保持期間の変更ハンドラ
+func ChatRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	// セッションからユーザー情報を取得
+	user, err := repository.GetUserByID(session.User.ID)
+	if err != nil {
+		log.Printf("ユーザー情報の取得に失敗: %v", err)
+		http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	chatID := r.FormValue("chatID")
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	policy, err := domain.ParseRetentionPolicy(r.FormValue("retention"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// チャットの参加者のみ保持期間を変更できる
+	ok, err := isChatParticipant(chatID, user.ID)
+	if err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	// 変更がない場合は何もしない
+	chatData, err := firebase.GetData("chats", chatID)
+	if err != nil {
+		log.Printf("チャットの取得に失敗: chatID=%s, error=%v", chatID, err)
+		http.Error(w, "チャットの取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+	current, _ := domain.ParseRetentionPolicy(getRetentionValue(chatData))
+	if current != policy {
+		if err := firebase.UpdateField("chats", chatID, "retention", string(policy)); err != nil {
+			log.Printf("保持期間の更新に失敗: chatID=%s, error=%v", chatID, err)
+			http.Error(w, "保持期間の更新に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		// 保持期間の変更をシステムメッセージとして記録
+		content := fmt.Sprintf("%sさんがメッセージの保持期間を「%s」に変更しました", user.Name, policy.Label())
+		if err := addSystemMessage(chatID, content); err != nil {
+			log.Printf("システムメッセージの追加に失敗: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"chat_id":   chatID,
+		"retention": policy,
+		"label":     policy.Label(),
+	})
+}
+
+// チャットのデータから保持期間の値を取得
+func getRetentionValue(chatData map[string]interface{}) string {
+	value, _ := chatData["retention"].(string)
+	return value
+}
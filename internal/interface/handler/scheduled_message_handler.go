@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// 予約メッセージの一覧取得・作成ハンドラ
+func ScheduleMessageHandler(w http.ResponseWriter, r *http.Request) {
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		chatID := r.URL.Query().Get("chat_id")
+		if chatID == "" {
+			http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+			return
+		}
+
+		scheduled, err := getPendingScheduledMessages(chatID, session.User.ID)
+		if err != nil {
+			log.Printf("予約メッセージの取得に失敗: chatID=%s, error=%v", chatID, err)
+			http.Error(w, "予約メッセージの取得に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		var items []map[string]interface{}
+		for _, s := range scheduled {
+			items = append(items, scheduledMessageResponse(s))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"scheduled_messages": items,
+		})
+
+	case http.MethodPost:
+		// セッションからユーザー情報を取得
+		user, err := repository.GetUserByID(session.User.ID)
+		if err != nil {
+			log.Printf("ユーザー情報の取得に失敗: %v", err)
+			http.Error(w, "ユーザー情報の取得に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		chatID := r.FormValue("chatID")
+		content := r.FormValue("content")
+		if chatID == "" {
+			http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+			return
+		}
+
+		// チャットの参加者のみ予約できる
+		ok, err := isChatParticipant(chatID, user.ID)
+		if err != nil || !ok {
+			http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+			return
+		}
+
+		// 相手にブロックされている場合は予約できない
+		if ok, err := canSendToChat(chatID, user.ID); err != nil || !ok {
+			http.Error(w, domain.ErrMessageNotAllowed.Error(), http.StatusForbidden)
+			return
+		}
+
+		// 予約メッセージは送信時まで本文をサーバーに保存するため、暗号化されたチャットでは予約できない
+		if e2ee, err := firebase.IsChatE2EE(chatID); err != nil || e2ee {
+			http.Error(w, domain.ErrPlaintextInE2EEChat.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sendAt, err := time.Parse(time.RFC3339, r.FormValue("send_at"))
+		if err != nil {
+			http.Error(w, "送信日時の形式が正しくありません", http.StatusBadRequest)
+			return
+		}
+		if err := domain.ValidateScheduledMessage(content, sendAt, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		now := time.Now()
+		scheduled := domain.ScheduledMessage{
+			ChatID:     chatID,
+			SenderID:   user.ID,
+			SenderName: user.Name,
+			Content:    content,
+			SendAt:     sendAt,
+			Status:     domain.ScheduleStatusPending,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		scheduled.ID, err = firebase.AddScheduledMessage(map[string]interface{}{
+			"chat_id":     scheduled.ChatID,
+			"sender_id":   scheduled.SenderID,
+			"sender_name": scheduled.SenderName,
+			"content":     scheduled.Content,
+			"send_at":     scheduled.SendAt,
+			"status":      string(scheduled.Status),
+			"created_at":  scheduled.CreatedAt,
+			"updated_at":  scheduled.UpdatedAt,
+		})
+		if err != nil {
+			log.Printf("予約メッセージの作成に失敗: chatID=%s, error=%v", chatID, err)
+			http.Error(w, "予約メッセージの作成に失敗しました", http.StatusInternalServerError)
+			return
+		}
+
+		// 入力欄の内容を予約したため下書きを削除
+		clearDraft(chatID, scheduled.SenderID)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(scheduledMessageResponse(scheduled))
+
+	default:
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+	}
+}
+
+// 予約メッセージの編集ハンドラ
+func EditScheduledMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	scheduleID := r.FormValue("scheduleID")
+	content := r.FormValue("content")
+	if scheduleID == "" {
+		http.Error(w, "予約メッセージのIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	sendAt, err := time.Parse(time.RFC3339, r.FormValue("send_at"))
+	if err != nil {
+		http.Error(w, "送信日時の形式が正しくありません", http.StatusBadRequest)
+		return
+	}
+	if err := domain.ValidateScheduledMessage(content, sendAt, time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = firebase.UpdatePendingScheduledMessage(scheduleID, session.User.ID, map[string]interface{}{
+		"content": content,
+		"send_at": sendAt,
+	})
+	if err != nil {
+		writeScheduleError(w, scheduleID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      scheduleID,
+		"content": content,
+		"send_at": sendAt.Format(time.RFC3339),
+	})
+}
+
+// 予約メッセージのキャンセルハンドラ
+func CancelScheduledMessageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Error(w, "認証されていません", http.StatusUnauthorized)
+		return
+	}
+
+	scheduleID := r.FormValue("scheduleID")
+	if scheduleID == "" {
+		http.Error(w, "予約メッセージのIDが必要です", http.StatusBadRequest)
+		return
+	}
+
+	err = firebase.UpdatePendingScheduledMessage(scheduleID, session.User.ID, map[string]interface{}{
+		"status": string(domain.ScheduleStatusCanceled),
+	})
+	if err != nil {
+		writeScheduleError(w, scheduleID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     scheduleID,
+		"status": domain.ScheduleStatusCanceled,
+	})
+}
+
+// 送信待ちの予約メッセージを送信日時の昇順で取得
+func getPendingScheduledMessages(chatID string, userID string) ([]domain.ScheduledMessage, error) {
+	scheduled, err := firebase.GetPendingScheduledMessages(chatID, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(scheduled, func(i, j int) bool {
+		return scheduled[i].SendAt.Before(scheduled[j].SendAt)
+	})
+	return scheduled, nil
+}
+
+// 予約メッセージのJSONレスポンスを作成
+func scheduledMessageResponse(s domain.ScheduledMessage) map[string]interface{} {
+	return map[string]interface{}{
+		"id":      s.ID,
+		"chat_id": s.ChatID,
+		"content": s.Content,
+		"send_at": s.SendAt.Format(time.RFC3339),
+		"status":  s.Status,
+	}
+}
+
+// 予約メッセージの更新エラーをレスポンスに変換
+func writeScheduleError(w http.ResponseWriter, scheduleID string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrScheduleNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, domain.ErrScheduleNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("予約メッセージの更新に失敗: scheduleID=%s, error=%v", scheduleID, err)
+		http.Error(w, "予約メッセージの更新に失敗しました", http.StatusInternalServerError)
+	}
+}
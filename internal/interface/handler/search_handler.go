@@ -11,6 +11,7 @@ import (
 	"security_chat_app/internal/infrastructure/firebase"
 	"security_chat_app/internal/interface/markup"
 	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
 )
 
 // 検索ページのデータ構造体
@@ -97,8 +98,21 @@ func getSearchPageData(user *domain.User, r *http.Request) (SearchPageData, erro
 		}
 	}
 
+	// ブロックしているユーザーを取得
+	blockedUsers, err := getBlockedUserSet(user.ID)
+	if err != nil {
+		return SearchPageData{}, fmt.Errorf("ブロックしているユーザーの取得に失敗しました: %v", err)
+	}
+
+	// 連絡先の関係を取得
+	contactStatuses, err := chat.GetContactStatuses(user.ID)
+	if err != nil {
+		return SearchPageData{}, fmt.Errorf("連絡先の取得に失敗しました: %v", err)
+	}
+
 	// 自分以外かつチャット履歴のないユーザーをフィルタリング
 	var filteredUsers []map[string]interface{}
+	now := time.Now()
 	for _, u := range users {
 		var userID string
 		var ok bool
@@ -119,14 +133,24 @@ func getSearchPageData(user *domain.User, r *http.Request) (SearchPageData, erro
 			continue
 		}
 
+		// ブロックしているユーザーは除外
+		if blockedUsers[userID] {
+			continue
+		}
+
 		// チャット履歴のないユーザーのみを追加
 		if !chattedUsers[userID] {
+			// オフライン表示のユーザーはオフラインとして表示する
+			presence := presenceOf(u, now)
 			// テンプレートで使用するフィールド名に合わせてデータを整形
 			userData := map[string]interface{}{
-				"id":       userID,
-				"name":     u["Name"],
-				"icon":     u["Icon"],
-				"IsOnline": u["IsOnline"],
+				"id":            userID,
+				"name":          u["Name"],
+				"icon":          u["Icon"],
+				"IsOnline":      presence != domain.AvailabilityOffline,
+				"presence":      presence,
+				"contactStatus": contactStatuses[userID],
+				"contactsOnly":  u["ContactsOnly"],
 			}
 			filteredUsers = append(filteredUsers, userData)
 		}
@@ -210,11 +234,16 @@ func GetUserData(userID string) (*domain.User, error) {
 		isOnline = online
 	}
 
+	availability, schedule := convertAvailability(userData)
+
 	return &domain.User{
-		ID:       id,
-		Name:     name,
-		Email:    email,
-		Icon:     iconURL,
-		IsOnline: isOnline,
+		ID:           id,
+		Name:         name,
+		Email:        email,
+		Icon:         iconURL,
+		IsOnline:     isOnline,
+		Availability: availability,
+		DNDSchedule:  schedule,
+		Profile:      convertProfile(userData),
 	}, nil
 }
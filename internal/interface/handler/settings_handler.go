@@ -5,10 +5,12 @@ import (
 	"log"
 	"net/http"
 
+	"security_chat_app/internal/config"
 	"security_chat_app/internal/domain"
 	"security_chat_app/internal/infrastructure/firebase"
 	"security_chat_app/internal/interface/markup"
 	"security_chat_app/internal/interface/middleware"
+	"security_chat_app/internal/usecase/chat"
 	"security_chat_app/internal/utils/uuid"
 )
 
@@ -22,8 +24,25 @@ type SettingsPageData struct {
 	UsernameForm struct {
 		NewUsername string // 新しいユーザー名
 	}
-	ValidationErrors         []string // バリデーションエラー
-	UsernameValidationErrors []string // ユーザー名のバリデーションエラー
+	ValidationErrors         []string             // バリデーションエラー
+	UsernameValidationErrors []string             // ユーザー名のバリデーションエラー
+	BlockedUsers             []*domain.User       // ブロックしているユーザー
+	Bots                     []domain.Bot         // 作成したボット
+	NewBotName               string               // 作成に失敗したボットの名前
+	BotErrors                []string             // ボットの作成のエラー
+	CreatedBot               *domain.Bot          // 作成したばかりのボット
+	CreatedBotToken          string               // 作成したばかりのボットのAPIトークン（この時だけ表示する）
+	EmailDigest              bool                 // 未読メッセージをメールで受け取るかどうか
+	EmailAvailable           bool                 // サーバーでメールを送信できるかどうか
+	PushPublicKey            string               // プッシュ通知の購読に使用するVAPIDの公開鍵（空の場合は利用できない）
+	PushDevices              int                  // プッシュ通知を受け取る端末の数
+	Profile                  domain.Profile       // プロフィール（更新に失敗した場合は入力した内容）
+	ProfileLinks             []domain.ProfileLink // プロフィールのリンクの入力欄
+	StatusExpiry             domain.StatusExpiry  // 選択しているステータスの有効期限
+	ProfileErrors            []string             // プロフィールの更新のエラー
+	Availability             domain.Availability  // 在席状況（更新に失敗した場合は選択した内容）
+	DNDSchedule              domain.DNDSchedule   // おやすみモードのスケジュール
+	AvailabilityErrors       []string             // 在席状況の更新のエラー
 }
 
 // 設定ページのハンドラ
@@ -63,7 +82,7 @@ func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 				},
 				UsernameValidationErrors: validationErrors,
 			}
-			markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+			renderSettings(w, data)
 			return
 		}
 
@@ -82,7 +101,7 @@ func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 				},
 				UsernameValidationErrors: validationErrors,
 			}
-			markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+			renderSettings(w, data)
 			return
 		}
 
@@ -137,7 +156,7 @@ func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 				PasswordForm:     form,
 				ValidationErrors: validationErrors,
 			}
-			markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+			renderSettings(w, data)
 			return
 		}
 
@@ -152,7 +171,7 @@ func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 				PasswordForm:     form,
 				ValidationErrors: []string{"パスワード更新エラーが発生しました"},
 			}
-			markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+			renderSettings(w, data)
 			return
 		}
 
@@ -167,7 +186,7 @@ func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 				PasswordForm:     form,
 				ValidationErrors: []string{"パスワード更新エラーが発生しました"},
 			}
-			markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+			renderSettings(w, data)
 			return
 		}
 
@@ -184,7 +203,7 @@ func SettingsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// テンプレートのレンダリング
-	markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+	renderSettings(w, data)
 }
 
 // 設定ページのデータを取得
@@ -204,3 +223,54 @@ func getSettingsPageData(user *domain.User, r *http.Request) (SettingsPageData,
 		ShowUsernameForm: showUsernameForm,
 	}, nil
 }
+
+// 設定ページを表示する（ブロックしているユーザーと作成したボットの一覧を含める）
+func renderSettings(w http.ResponseWriter, data SettingsPageData) {
+	if data.User != nil {
+		ids, err := firebase.GetBlockedUserIDs(data.User.ID)
+		if err != nil {
+			log.Printf("ブロックしているユーザーの取得に失敗: userID=%s, error=%v", data.User.ID, err)
+		}
+		for _, id := range ids {
+			blocked, err := GetUserData(id)
+			if err != nil {
+				log.Printf("ブロックしているユーザーの情報の取得に失敗: userID=%s, error=%v", id, err)
+				continue
+			}
+			data.BlockedUsers = append(data.BlockedUsers, blocked)
+		}
+
+		bots, err := firebase.GetBotsByOwner(data.User.ID)
+		if err != nil {
+			log.Printf("ボットの取得に失敗: userID=%s, error=%v", data.User.ID, err)
+		}
+		data.Bots = bots
+
+		// メール通知の設定はセッションではなく最新のユーザー情報から取得する
+		if userData, err := firebase.GetData("users", data.User.ID); err == nil {
+			data.EmailDigest, _ = userData["EmailDigest"].(bool)
+			// 更新に失敗した場合は入力した内容を表示する
+			if data.AvailabilityErrors == nil {
+				data.Availability, data.DNDSchedule = convertAvailability(userData)
+			}
+			if data.ProfileErrors == nil {
+				data.Profile = convertProfile(userData)
+				if data.Profile.HasStatus() {
+					data.StatusExpiry = domain.StatusExpiryKeep
+				}
+			}
+		}
+		data.ProfileLinks = profileLinkInputs(data.Profile.Links)
+
+		if subs, err := firebase.GetPushSubscriptionsByUser(data.User.ID); err == nil {
+			data.PushDevices = len(subs)
+		} else {
+			log.Printf("プッシュ通知の購読の取得に失敗: userID=%s, error=%v", data.User.ID, err)
+		}
+	}
+	data.EmailAvailable = config.Config.SMTPHost != ""
+	if chat.DefaultPushNotifier != nil {
+		data.PushPublicKey = config.Config.VAPIDPublicKey
+	}
+	markup.GenerateHTML(w, data, "layout", "header", "settings", "footer")
+}
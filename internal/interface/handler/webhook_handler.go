@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/interface/markup"
+	"security_chat_app/internal/interface/middleware"
+)
+
+// Webhookごとに表示する配信履歴の件数
+const webhookDeliveryDisplayLimit = 10
+
+// Webhookの管理ページのデータ構造体
+type WebhooksPageData struct {
+	IsLoggedIn bool           // ログイン状態
+	User       *domain.User   // ユーザー情報
+	ChatID     string         // 対象のチャットのID（全体のWebhookの場合は空）
+	IsAdmin    bool           // 管理画面として表示するかどうか
+	Webhooks   []WebhookEntry // Webhookの一覧
+	Error      string         // 操作に失敗した場合のエラー
+
+	IncomingWebhooks       []domain.IncomingWebhook // 受信Webhookの一覧（チャットの場合のみ）
+	CreatedIncomingWebhook *domain.IncomingWebhook  // 作成した受信Webhook
+	CreatedIncomingURL     string                   // 作成した受信WebhookのURL（作成時にしか表示しない）
+}
+
+// Webhookと最近の配信履歴
+type WebhookEntry struct {
+	domain.Webhook
+	Deliveries []domain.WebhookDelivery
+}
+
+// チャットのWebhookの管理ページと登録のハンドラ
+// チャットの参加者であれば誰でも一覧を表示・登録・変更でき、変更はシステムメッセージで全員に知らせる
+func ChatWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return
+	}
+
+	chatID := r.FormValue("chat_id")
+	if chatID == "" {
+		chatID = r.FormValue("chatID")
+	}
+	if chatID == "" {
+		http.Error(w, "チャットIDが必要です", http.StatusBadRequest)
+		return
+	}
+	if ok, err := isChatParticipant(chatID, session.User.ID); err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		renderWebhooks(w, WebhooksPageData{
+			IsLoggedIn: true,
+			User:       session.User,
+			ChatID:     chatID,
+			Error:      r.URL.Query().Get("error"),
+		})
+	case http.MethodPost:
+		webhook, err := createWebhook(r, session.User, chatID)
+		if err != nil {
+			redirectWebhooks(w, r, chatID, err)
+			return
+		}
+		notifyWebhookChange(session.User, *webhook, "追加しました")
+		redirectWebhooks(w, r, chatID, nil)
+	default:
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+	}
+}
+
+// 全体のWebhookの管理ページと登録のハンドラ（管理者のみ）
+func AdminWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	admin, ok := requireAdmin(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		renderWebhooks(w, WebhooksPageData{
+			IsLoggedIn: true,
+			User:       admin,
+			IsAdmin:    true,
+			Error:      r.URL.Query().Get("error"),
+		})
+	case http.MethodPost:
+		webhook, err := createWebhook(r, admin, "")
+		if err != nil {
+			redirectWebhooks(w, r, "", err)
+			return
+		}
+		recordWebhookChange(admin, domain.AuditWebhookCreated, *webhook)
+		redirectWebhooks(w, r, "", nil)
+	default:
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+	}
+}
+
+// Webhookの有効・無効の切り替えハンドラ
+func ToggleWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, webhook, ok := authorizeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	webhook.Enabled = !webhook.Enabled
+	if err := firebase.SetWebhookEnabled(webhook.ID, webhook.Enabled); err != nil {
+		log.Printf("Webhookの更新に失敗: webhookID=%s, error=%v", webhook.ID, err)
+		redirectWebhooks(w, r, webhook.ChatID, errors.New("Webhookの更新に失敗しました"))
+		return
+	}
+
+	if webhook.IsGlobal() {
+		recordWebhookChange(user, domain.AuditWebhookUpdated, *webhook)
+	} else if webhook.Enabled {
+		notifyWebhookChange(user, *webhook, "有効にしました")
+	} else {
+		notifyWebhookChange(user, *webhook, "無効にしました")
+	}
+	redirectWebhooks(w, r, webhook.ChatID, nil)
+}
+
+// Webhookの削除ハンドラ
+func DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "メソッドが許可されていません", http.StatusMethodNotAllowed)
+		return
+	}
+
+	user, webhook, ok := authorizeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := firebase.DeleteWebhook(webhook.ID); err != nil {
+		log.Printf("Webhookの削除に失敗: webhookID=%s, error=%v", webhook.ID, err)
+		redirectWebhooks(w, r, webhook.ChatID, errors.New("Webhookの削除に失敗しました"))
+		return
+	}
+
+	if webhook.IsGlobal() {
+		recordWebhookChange(user, domain.AuditWebhookDeleted, *webhook)
+	} else {
+		notifyWebhookChange(user, *webhook, "削除しました")
+	}
+	redirectWebhooks(w, r, webhook.ChatID, nil)
+}
+
+// 操作するWebhookを取得し、権限を確認する
+// 全体のWebhookは管理者、チャットのWebhookはチャットの参加者だけが操作できる
+func authorizeWebhook(w http.ResponseWriter, r *http.Request) (*domain.User, *domain.Webhook, bool) {
+	webhook, err := firebase.GetWebhook(r.FormValue("webhookID"))
+	if err != nil {
+		http.Error(w, domain.ErrWebhookNotFound.Error(), http.StatusNotFound)
+		return nil, nil, false
+	}
+
+	if webhook.IsGlobal() {
+		admin, ok := requireAdmin(w, r)
+		return admin, webhook, ok
+	}
+
+	// セッションの検証
+	session, err := middleware.ValidateSession(w, r)
+	if err != nil {
+		http.Redirect(w, r, "/login", http.StatusSeeOther)
+		return nil, nil, false
+	}
+	if ok, err := isChatParticipant(webhook.ChatID, session.User.ID); err != nil || !ok {
+		http.Error(w, "このチャットにはアクセスできません", http.StatusForbidden)
+		return nil, nil, false
+	}
+	return session.User, webhook, true
+}
+
+// フォームの内容からWebhookを登録する
+func createWebhook(r *http.Request, user *domain.User, chatID string) (*domain.Webhook, error) {
+	rawURL := strings.TrimSpace(r.FormValue("url"))
+	var events []domain.WebhookEvent
+	for _, e := range r.Form["events"] {
+		events = append(events, domain.WebhookEvent(e))
+	}
+	if err := domain.ValidateWebhook(rawURL, events); err != nil {
+		return nil, err
+	}
+
+	if chatID != "" {
+		webhooks, err := firebase.GetWebhooksByChat(chatID)
+		if err != nil {
+			log.Printf("Webhookの取得に失敗: chatID=%s, error=%v", chatID, err)
+			return nil, errors.New("Webhookの登録に失敗しました")
+		}
+		if len(webhooks) >= domain.MaxWebhooksPerChat {
+			return nil, domain.ErrWebhookLimitExceeded
+		}
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		log.Printf("Webhookのシークレットの生成に失敗: error=%v", err)
+		return nil, errors.New("Webhookの登録に失敗しました")
+	}
+
+	webhook := domain.Webhook{
+		ChatID:    chatID,
+		URL:       rawURL,
+		Secret:    secret,
+		Events:    events,
+		Enabled:   true,
+		CreatedBy: user.ID,
+		CreatedAt: time.Now(),
+	}
+	webhookID, err := firebase.AddWebhook(webhook)
+	if err != nil {
+		log.Printf("Webhookの登録に失敗: chatID=%s, error=%v", chatID, err)
+		return nil, errors.New("Webhookの登録に失敗しました")
+	}
+	webhook.ID = webhookID
+	return &webhook, nil
+}
+
+// Webhookの管理ページを表示する
+func renderWebhooks(w http.ResponseWriter, data WebhooksPageData) {
+	webhooks, err := firebase.GetWebhooksByChat(data.ChatID)
+	if err != nil {
+		log.Printf("Webhookの取得に失敗: chatID=%s, error=%v", data.ChatID, err)
+		http.Error(w, "Webhookの取得に失敗しました", http.StatusInternalServerError)
+		return
+	}
+
+	data.Webhooks = make([]WebhookEntry, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries, err := firebase.GetWebhookDeliveries(webhook.ID, webhookDeliveryDisplayLimit)
+		if err != nil {
+			log.Printf("Webhookの配信履歴の取得に失敗: webhookID=%s, error=%v", webhook.ID, err)
+		}
+		data.Webhooks = append(data.Webhooks, WebhookEntry{Webhook: webhook, Deliveries: deliveries})
+	}
+
+	if !data.IsAdmin {
+		data.IncomingWebhooks, err = firebase.GetIncomingWebhooksByChat(data.ChatID)
+		if err != nil {
+			log.Printf("受信Webhookの取得に失敗: chatID=%s, error=%v", data.ChatID, err)
+		}
+	}
+
+	if data.IsAdmin {
+		markup.GenerateHTML(w, data, "layout", "header", "admin_nav", "admin_webhooks", "webhook_list", "footer")
+		return
+	}
+	markup.GenerateHTML(w, data, "layout", "header", "chat_webhooks", "webhook_list", "footer")
+}
+
+// チャットのWebhookの変更をシステムメッセージで参加者に知らせる
+// URLのパスやクエリにはトークンが含まれる場合があるため、ホスト名だけを表示する
+func notifyWebhookChange(user *domain.User, webhook domain.Webhook, action string) {
+	host := webhook.URL
+	if u, err := url.Parse(webhook.URL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	notice := fmt.Sprintf("%sさんがWebhook（%s）を%s", user.Name, host, action)
+	if err := addSystemMessage(webhook.ChatID, notice); err != nil {
+		log.Printf("システムメッセージの追加に失敗: chatID=%s, error=%v", webhook.ChatID, err)
+	}
+}
+
+// 全体のWebhookの変更を監査ログに記録する
+func recordWebhookChange(admin *domain.User, action domain.AuditAction, webhook domain.Webhook) {
+	detail := webhook.URL
+	if action == domain.AuditWebhookUpdated {
+		if webhook.Enabled {
+			detail += "（有効）"
+		} else {
+			detail += "（無効）"
+		}
+	}
+
+	err := firebase.AddAuditLog(domain.AuditLog{
+		Action:    action,
+		ActorID:   admin.ID,
+		ActorName: admin.Name,
+		Detail:    detail,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		log.Printf("監査ログの保存に失敗: webhookID=%s, error=%v", webhook.ID, err)
+	}
+}
+
+// Webhookの署名用シークレットを生成する
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return domain.WebhookSecretPrefix + hex.EncodeToString(b), nil
+}
+
+// Webhookの管理ページに戻る
+func redirectWebhooks(w http.ResponseWriter, r *http.Request, chatID string, err error) {
+	target := "/admin/webhooks"
+	query := url.Values{}
+	if chatID != "" {
+		target = "/chat/webhooks"
+		query.Set("chat_id", chatID)
+	}
+	if err != nil {
+		query.Set("error", err.Error())
+	}
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
@@ -25,6 +25,12 @@ var templateFuncs = template.FuncMap{
 			return len(v)
 		case []domain.Chat:
 			return len(v)
+		case []domain.PinnedMessage:
+			return len(v)
+		case []domain.Bot:
+			return len(v)
+		case []string:
+			return len(v)
 		default:
 			return 0
 		}
@@ -45,6 +51,73 @@ var templateFuncs = template.FuncMap{
 		}
 		return s[start:end]
 	},
+	"dict": func(values ...any) map[string]any {
+		// テンプレートに複数の値を渡すためのマップを生成
+		dict := make(map[string]any, len(values)/2)
+		for i := 0; i+1 < len(values); i += 2 {
+			if key, ok := values[i].(string); ok {
+				dict[key] = values[i+1]
+			}
+		}
+		return dict
+	},
+	"renderMessage": func(m domain.Message) template.HTML {
+		return RenderMessage(m.Content, m.Mentions)
+	},
+	"retentionPolicies": func() []domain.RetentionPolicy {
+		return domain.RetentionPolicies()
+	},
+	"maxPinnedMessages": func() int {
+		return domain.MaxPinnedMessages
+	},
+	"reportReasons": func() []domain.ReportReason {
+		return domain.ReportReasons()
+	},
+	"maxReportCommentLength": func() int {
+		return domain.MaxReportCommentLength
+	},
+	"filterActions": func() []domain.FilterAction {
+		return domain.FilterActions()
+	},
+	"maxFilterPatternLength": func() int {
+		return domain.MaxFilterPatternLength
+	},
+	"webhookEvents": func() []domain.WebhookEvent {
+		return domain.WebhookEvents()
+	},
+	"maxWebhooksPerChat": func() int {
+		return domain.MaxWebhooksPerChat
+	},
+	"maxWebhookURLLength": func() int {
+		return domain.MaxWebhookURLLength
+	},
+	"maxIncomingWebhookNameLength": func() int {
+		return domain.MaxIncomingWebhookNameLength
+	},
+	"incomingWebhookRateLimit": func() int {
+		return domain.IncomingWebhookRateLimit
+	},
+	"availabilities": func() []domain.Availability {
+		return domain.Availabilities()
+	},
+	"statusExpiries": func() []domain.StatusExpiry {
+		return domain.StatusExpiries()
+	},
+	"maxBioLength": func() int {
+		return domain.MaxBioLength
+	},
+	"maxStatusTextLength": func() int {
+		return domain.MaxStatusTextLength
+	},
+	"maxDepartmentLength": func() int {
+		return domain.MaxDepartmentLength
+	},
+	"maxJobTitleLength": func() int {
+		return domain.MaxJobTitleLength
+	},
+	"maxProfileLinkLabelLength": func() int {
+		return domain.MaxProfileLinkLabelLength
+	},
 	"getRandomDefaultIcon": func() string {
 		// 0から6までのランダムな数字を生成
 		randomNum := random.LocalRand.Intn(icons.DefaultIconCount)
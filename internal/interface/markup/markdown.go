@@ -0,0 +1,339 @@
+package markup
+
+import (
+	"fmt"
+	"html/template"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"security_chat_app/internal/domain"
+)
+
+// メッセージで使用できるMarkdownの記法
+//   - **太字** / __太字__
+//   - *斜体* / _斜体_
+//   - `インラインコード`
+//   - ```で囲んだコードブロック
+//   - 「- 」「* 」「1. 」で始まるリスト
+//   - [テキスト](URL) 形式のリンクとURLの自動リンク
+
+// メンションを本文中で一時的に置き換えるための目印（Unicodeの私用領域の文字）
+const (
+	mentionOpen  = '\uE000'
+	mentionClose = '\uE001'
+)
+
+var (
+	fencePattern       = regexp.MustCompile("^\\s*```\\s*([A-Za-z0-9_+-]*)\\s*$")
+	unorderedPattern   = regexp.MustCompile(`^\s*[-*+]\s+(.*)$`)
+	orderedPattern     = regexp.MustCompile(`^\s*\d{1,9}[.)]\s+(.*)$`)
+	mentionPlaceholder = regexp.MustCompile(`\x{E000}(\d+)\x{E001}`)
+)
+
+// RenderMessage メッセージの本文をMarkdownとして解釈し、許可したタグのみのHTMLに変換する
+// メンションはプロフィールへのリンクとして表示する
+func RenderMessage(content string, mentions []domain.Mention) template.HTML {
+	r := &markdownRenderer{}
+	text := r.replaceMentions(content, mentions)
+	return template.HTML(SanitizeHTML(r.renderBlocks(text)))
+}
+
+// Markdownの変換処理
+type markdownRenderer struct {
+	mentions []domain.Mention // 本文から置き換えたメンション
+}
+
+// 本文と一致するメンションを目印に置き換える
+func (r *markdownRenderer) replaceMentions(content string, mentions []domain.Mention) string {
+	// 本文に含まれる目印の文字は置き換えて、メンションを偽装できないようにする
+	content = strings.Map(func(c rune) rune {
+		if c == mentionOpen || c == mentionClose {
+			return utf8.RuneError
+		}
+		return c
+	}, content)
+
+	sorted := make([]domain.Mention, len(mentions))
+	copy(sorted, mentions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start < sorted[j].Start
+	})
+
+	var b strings.Builder
+	pos := 0
+	for _, m := range sorted {
+		// 本文と一致しないメンション（編集後など）はそのまま表示する
+		if m.Start < pos || m.End > len(content) || m.Start >= m.End ||
+			content[m.Start:m.End] != "@"+m.Username {
+			continue
+		}
+		b.WriteString(content[pos:m.Start])
+		fmt.Fprintf(&b, "%c%d%c", mentionOpen, len(r.mentions), mentionClose)
+		r.mentions = append(r.mentions, m)
+		pos = m.End
+	}
+	b.WriteString(content[pos:])
+	return b.String()
+}
+
+// ブロック要素（段落・コードブロック・リスト）に変換する
+func (r *markdownRenderer) renderBlocks(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var b strings.Builder
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		var rendered []string
+		for _, line := range paragraph {
+			rendered = append(rendered, r.renderInline(line))
+		}
+		b.WriteString("<p>" + strings.Join(rendered, "<br>") + "</p>")
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		// コードブロック（閉じられていない場合は末尾までをコードとする）
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			flushParagraph()
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.TrimSpace(lines[i]) == "```" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			b.WriteString("<pre><code")
+			if m[1] != "" {
+				b.WriteString(` class="language-` + m[1] + `"`)
+			}
+			b.WriteString(">" + template.HTMLEscapeString(r.restoreMentions(strings.Join(code, "\n"))) + "</code></pre>")
+			continue
+		}
+
+		// リスト
+		if unorderedPattern.MatchString(line) || orderedPattern.MatchString(line) {
+			flushParagraph()
+			pattern, tag := unorderedPattern, "ul"
+			if !unorderedPattern.MatchString(line) {
+				pattern, tag = orderedPattern, "ol"
+			}
+			b.WriteString("<" + tag + ">")
+			for ; i < len(lines); i++ {
+				m := pattern.FindStringSubmatch(lines[i])
+				if m == nil {
+					break
+				}
+				b.WriteString("<li>" + r.renderInline(m[1]) + "</li>")
+			}
+			b.WriteString("</" + tag + ">")
+			i--
+			continue
+		}
+
+		// 空行で段落を区切る
+		if strings.TrimSpace(line) == "" {
+			flushParagraph()
+			continue
+		}
+		paragraph = append(paragraph, line)
+	}
+	flushParagraph()
+
+	return b.String()
+}
+
+// インライン要素（太字・斜体・コード・リンク・メンション）に変換する
+func (r *markdownRenderer) renderInline(s string) string {
+	return r.renderInlineWith(s, true)
+}
+
+func (r *markdownRenderer) renderInlineWith(s string, allowLinks bool) string {
+	var b strings.Builder
+	var text strings.Builder
+	flushText := func() {
+		b.WriteString(template.HTMLEscapeString(text.String()))
+		text.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		rest := s[i:]
+
+		// インラインコード（中身は記法を解釈しない）
+		if rest[0] == '`' {
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flushText()
+				b.WriteString("<code>" + template.HTMLEscapeString(r.restoreMentions(rest[1:1+end])) + "</code>")
+				i += end + 2
+				continue
+			}
+		}
+
+		// 太字
+		if strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__") {
+			delim := rest[:2]
+			if end := strings.Index(rest[2:], delim); end > 0 && canOpenEmphasis(s, i, rest[2:]) &&
+				(delim == "**" || isBoundaryAt(s, i+4+end)) {
+				flushText()
+				b.WriteString("<strong>" + r.renderInlineWith(rest[2:2+end], allowLinks) + "</strong>")
+				i += end + 4
+				continue
+			}
+		}
+
+		// 斜体
+		if rest[0] == '*' || rest[0] == '_' {
+			delim := rest[:1]
+			if end := strings.Index(rest[1:], delim); end > 0 && canOpenEmphasis(s, i, rest[1:]) &&
+				(delim == "*" || isBoundaryAt(s, i+2+end)) {
+				flushText()
+				b.WriteString("<em>" + r.renderInlineWith(rest[1:1+end], allowLinks) + "</em>")
+				i += end + 2
+				continue
+			}
+		}
+
+		// [テキスト](URL) 形式のリンク
+		if allowLinks && rest[0] == '[' {
+			if label, href, n, ok := parseLink(rest); ok {
+				flushText()
+				b.WriteString(`<a href="` + template.HTMLEscapeString(href) + `" rel="noopener noreferrer nofollow" target="_blank">`)
+				b.WriteString(r.renderInlineWith(label, false) + "</a>")
+				i += n
+				continue
+			}
+		}
+
+		// URLの自動リンク
+		if allowLinks && (strings.HasPrefix(rest, "http://") || strings.HasPrefix(rest, "https://")) && isBoundaryBefore(s, i) {
+			if href, n := parseAutolink(rest); n > 0 {
+				flushText()
+				escaped := template.HTMLEscapeString(href)
+				b.WriteString(`<a href="` + escaped + `" rel="noopener noreferrer nofollow" target="_blank">` + escaped + "</a>")
+				i += n
+				continue
+			}
+		}
+
+		// メンション
+		if loc := mentionPlaceholder.FindStringSubmatchIndex(rest); loc != nil && loc[0] == 0 {
+			idx, _ := strconv.Atoi(rest[loc[2]:loc[3]])
+			flushText()
+			m := r.mentions[idx]
+			if allowLinks {
+				b.WriteString(`<a href="/profile/` + template.HTMLEscapeString(url.PathEscape(m.UserID)) + `" class="p-message__mention">`)
+				b.WriteString(template.HTMLEscapeString("@"+m.Username) + "</a>")
+			} else {
+				b.WriteString(template.HTMLEscapeString("@" + m.Username))
+			}
+			i += loc[1]
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(rest)
+		text.WriteString(rest[:size])
+		i += size
+	}
+	flushText()
+
+	return b.String()
+}
+
+// 目印に置き換えたメンションを元の文字列に戻す（コード内で使用する）
+func (r *markdownRenderer) restoreMentions(s string) string {
+	return mentionPlaceholder.ReplaceAllStringFunc(s, func(token string) string {
+		idx, _ := strconv.Atoi(token[len(string(mentionOpen)) : len(token)-len(string(mentionClose))])
+		return "@" + r.mentions[idx].Username
+	})
+}
+
+// 強調の開始記号の直後が空白の場合は強調とみなさない（「* 」などの通常の記号）
+// 「_」はsnake_caseなどの単語の途中では強調とみなさない
+func canOpenEmphasis(s string, at int, inner string) bool {
+	if inner == "" || inner[0] == ' ' || inner[0] == '\t' {
+		return false
+	}
+	return s[at] == '*' || isBoundaryBefore(s, at)
+}
+
+// 位置atの直前が半角英数字でないかどうか
+func isBoundaryBefore(s string, at int) bool {
+	if at <= 0 {
+		return true
+	}
+	c, _ := utf8.DecodeLastRuneInString(s[:at])
+	return !isWordRune(c)
+}
+
+// 位置atの文字が半角英数字でないかどうか
+func isBoundaryAt(s string, at int) bool {
+	if at >= len(s) {
+		return true
+	}
+	c, _ := utf8.DecodeRuneInString(s[at:])
+	return !isWordRune(c)
+}
+
+// 半角英数字とアンダースコアかどうか
+func isWordRune(c rune) bool {
+	return c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// [テキスト](URL) 形式のリンクを解析する
+func parseLink(s string) (label string, href string, n int, ok bool) {
+	closeLabel := strings.Index(s, "](")
+	if closeLabel <= 1 {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(s[closeLabel+2:], ')')
+	if closeURL < 0 {
+		return "", "", 0, false
+	}
+	href = s[closeLabel+2 : closeLabel+2+closeURL]
+	if !isSafeURL(href) {
+		return "", "", 0, false
+	}
+	return s[1:closeLabel], href, closeLabel + 3 + closeURL, true
+}
+
+// 本文中のURLを解析する（末尾の句読点や閉じ括弧はURLに含めない）
+func parseAutolink(s string) (string, int) {
+	end := strings.IndexFunc(s, func(c rune) bool {
+		return c <= ' ' || c == '<' || c == '>' || c == '"' || c == '`' || c == mentionOpen || c >= utf8.RuneSelf
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	href := strings.TrimRight(s[:end], ".,:;!?)]'*_")
+	if !isSafeURL(href) {
+		return "", 0
+	}
+	return href, len(href)
+}
+
+// リンク先として許可するURLかどうか（javascript: などのスキームを拒否する）
+func isSafeURL(raw string) bool {
+	if raw == "" || strings.ContainsAny(raw, " \t\r\n<>\"'`") {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return u.Host != ""
+	case "mailto":
+		return u.Opaque != ""
+	default:
+		return false
+	}
+}
@@ -0,0 +1,81 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+
+	"security_chat_app/internal/domain"
+)
+
+func TestRenderMessageXSS(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		absent  []string // 出力に含まれてはいけない文字列（小文字で比較する）
+	}{
+		{"Markdown中のscriptタグ", "こんにちは<script>alert(1)</script>", []string{"<script"}},
+		{"Markdown中のimgのonerror", `<img src=x onerror=alert(1)>`, []string{"<img"}},
+		{"太字の中のHTML", `**<img src=x onerror=alert(1)>**`, []string{"<img"}},
+		{"リスト中のHTML", "- <svg onload=alert(1)>\n- b", []string{"<svg"}},
+		{"コードブロック中のHTML", "```html\n<script>alert(1)</script>\n```", []string{"<script"}},
+		{"インラインコード中のHTML", "`<a href=\"javascript:alert(1)\">x</a>`", []string{"<a href"}},
+		{"HTMLのリンク", `<a href="javascript:alert(1)">x</a>`, []string{"<a href"}},
+		{"javascriptのリンク", `[x](javascript:alert(1))`, []string{"href="}},
+		{"大文字小文字が混在したjavascriptのリンク", `[x](JavaScript:alert(1))`, []string{"href="}},
+		{"文字参照でエンコードしたjavascriptのリンク", `[x](&#106;avascript:alert(1))`, []string{"href="}},
+		{"dataのリンク", `[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`, []string{"href="}},
+		{"大文字のdataのリンク", `[x](DATA:text/html,alert(1))`, []string{"href="}},
+		{"属性を閉じようとするリンク", `[x](https://example.com/"onmouseover="alert(1))`, []string{"onmouseover=\""}},
+		{"リンクのテキスト中のHTML", `[<img src=x onerror=alert(1)>](https://example.com)`, []string{"<img"}},
+		{"自動リンクに続くHTML", `https://example.com/<script>alert(1)</script>`, []string{"<script"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(RenderMessage(tt.content, nil))
+			assertSafeHTML(t, got)
+			for _, s := range tt.absent {
+				if strings.Contains(strings.ToLower(got), s) {
+					t.Errorf("RenderMessage(%q) = %q, %q を含んではいけません", tt.content, got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderMessageLinks(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"Markdownのリンク", `[例](https://example.com)`, `href="https://example.com"`},
+		{"自動リンク", `https://example.com を見てください`, `href="https://example.com"`},
+		{"別タブで開くリンク", `https://example.com`, `rel="noopener noreferrer nofollow"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(RenderMessage(tt.content, nil))
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("RenderMessage(%q) = %q, %q を含む必要があります", tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderMessageMentions(t *testing.T) {
+	mentions := []domain.Mention{{UserID: "u1", Username: "taro", Start: 0, End: len("@taro")}}
+
+	got := string(RenderMessage("@taro こんにちは", mentions))
+	if !strings.Contains(got, `href="/profile/u1"`) {
+		t.Errorf("メンションがプロフィールへのリンクになっていません: %q", got)
+	}
+
+	// 本文に目印の文字を含めてもメンションを偽装できない
+	got = string(RenderMessage("\uE0000\uE001 <script>", nil))
+	assertSafeHTML(t, got)
+	if strings.Contains(got, "/profile/") {
+		t.Errorf("偽装したメンションがリンクになっています: %q", got)
+	}
+}
@@ -0,0 +1,138 @@
+package markup
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// メッセージのHTMLで許可するタグと属性
+var allowedTags = map[string][]string{
+	"p":      nil,
+	"br":     nil,
+	"strong": nil,
+	"em":     nil,
+	"code":   {"class"},
+	"pre":    nil,
+	"ul":     nil,
+	"ol":     nil,
+	"li":     nil,
+	"a":      {"href", "class", "rel", "target"},
+}
+
+// 許可するclass属性の値
+var allowedClassPattern = regexp.MustCompile(`^(p-message__mention|language-[A-Za-z0-9_+-]+)$`)
+
+// SanitizeHTML 許可したタグと属性以外を取り除いたHTMLを返す
+// 許可していないタグは取り除き、中のテキストはエスケープして残す
+func SanitizeHTML(s string) string {
+	var b strings.Builder
+	var open []string // 出力した開始タグ（閉じタグの対応を取るため）
+	z := html.NewTokenizer(strings.NewReader(s))
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// 閉じられていないタグを閉じる
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+
+		case html.TextToken:
+			b.WriteString(html.EscapeString(string(z.Text())))
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			attrs, ok := allowedTags[token.Data]
+			if !ok {
+				continue
+			}
+			token.Attr = sanitizeAttrs(token.Data, token.Attr, attrs)
+			if token.Data == "br" {
+				b.WriteString("<br>")
+				continue
+			}
+			token.Type = html.StartTagToken
+			b.WriteString(token.String())
+			open = append(open, token.Data)
+
+		case html.EndTagToken:
+			token := z.Token()
+			if _, ok := allowedTags[token.Data]; !ok || token.Data == "br" {
+				continue
+			}
+			// 対応する開始タグがない閉じタグは出力しない
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != token.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+// 許可した属性のみを残す
+func sanitizeAttrs(tag string, attrs []html.Attribute, allowed []string) []html.Attribute {
+	var result []html.Attribute
+	for _, attr := range attrs {
+		if attr.Namespace != "" || !containsString(allowed, attr.Key) {
+			continue
+		}
+		switch attr.Key {
+		case "href":
+			if !isSafeURL(attr.Val) && !isProfileLink(attr.Val) {
+				continue
+			}
+		case "class":
+			if !allowedClassPattern.MatchString(attr.Val) {
+				continue
+			}
+		case "target":
+			if attr.Val != "_blank" {
+				continue
+			}
+		case "rel":
+			attr.Val = "noopener noreferrer nofollow"
+		}
+		result = append(result, attr)
+	}
+
+	// 別タブで開くリンクは必ずrelを付ける
+	hasTarget, hasRel := false, false
+	for _, attr := range result {
+		switch attr.Key {
+		case "target":
+			hasTarget = true
+		case "rel":
+			hasRel = true
+		}
+	}
+	if tag == "a" && hasTarget && !hasRel {
+		result = append(result, html.Attribute{Key: "rel", Val: "noopener noreferrer nofollow"})
+	}
+	return result
+}
+
+// メンションのリンク先（プロフィールページ）かどうか
+func isProfileLink(href string) bool {
+	id := strings.TrimPrefix(href, "/profile/")
+	return id != href && id != "" && !strings.ContainsAny(id, "/\\:?#")
+}
+
+// スライスに文字列が含まれるかどうか
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
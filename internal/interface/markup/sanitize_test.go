@@ -0,0 +1,81 @@
+package markup
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+// 出力されたHTMLに、許可していないタグ・イベントハンドラ・安全でないリンクが含まれていないことを確認する
+func assertSafeHTML(t *testing.T, out string) {
+	t.Helper()
+	z := html.NewTokenizer(strings.NewReader(out))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+		token := z.Token()
+		allowed, ok := allowedTags[token.Data]
+		if !ok {
+			t.Errorf("許可していないタグ <%s> が出力されました: %q", token.Data, out)
+			continue
+		}
+		for _, attr := range token.Attr {
+			if !containsString(allowed, attr.Key) {
+				t.Errorf("許可していない属性 %s が出力されました: %q", attr.Key, out)
+			}
+			if attr.Key == "href" && !isSafeURL(attr.Val) && !isProfileLink(attr.Val) {
+				t.Errorf("安全でないリンク %q が出力されました: %q", attr.Val, out)
+			}
+		}
+	}
+}
+
+func TestSanitizeHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string // 空の場合は安全であることだけを確認する
+	}{
+		{"scriptタグ", `<script>alert(1)</script>`, `alert(1)`},
+		{"大文字のscriptタグ", `<SCRIPT SRC="https://evil.example/x.js"></SCRIPT>`, ``},
+		{"閉じていないscriptタグ", `<script>alert(1)`, `alert(1)`},
+		{"javascriptリンク", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"大文字小文字が混在したjavascriptリンク", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{"文字参照でエンコードしたjavascriptリンク", `<a href="&#106;&#97;vascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"16進数の文字参照でエンコードしたjavascriptリンク", `<a href="&#x6A;avascript&#x3A;alert(1)">x</a>`, `<a>x</a>`},
+		{"タブを挟んだjavascriptリンク", `<a href="java&#x09;script:alert(1)">x</a>`, `<a>x</a>`},
+		{"先頭に空白があるjavascriptリンク", `<a href=" javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"dataリンク", `<a href="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">x</a>`, `<a>x</a>`},
+		{"大文字のdataリンク", `<a href="DATA:text/html,<script>alert(1)</script>">x</a>`, `<a>x</a>`},
+		{"vbscriptリンク", `<a href="vbscript:msgbox(1)">x</a>`, `<a>x</a>`},
+		{"onclick属性", `<a href="https://example.com" onclick="alert(1)">x</a>`, `<a href="https://example.com">x</a>`},
+		{"大文字のイベントハンドラ属性", `<p OnMouseOver="alert(1)">x</p>`, `<p>x</p>`},
+		{"imgのonerror", `<img src=x onerror="alert(1)">`, ``},
+		{"svgのonload", `<svg onload="alert(1)"></svg>`, ``},
+		{"iframe", `<iframe src="javascript:alert(1)"></iframe>`, ``},
+		{"style属性", `<p style="background:url(javascript:alert(1))">x</p>`, `<p>x</p>`},
+		{"許可していないclass", `<code class="x onerror">x</code>`, `<code>x</code>`},
+		{"target付きリンクにはrelを付ける", `<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com" target="_blank" rel="noopener noreferrer nofollow">x</a>`},
+		{"閉じていないタグは閉じる", `<strong>x`, `<strong>x</strong>`},
+		{"対応しない閉じタグは出力しない", `x</em>`, `x`},
+		{"テキストはエスケープする", `"><script>alert(1)</script>`, `&#34;&gt;alert(1)`},
+		{"プロフィールへのリンク", `<a href="/profile/u1">@me</a>`, `<a href="/profile/u1">@me</a>`},
+		{"プロフィールに見せかけたリンク", `<a href="/profile/../logout">x</a>`, `<a>x</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeHTML(tt.input)
+			assertSafeHTML(t, got)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("SanitizeHTML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package chat
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/utils/uuid"
+)
+
+// ボットがメッセージのイベントを処理できる時間
+const botEventTimeout = 10 * time.Second
+
+// ボットに通知するメッセージのイベント
+type MessageEvent struct {
+	ChatID     string             // メッセージが送信されたチャットのID
+	MessageID  string             // メッセージのID
+	SenderID   string             // 送信者のID
+	SenderName string             // 送信者の名前
+	Content    string             // メッセージの内容
+	Type       domain.MessageType // メッセージの種類
+	CreatedAt  time.Time          // 送信日時
+}
+
+// イベントを受け取ったボットがチャットに応答するための情報
+type BotContext struct {
+	context.Context
+	BotID  string // 応答するボットのID
+	ChatID string // イベントが発生したチャットのID
+}
+
+// イベントが発生したチャットにボットとしてメッセージを送信する
+func (c *BotContext) Post(content string) error {
+	_, err := PostAsBot(c.BotID, c.ChatID, content)
+	return err
+}
+
+// サーバー内で動作するボット
+// ボットが参加しているチャットで人が送信したメッセージごとにOnMessageが呼ばれる
+type BotHandler interface {
+	OnMessage(ctx *BotContext, event MessageEvent)
+}
+
+// 関数をBotHandlerとして使用する
+type BotHandlerFunc func(ctx *BotContext, event MessageEvent)
+
+func (f BotHandlerFunc) OnMessage(ctx *BotContext, event MessageEvent) {
+	f(ctx, event)
+}
+
+// サーバー内で動作するボットの登録先（キーはボットのID）
+var botHandlers = struct {
+	sync.RWMutex
+	m map[string]BotHandler
+}{m: make(map[string]BotHandler)}
+
+// サーバー内で動作するボットを登録する
+// botIDには設定画面で作成したボットのIDを指定する
+func RegisterBot(botID string, handler BotHandler) {
+	botHandlers.Lock()
+	defer botHandlers.Unlock()
+	botHandlers.m[botID] = handler
+}
+
+// サーバー内で動作するボットの登録を解除する
+func UnregisterBot(botID string) {
+	botHandlers.Lock()
+	defer botHandlers.Unlock()
+	delete(botHandlers.m, botID)
+}
+
+// ボットを作成し、APIトークンを返す
+// トークンはハッシュ値だけを保存するため、作成時にしか表示できない
+func CreateBot(owner *domain.User, name string) (*domain.Bot, string, error) {
+	name = strings.TrimSpace(name)
+	if err := domain.ValidateBotName(name); err != nil {
+		return nil, "", err
+	}
+	bots, err := firebase.GetBotsByOwner(owner.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(bots) >= domain.MaxBotsPerUser {
+		return nil, "", domain.ErrBotLimitExceeded
+	}
+
+	botID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := generateToken(domain.BotTokenPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	bot := &domain.Bot{
+		ID:        botID,
+		Name:      name,
+		OwnerID:   owner.ID,
+		TokenHash: hashToken(token),
+		CreatedAt: now,
+	}
+	user := domain.User{
+		ID:        botID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+		IsBot:     true,
+	}
+	if err := firebase.CreateBot(*bot, user); err != nil {
+		return nil, "", err
+	}
+	return bot, token, nil
+}
+
+// APIトークンからボットを認証する
+func AuthenticateBot(token string) (*domain.Bot, error) {
+	if !strings.HasPrefix(token, domain.BotTokenPrefix) {
+		return nil, domain.ErrInvalidBotToken
+	}
+	return firebase.GetBotByTokenHash(hashToken(token))
+}
+
+// ボットとしてチャットにメッセージを送信する
+// ボットはチャットに参加している必要がある
+func PostAsBot(botID string, chatID string, content string) (*OutgoingMessage, error) {
+	bot, err := firebase.GetBot(botID)
+	if err != nil {
+		return nil, err
+	}
+	bots, err := firebase.GetChatBots(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(bots, botID) {
+		return nil, domain.ErrBotNotInChat
+	}
+	participants, err := firebase.GetChatParticipants(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &OutgoingMessage{
+		ChatID:     chatID,
+		SenderID:   bot.ID,
+		SenderName: bot.Name,
+		OnBehalfOf: bot.OwnerID,
+		Content:    content,
+		// ボットも送信者として検証・メンションの対象にする
+		Participants: append(participants, bot.ID),
+	}
+	msg.Set("is_bot", true)
+	if err := SendMessage(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// 接頭辞を付けたランダムなトークンを生成する
+func generateToken(prefix string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// トークンのハッシュ値（保存・照合にはハッシュ値を使用する）
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// 文字列のスライスに指定した文字列が含まれるかどうか
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// 保存したメッセージをチャットに参加しているサーバー内のボットに通知する送信処理
+// ボットが送信したメッセージは、ボット同士の応答が続かないように通知しない
+type botEventProcessor struct{}
+
+func (botEventProcessor) Name() string { return ProcessorBotEvent }
+
+func (botEventProcessor) BeforeSave(msg *OutgoingMessage) error { return nil }
+
+func (botEventProcessor) AfterSave(msg *OutgoingMessage) {
+	if isBot, _ := msg.Fields["is_bot"].(bool); isBot {
+		return
+	}
+
+	botHandlers.RLock()
+	registered := len(botHandlers.m) > 0
+	botHandlers.RUnlock()
+	if !registered {
+		return
+	}
+
+	bots, err := firebase.GetChatBots(msg.ChatID)
+	if err != nil {
+		log.Printf("チャットのボットの取得に失敗: chatID=%s, error=%v", msg.ChatID, err)
+		return
+	}
+
+	event := MessageEvent{
+		ChatID:     msg.ChatID,
+		MessageID:  msg.ID,
+		SenderID:   msg.SenderID,
+		SenderName: msg.SenderName,
+		Content:    msg.Content,
+		Type:       msg.Type,
+		CreatedAt:  msg.CreatedAt,
+	}
+	for _, botID := range bots {
+		botHandlers.RLock()
+		handler, ok := botHandlers.m[botID]
+		botHandlers.RUnlock()
+		if !ok {
+			continue
+		}
+		go dispatchBotEvent(botID, handler, event)
+	}
+}
+
+// ボットにイベントを通知する（ボットの不具合で送信処理が止まらないように別のゴルーチンで実行する）
+func dispatchBotEvent(botID string, handler BotHandler, event MessageEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), botEventTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("ボットのイベント処理で異常終了: botID=%s, chatID=%s, error=%v", botID, event.ChatID, r)
+		}
+	}()
+	handler.OnMessage(&BotContext{Context: ctx, BotID: botID, ChatID: event.ChatID}, event)
+}
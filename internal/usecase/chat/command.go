@@ -0,0 +1,382 @@
+package chat
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+)
+
+// スラッシュコマンドの名前の形式（「/etc/hosts」のような本文はコマンドとして扱わない）
+var commandNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// スラッシュコマンドの実行時の情報
+type CommandContext struct {
+	Message  *OutgoingMessage // 送信しようとしたメッセージ（コマンドが書き換えることができる）
+	Args     string           // コマンド名より後の引数
+	Commands *CommandRegistry // コマンドを登録しているレジストリ
+	Now      time.Time        // 実行日時
+}
+
+// 送信者だけに表示する応答を設定し、メッセージを保存せずに処理を終える
+func (c *CommandContext) Reply(text string) {
+	c.Message.Handled = true
+	c.Message.Reply = text
+}
+
+// スラッシュコマンド
+// Executeでメッセージを書き換えた場合はそのまま送信され、Replyを呼んだ場合は保存されない
+type SlashCommand interface {
+	// コマンド名（先頭の「/」を除いた小文字の英数字）
+	Name() string
+	// /help に表示する説明
+	Description() string
+	// /help に表示する引数の書式
+	Usage() string
+	// コマンドを実行する。利用者に表示するエラーはRejectで返す
+	Execute(ctx *CommandContext) error
+}
+
+// スラッシュコマンドのレジストリ
+type CommandRegistry struct {
+	mu       sync.RWMutex
+	commands map[string]SlashCommand
+}
+
+// スラッシュコマンドのレジストリを生成する
+func NewCommandRegistry(commands ...SlashCommand) *CommandRegistry {
+	r := &CommandRegistry{commands: make(map[string]SlashCommand, len(commands))}
+	for _, cmd := range commands {
+		r.Register(cmd)
+	}
+	return r
+}
+
+// コマンドを登録する（同じ名前のコマンドは置き換える）
+func (r *CommandRegistry) Register(cmd SlashCommand) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands[cmd.Name()] = cmd
+}
+
+// 名前からコマンドを探す
+func (r *CommandRegistry) Lookup(name string) (SlashCommand, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cmd, ok := r.commands[name]
+	return cmd, ok
+}
+
+// 登録されているコマンド（名前順）
+func (r *CommandRegistry) Commands() []SlashCommand {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	commands := make([]SlashCommand, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Name() < commands[j].Name()
+	})
+	return commands
+}
+
+// メッセージの送信に使用するスラッシュコマンドのレジストリ
+var DefaultCommands = NewCommandRegistry(
+	helpCommand{},
+	shrugCommand{},
+	remindCommand{},
+	pollCommand{filter: DefaultContentFilter},
+)
+
+// 本文からコマンド名と引数を取り出す
+// コマンドでない場合はokがfalseになる
+func parseCommand(content string) (name string, args string, ok bool) {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "/") || strings.HasPrefix(trimmed, "//") {
+		return "", "", false
+	}
+	name, args, _ = strings.Cut(trimmed[1:], " ")
+	if i := strings.IndexAny(name, "\n\t"); i >= 0 {
+		args = name[i:] + " " + args
+		name = name[:i]
+	}
+	name = strings.ToLower(name)
+	if !commandNamePattern.MatchString(name) {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(args), true
+}
+
+// スラッシュコマンドを実行する送信処理
+// 「//」で始まるメッセージは先頭の「/」を1つ取り除いてそのまま送信する
+type commandProcessor struct {
+	commands *CommandRegistry
+}
+
+func (commandProcessor) Name() string { return ProcessorCommand }
+
+func (p commandProcessor) BeforeSave(msg *OutgoingMessage) error {
+	if !msg.AllowCommands || msg.IsEncrypted() {
+		return nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(msg.Content), "//") {
+		msg.Content = strings.Replace(msg.Content, "//", "/", 1)
+		return nil
+	}
+
+	name, args, ok := parseCommand(msg.Content)
+	if !ok {
+		return nil
+	}
+	cmd, found := p.commands.Lookup(name)
+	if !found {
+		return Reject(ProcessorCommand, domain.ErrUnknownCommand)
+	}
+	return cmd.Execute(&CommandContext{
+		Message:  msg,
+		Args:     args,
+		Commands: p.commands,
+		Now:      time.Now(),
+	})
+}
+
+func (commandProcessor) AfterSave(msg *OutgoingMessage) {}
+
+// /help 使えるコマンドの一覧を表示する
+type helpCommand struct{}
+
+func (helpCommand) Name() string {
+	return "help"
+}
+
+func (helpCommand) Description() string {
+	return "使えるコマンドの一覧を表示します"
+}
+
+func (helpCommand) Usage() string {
+	return ""
+}
+
+func (helpCommand) Execute(ctx *CommandContext) error {
+	var b strings.Builder
+	b.WriteString("使えるコマンド:")
+	for _, cmd := range ctx.Commands.Commands() {
+		b.WriteString("\n/" + cmd.Name())
+		if usage := cmd.Usage(); usage != "" {
+			b.WriteString(" " + usage)
+		}
+		b.WriteString(" — " + cmd.Description())
+	}
+	b.WriteString("\n「/」で始まる文章をそのまま送信するには「//」から入力してください")
+	ctx.Reply(b.String())
+	return nil
+}
+
+// /shrug メッセージの末尾に ¯\_(ツ)_/¯ を付けて送信する
+type shrugCommand struct{}
+
+const shrug = `¯\_(ツ)_/¯`
+
+func (shrugCommand) Name() string {
+	return "shrug"
+}
+
+func (shrugCommand) Description() string {
+	return "メッセージの末尾に " + shrug + " を付けて送信します"
+}
+
+func (shrugCommand) Usage() string {
+	return "[メッセージ]"
+}
+
+func (shrugCommand) Execute(ctx *CommandContext) error {
+	if ctx.Args == "" {
+		ctx.Message.Content = shrug
+	} else {
+		ctx.Message.Content = ctx.Args + " " + shrug
+	}
+	return nil
+}
+
+// /remind 指定した時間の後にこのチャットへリマインダーを送信する
+type remindCommand struct{}
+
+func (remindCommand) Name() string {
+	return "remind"
+}
+
+func (remindCommand) Description() string {
+	return "指定した時間の後にこのチャットへリマインダーを送信します"
+}
+
+func (remindCommand) Usage() string {
+	return "<30m|2h|1d|15:04> 内容"
+}
+
+func (remindCommand) Execute(ctx *CommandContext) error {
+	when, text, _ := strings.Cut(ctx.Args, " ")
+	text = strings.TrimSpace(text)
+	// 「15:04」の形式の時刻は送信者のタイムゾーン（おやすみモードの設定）で解釈する
+	loc := senderLocation(ctx.Message.SenderID)
+	sendAt, ok := parseRemindTime(when, ctx.Now.In(loc))
+	if !ok || text == "" {
+		return Reject(ProcessorCommand, domain.ErrReminderUsage)
+	}
+
+	msg := ctx.Message
+	content := "リマインダー: " + text
+	if err := domain.ValidateScheduledMessage(content, sendAt, ctx.Now); err != nil {
+		return Reject(ProcessorCommand, err)
+	}
+	scheduleID, err := firebase.AddScheduledMessage(map[string]interface{}{
+		"chat_id":     msg.ChatID,
+		"sender_id":   msg.SenderID,
+		"sender_name": msg.SenderName,
+		"content":     content,
+		"send_at":     sendAt,
+		"status":      string(domain.ScheduleStatusPending),
+		"created_at":  ctx.Now,
+		"updated_at":  ctx.Now,
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("リマインダーを登録しました: chatID=%s, scheduleID=%s", msg.ChatID, scheduleID)
+
+	ctx.Reply(fmt.Sprintf("%s（%s）にリマインダーを送信します（予約メッセージから取り消せます）", sendAt.Format("1月2日 15:04"), loc))
+	return nil
+}
+
+// 送信者のタイムゾーン（取得できない場合はデフォルトのタイムゾーン）
+func senderLocation(userID string) *time.Location {
+	user, err := repository.GetUserByID(userID)
+	if err != nil || user == nil {
+		return domain.DNDSchedule{}.Location()
+	}
+	return user.DNDSchedule.Location()
+}
+
+// リマインダーの送信日時を解釈する
+// 「30m」「2h」「1d」は現在からの時間、「15:04」はnowのタイムゾーンで次にその時刻になる日時
+func parseRemindTime(s string, now time.Time) (time.Time, bool) {
+	if clock, err := time.ParseInLocation("15:04", s, now.Location()); err == nil {
+		t := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if !t.After(now) {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, true
+	}
+
+	if len(s) < 2 {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if err != nil || n <= 0 {
+		return time.Time{}, false
+	}
+	switch s[len(s)-1] {
+	case 'm':
+		return now.Add(time.Duration(n) * time.Minute), true
+	case 'h':
+		return now.Add(time.Duration(n) * time.Hour), true
+	case 'd':
+		return now.AddDate(0, 0, n), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// /poll 投票を作成する
+// 選択肢は本文に含まれないため、質問と選択肢にはここでコンテンツフィルターを適用する
+type pollCommand struct {
+	filter *ContentFilter
+}
+
+func (pollCommand) Name() string {
+	return "poll"
+}
+
+func (pollCommand) Description() string {
+	return "投票を作成します（選択肢は2〜10個）"
+}
+
+func (pollCommand) Usage() string {
+	return `"質問" "選択肢1" "選択肢2" ...`
+}
+
+func (c pollCommand) Execute(ctx *CommandContext) error {
+	args := splitQuotedArgs(ctx.Args)
+	if len(args) == 0 {
+		return Reject(ProcessorCommand, domain.ErrPollUsage)
+	}
+	for i, arg := range args {
+		result := c.filter.Apply(arg)
+		if result.Rejected {
+			return Reject(ProcessorContentFilter, domain.ErrContentRejected)
+		}
+		args[i] = result.Content
+		flagFilteredMessage(ctx.Message, result)
+	}
+	poll, err := domain.NewPoll(args[0], args[1:])
+	if err != nil {
+		return Reject(ProcessorCommand, err)
+	}
+
+	ctx.Message.Type = domain.MessageTypePoll
+	ctx.Message.Content = poll.Question
+	ctx.Message.Set("poll", firebase.PollToData(*poll))
+	return nil
+}
+
+// 引用符で囲まれた部分を1つの引数として、空白で引数を区切る
+// 「"」のほか「“”」「「」」も引用符として扱い、引用符と前後の文字を続けて書いた場合（「「A」「B」」など）も別の引数にする
+func splitQuotedArgs(s string) []string {
+	closing := map[rune]rune{'"': '"', '“': '”', '「': '」'}
+
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				args = append(args, current.String())
+				current.Reset()
+				quote = 0
+				inArg = false
+				continue
+			}
+			current.WriteRune(r)
+		case closing[r] != 0:
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+			}
+			quote = closing[r]
+			inArg = true
+		case r == ' ' || r == '　' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args
+}
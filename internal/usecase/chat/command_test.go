@@ -0,0 +1,149 @@
+package chat
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"security_chat_app/internal/domain"
+)
+
+func TestSplitQuotedArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{"空", "", nil},
+		{"空白だけ", " 　\t", nil},
+		{"引用符なし", "a b  c", []string{"a", "b", "c"}},
+		{"半角の引用符", `"好きな 果物は" "りんご" "みかん"`, []string{"好きな 果物は", "りんご", "みかん"}},
+		{"全角の引用符", "“好きな 果物は” “りんご” “みかん”", []string{"好きな 果物は", "りんご", "みかん"}},
+		{"かぎ括弧", "「好きな　果物は」「りんご」「みかん」", []string{"好きな　果物は", "りんご", "みかん"}},
+		{"引用符の混在", `「質問」 "A" “B” C`, []string{"質問", "A", "B", "C"}},
+		{"全角の空白で区切る", "りんご　みかん", []string{"りんご", "みかん"}},
+		{"改行で区切る", "りんご\nみかん", []string{"りんご", "みかん"}},
+		{"引用符の中の別の引用符", `「"A"と“B”」`, []string{`"A"と“B”`}},
+		{"引用符に続く文字は別の引数", `"A"B C`, []string{"A", "B", "C"}},
+		{"文字に続く引用符", `A"B C"`, []string{"A", "B C"}},
+		{"空の引用符", `"" "A"`, []string{"", "A"}},
+		{"閉じていない引用符", `"A B`, []string{"A B"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitQuotedArgs(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitQuotedArgs(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRemindTime(t *testing.T) {
+	jst := time.FixedZone("JST", 9*60*60)
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("タイムゾーンのデータがありません: %v", err)
+	}
+	// 2026-10-19 12:00 UTC（日本時間では21:00）
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		in   string
+		now  time.Time
+		want time.Time
+		ok   bool
+	}{
+		{"分", "30m", now, now.Add(30 * time.Minute), true},
+		{"時間", "2h", now, now.Add(2 * time.Hour), true},
+		{"日", "1d", now, now.AddDate(0, 0, 1), true},
+		{"UTCの今日の時刻", "15:00", now, time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC), true},
+		{"日本時間では過ぎた時刻は翌日", "15:00", now.In(jst), time.Date(2026, 10, 20, 15, 0, 0, 0, jst), true},
+		{"日本時間の今日の時刻", "23:30", now.In(jst), time.Date(2026, 10, 19, 23, 30, 0, 0, jst), true},
+		{"現在と同じ時刻は翌日", "21:00", now.In(jst), time.Date(2026, 10, 20, 21, 0, 0, 0, jst), true},
+		{"日付が変わる前の時刻", "00:15", time.Date(2026, 10, 19, 23, 50, 0, 0, jst), time.Date(2026, 10, 20, 0, 15, 0, 0, jst), true},
+		// 夏時間が終わる日をまたいでも、時刻は送信者のタイムゾーンの時刻で解釈する
+		{"夏時間の終わりをまたぐ時刻", "09:00", time.Date(2026, 10, 31, 22, 0, 0, 0, newYork), time.Date(2026, 11, 1, 9, 0, 0, 0, newYork), true},
+		{"夏時間の終わりをまたぐ日", "1d", time.Date(2026, 10, 31, 22, 0, 0, 0, newYork), time.Date(2026, 11, 1, 22, 0, 0, 0, newYork), true},
+		{"不正な時刻", "25:00", now, time.Time{}, false},
+		{"0分", "0m", now, time.Time{}, false},
+		{"負の時間", "-1h", now, time.Time{}, false},
+		{"不明な単位", "3w", now, time.Time{}, false},
+		{"単位なし", "30", now, time.Time{}, false},
+		{"空", "", now, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRemindTime(tt.in, tt.now)
+			if ok != tt.ok || !got.Equal(tt.want) {
+				t.Errorf("parseRemindTime(%q, %v) = %v, %v, want %v, %v", tt.in, tt.now, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPollCommandContentFilter(t *testing.T) {
+	reject := wordRule("すぱむ")
+	reject.ID, reject.Action = "reject", domain.FilterActionReject
+	flag := wordRule("あやしい")
+	flag.ID, flag.Action = "flag", domain.FilterActionFlag
+	f := NewContentFilter(0)
+	f.SetRules([]domain.FilterRule{wordRule("ばか"), reject, flag})
+	cmd := pollCommand{filter: f}
+
+	t.Run("選択肢を伏せ字にする", func(t *testing.T) {
+		msg := &OutgoingMessage{}
+		if err := cmd.Execute(&CommandContext{Message: msg, Args: `"ばかな質問" "りんご" "バカ"`}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if msg.Type != domain.MessageTypePoll || msg.Content != "**な質問" {
+			t.Errorf("Type = %q, Content = %q", msg.Type, msg.Content)
+		}
+		poll, _ := msg.Fields["poll"].(map[string]interface{})
+		options, _ := poll["options"].([]map[string]interface{})
+		var texts []string
+		for _, option := range options {
+			texts = append(texts, option["text"].(string))
+		}
+		if strings.Join(texts, ",") != "りんご,**" || poll["question"] != "**な質問" {
+			t.Errorf("poll = %v", poll)
+		}
+		if _, flagged := msg.Fields["flagged"]; flagged {
+			t.Errorf("flagged = %v, want unset", msg.Fields["flagged"])
+		}
+	})
+
+	t.Run("選択肢で拒否する", func(t *testing.T) {
+		msg := &OutgoingMessage{}
+		err := cmd.Execute(&CommandContext{Message: msg, Args: `"質問" "りんご" "スパム"`})
+		var rejected *domain.MessageRejectedError
+		if !errors.As(err, &rejected) || rejected.Processor != ProcessorContentFilter || !errors.Is(err, domain.ErrContentRejected) {
+			t.Errorf("Execute() error = %v, want rejected by %s", err, ProcessorContentFilter)
+		}
+		if _, ok := msg.Fields["poll"]; ok {
+			t.Error("拒否した投票が設定されています")
+		}
+	})
+
+	t.Run("選択肢で報告する", func(t *testing.T) {
+		msg := &OutgoingMessage{}
+		if err := cmd.Execute(&CommandContext{Message: msg, Args: `"質問" "りんご" "アヤシイ"`}); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		// 本文に適用するフィルターで報告するルールが重複しないこと
+		if err := (contentFilterProcessor{filter: f}).BeforeSave(msg); err != nil {
+			t.Fatalf("BeforeSave() error = %v", err)
+		}
+		if flagged, _ := msg.Fields["flagged"].(bool); !flagged {
+			t.Errorf("flagged = %v, want true", msg.Fields["flagged"])
+		}
+		if ids, _ := msg.Fields["filter_rule_ids"].([]string); strings.Join(ids, ",") != "flag" {
+			t.Errorf("filter_rule_ids = %v, want [flag]", ids)
+		}
+		if got, want := filterSnapshot(msg), "質問\n・りんご\n・アヤシイ"; got != want {
+			t.Errorf("filterSnapshot() = %q, want %q", got, want)
+		}
+	})
+}
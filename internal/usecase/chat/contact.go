@@ -0,0 +1,185 @@
+package chat
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/usecase/notification"
+)
+
+// 連絡先の申請を送信する
+// 相手から既に申請を受け取っている場合は、その申請を承認して連絡先に追加する（acceptedがtrue）
+func SendContactRequest(user *domain.User, targetID string) (accepted bool, err error) {
+	if targetID == user.ID {
+		return false, domain.ErrCannotAddSelfContact
+	}
+	target, err := firebase.GetData("users", targetID)
+	if err != nil {
+		return false, domain.ErrContactRequestUnavailable
+	}
+	// ボットと、自分をブロックしているユーザーには申請できない
+	if isBot, _ := target["IsBot"].(bool); isBot {
+		return false, domain.ErrContactRequestUnavailable
+	}
+	blocked, err := firebase.IsBlocked(targetID, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, domain.ErrContactRequestUnavailable
+	}
+
+	isContact, err := firebase.IsContact(user.ID, targetID)
+	if err != nil {
+		return false, err
+	}
+	if isContact {
+		return false, domain.ErrAlreadyContact
+	}
+	if _, err := firebase.GetContactRequest(user.ID, targetID); err == nil {
+		return false, domain.ErrContactRequestExists
+	}
+
+	// 相手からの申請がある場合は承認する
+	if _, err := firebase.GetContactRequest(targetID, user.ID); err == nil {
+		if err := AcceptContactRequest(user, targetID); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	sent, err := firebase.GetContactRequestsFrom(user.ID)
+	if err != nil {
+		return false, err
+	}
+	if len(sent) >= domain.MaxPendingContactRequests {
+		return false, domain.ErrContactRequestLimitExceeded
+	}
+
+	targetName, _ := target["Name"].(string)
+	err = firebase.AddContactRequest(domain.ContactRequest{
+		FromID:    user.ID,
+		FromName:  user.Name,
+		ToID:      targetID,
+		ToName:    targetName,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	notifyContactRequest(targetID, fmt.Sprintf("%sさんから連絡先の申請が届きました", user.Name))
+	return false, nil
+}
+
+// 受け取った連絡先の申請を承認する
+func AcceptContactRequest(user *domain.User, fromID string) error {
+	if err := firebase.AcceptContactRequest(fromID, user.ID); err != nil {
+		return err
+	}
+	notifyContactRequest(fromID, fmt.Sprintf("%sさんが連絡先の申請を承認しました", user.Name))
+	return nil
+}
+
+// 受け取った連絡先の申請を拒否する（申請したユーザーには通知しない）
+func DeclineContactRequest(user *domain.User, fromID string) error {
+	if _, err := firebase.GetContactRequest(fromID, user.ID); err != nil {
+		return err
+	}
+	return firebase.DeleteContactRequest(fromID, user.ID)
+}
+
+// 送信した連絡先の申請を取り消す
+func CancelContactRequest(user *domain.User, toID string) error {
+	if _, err := firebase.GetContactRequest(user.ID, toID); err != nil {
+		return err
+	}
+	return firebase.DeleteContactRequest(user.ID, toID)
+}
+
+// 連絡先から削除する（相手の連絡先からも削除される）
+func RemoveContact(user *domain.User, contactID string) error {
+	isContact, err := firebase.IsContact(user.ID, contactID)
+	if err != nil {
+		return err
+	}
+	if !isContact {
+		return domain.ErrNotContact
+	}
+	return firebase.DeleteContact(user.ID, contactID)
+}
+
+// ブロックしたユーザーとの連絡先と、双方向の申請を削除する
+func ClearContact(userID string, otherID string) {
+	if err := firebase.DeleteContact(userID, otherID); err != nil {
+		log.Printf("連絡先の削除に失敗: userID=%s, contactID=%s, error=%v", userID, otherID, err)
+	}
+	for _, pair := range [][2]string{{userID, otherID}, {otherID, userID}} {
+		if err := firebase.DeleteContactRequest(pair[0], pair[1]); err != nil {
+			log.Printf("連絡先の申請の削除に失敗: fromID=%s, toID=%s, error=%v", pair[0], pair[1], err)
+		}
+	}
+}
+
+// ログインユーザーから見た各ユーザーとの連絡先の関係を取得する（連絡先ではないユーザーは含まない）
+func GetContactStatuses(userID string) (map[string]domain.ContactStatus, error) {
+	statuses := make(map[string]domain.ContactStatus)
+
+	received, err := firebase.GetContactRequestsTo(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range received {
+		statuses[req.FromID] = domain.ContactStatusReceived
+	}
+	sent, err := firebase.GetContactRequestsFrom(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range sent {
+		statuses[req.ToID] = domain.ContactStatusSent
+	}
+	contactIDs, err := firebase.GetContactIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range contactIDs {
+		statuses[id] = domain.ContactStatusContact
+	}
+	return statuses, nil
+}
+
+// 相手がチャットの開始を受け付けるかどうかを確認する
+// 連絡先からのチャットのみ受け付ける設定の場合、連絡先ではないユーザーはdomain.ErrContactsOnlyになる
+func CheckChatAllowed(userID string, targetID string) error {
+	target, err := firebase.GetData("users", targetID)
+	if err != nil {
+		return err
+	}
+	if contactsOnly, _ := target["ContactsOnly"].(bool); !contactsOnly {
+		return nil
+	}
+	isContact, err := firebase.IsContact(targetID, userID)
+	if err != nil {
+		return err
+	}
+	if !isContact {
+		return domain.ErrContactsOnly
+	}
+	return nil
+}
+
+// 連絡先の申請に関するプッシュ通知を送信する
+func notifyContactRequest(userID string, title string) {
+	if notifier := DefaultPushNotifier; notifier != nil {
+		go notifier.NotifyUser(userID, notification.PushPayload{
+			Type:  domain.PushNotificationContactRequest,
+			Title: title,
+			URL:   "/contacts",
+			Tag:   "contacts",
+		})
+	}
+}
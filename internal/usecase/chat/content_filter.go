@@ -0,0 +1,407 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// 伏せ字に使用する文字
+const filterMaskRune = '*'
+
+// 照合用に変換したルール
+type compiledFilterRule struct {
+	rule  domain.FilterRule
+	word  []rune         // 語句のルールの場合の正規化した語句
+	regex *regexp.Regexp // 正規表現のルールの場合のパターン
+}
+
+// コンテンツフィルターの適用結果
+type FilterResult struct {
+	Content  string              // 伏せ字にした後の内容
+	Rejected bool                // 送信を拒否するルールに一致したかどうか
+	Matched  []domain.FilterRule // 一致したルール
+}
+
+// 管理者に報告するルールに一致したかどうか
+func (r FilterResult) Flagged() bool {
+	for _, rule := range r.Matched {
+		if rule.Action == domain.FilterActionFlag {
+			return true
+		}
+	}
+	return false
+}
+
+// データベースのルールでメッセージを検査するコンテンツフィルター
+// ルールは定期的に読み込み直すため、変更はサーバーを再起動せずに反映される
+type ContentFilter struct {
+	interval time.Duration // ルールを読み込み直す間隔
+
+	mu    sync.RWMutex
+	rules []compiledFilterRule
+}
+
+// コンテンツフィルターを生成する
+func NewContentFilter(interval time.Duration) *ContentFilter {
+	return &ContentFilter{interval: interval}
+}
+
+// メッセージの送信に使用するコンテンツフィルター
+var DefaultContentFilter = NewContentFilter(30 * time.Second)
+
+// Start ctxがキャンセルされるまで、ルールを定期的に読み込み直す
+func (f *ContentFilter) Start(ctx context.Context) {
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+
+	if err := f.Reload(); err != nil {
+		log.Printf("フィルターのルールの読み込みに失敗: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("コンテンツフィルターの更新を停止します")
+			return
+		case <-ticker.C:
+			if err := f.Reload(); err != nil {
+				log.Printf("フィルターのルールの読み込みに失敗: %v", err)
+			}
+		}
+	}
+}
+
+// データベースからルールを読み込み直す
+// 読み込みに失敗した場合は、それまでのルールを使い続ける
+func (f *ContentFilter) Reload() error {
+	rules, err := firebase.GetFilterRules()
+	if err != nil {
+		return err
+	}
+	f.SetRules(rules)
+	return nil
+}
+
+// 有効なルールを照合用に変換して置き換える
+func (f *ContentFilter) SetRules(rules []domain.FilterRule) {
+	compiled := make([]compiledFilterRule, 0, len(rules))
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		c := compiledFilterRule{rule: rule}
+		switch rule.MatchType {
+		case domain.FilterMatchWord:
+			c.word, _, _ = normalizeForFilter(rule.Pattern)
+			if len(c.word) == 0 {
+				continue
+			}
+		case domain.FilterMatchRegex:
+			re, err := compileFilterPattern(rule.Pattern)
+			if err != nil {
+				log.Printf("フィルターの正規表現が不正です: ruleID=%s, error=%v", rule.ID, err)
+				continue
+			}
+			c.regex = re
+		default:
+			continue
+		}
+		compiled = append(compiled, c)
+	}
+
+	f.mu.Lock()
+	f.rules = compiled
+	f.mu.Unlock()
+}
+
+// メッセージの内容にルールを適用する
+func (f *ContentFilter) Apply(content string) FilterResult {
+	f.mu.RLock()
+	rules := f.rules
+	f.mu.RUnlock()
+
+	result := FilterResult{Content: content}
+	if len(rules) == 0 || content == "" {
+		return result
+	}
+
+	normalized, starts, ends := normalizeForFilter(content)
+	mask := make([]bool, utf8.RuneCountInString(content))
+
+	for _, c := range rules {
+		ranges := c.find(normalized)
+		if len(ranges) == 0 {
+			continue
+		}
+		result.Matched = append(result.Matched, c.rule)
+
+		switch c.rule.Action {
+		case domain.FilterActionReject:
+			result.Rejected = true
+		case domain.FilterActionMask:
+			// 正規化後の位置を元の文字の位置に戻して伏せ字にする
+			for _, r := range ranges {
+				for i := starts[r[0]]; i < ends[r[1]-1]; i++ {
+					mask[i] = true
+				}
+			}
+		}
+	}
+
+	if result.Rejected {
+		return result
+	}
+
+	var b strings.Builder
+	i := 0
+	for _, r := range content {
+		if mask[i] && !unicode.IsSpace(r) {
+			b.WriteRune(filterMaskRune)
+		} else {
+			b.WriteRune(r)
+		}
+		i++
+	}
+	result.Content = b.String()
+	return result
+}
+
+// 指定したIDのルールのパターンを返す
+func (f *ContentFilter) patterns(ruleIDs []string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	var patterns []string
+	for _, id := range ruleIDs {
+		for _, c := range f.rules {
+			if c.rule.ID == id {
+				patterns = append(patterns, c.rule.Pattern)
+				break
+			}
+		}
+	}
+	return patterns
+}
+
+// 正規化した本文の中でルールに一致する範囲（文字の位置）を返す
+func (c compiledFilterRule) find(text []rune) [][2]int {
+	var ranges [][2]int
+	if c.regex != nil {
+		s := string(text)
+		// バイトの位置を文字の位置に変換する
+		runeIndex := make([]int, len(s)+1)
+		n := 0
+		for i := range s {
+			runeIndex[i] = n
+			n++
+		}
+		runeIndex[len(s)] = n
+
+		for _, loc := range c.regex.FindAllStringIndex(s, -1) {
+			if loc[0] == loc[1] {
+				continue
+			}
+			ranges = append(ranges, [2]int{runeIndex[loc[0]], runeIndex[loc[1]]})
+		}
+		return ranges
+	}
+
+	for i := 0; i+len(c.word) <= len(text); {
+		if runesEqual(text[i:i+len(c.word)], c.word) {
+			ranges = append(ranges, [2]int{i, i + len(c.word)})
+			i += len(c.word)
+			continue
+		}
+		i++
+	}
+	return ranges
+}
+
+// 照合用に文字列を正規化する
+// 全角英数字・記号を半角に、半角カタカナを全角に揃えた上で（NFKC）、英字を小文字に、カタカナをひらがなに変換する
+// 伏せ字にする位置を元に戻せるように、正規化後の各文字に対応する元の文字の範囲も返す
+func normalizeForFilter(s string) (normalized []rune, starts []int, ends []int) {
+	i := 0
+	for _, r := range s {
+		for _, c := range norm.NFKC.String(string(r)) {
+			c = foldForFilter(c)
+
+			// 半角カタカナの濁点・半濁点は直前の文字と合成する（ｶﾞ → が）
+			if (c == '\u3099' || c == '\u309A') && len(normalized) > 0 {
+				last := len(normalized) - 1
+				composed := []rune(norm.NFC.String(string([]rune{normalized[last], c})))
+				if len(composed) == 1 {
+					normalized[last] = composed[0]
+					ends[last] = i + 1
+					continue
+				}
+			}
+
+			normalized = append(normalized, c)
+			starts = append(starts, i)
+			ends = append(ends, i+1)
+		}
+		i++
+	}
+	return normalized, starts, ends
+}
+
+// 大文字・小文字とカタカナ・ひらがなの違いをなくす
+func foldForFilter(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - ('ァ' - 'ぁ')
+	}
+	return unicode.ToLower(r)
+}
+
+// 正規表現のパターンを正規化した本文と照合できるように変換してコンパイルする
+// 本文は正規化してから照合するため、パターンの文字と文字クラスも同じように正規化し、大文字・小文字は区別しない
+func compileFilterPattern(pattern string) (*regexp.Regexp, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl|syntax.FoldCase)
+	if err != nil {
+		return nil, err
+	}
+	foldFilterRegexp(re)
+	return regexp.Compile(re.String())
+}
+
+// 正規表現の構文木の文字と文字クラスを正規化する
+func foldFilterRegexp(re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		re.Rune, _, _ = normalizeForFilter(string(re.Rune))
+	case syntax.OpCharClass:
+		// 全角英数字・記号と（半角の）カタカナを含む範囲には、正規化した後の文字を追加する
+		ranges := re.Rune
+		for i := 0; i+1 < len(ranges); i += 2 {
+			for _, span := range [][2]rune{{'！', '～'}, {'ァ', 'ヶ'}, {'ｦ', 'ﾝ'}} {
+				lo, hi := max(ranges[i], span[0]), min(ranges[i+1], span[1])
+				for r := lo; r <= hi; r++ {
+					if folded, _, _ := normalizeForFilter(string(r)); len(folded) == 1 && folded[0] != r {
+						re.Rune = append(re.Rune, folded[0], folded[0])
+					}
+				}
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		foldFilterRegexp(sub)
+	}
+}
+
+// 2つの文字列（rune）が等しいかどうか
+func runesEqual(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// コンテンツフィルターを適用する送信処理
+// 伏せ字にするルールは本文を書き換え、拒否するルールは送信を拒否し、報告するルールは保存後に通報として管理者に報告する
+// 暗号化されたメッセージは本文を読めないため対象外
+type contentFilterProcessor struct {
+	filter *ContentFilter
+}
+
+func (contentFilterProcessor) Name() string { return ProcessorContentFilter }
+
+func (p contentFilterProcessor) BeforeSave(msg *OutgoingMessage) error {
+	if msg.IsEncrypted() {
+		return nil
+	}
+	result := p.filter.Apply(msg.Content)
+	if result.Rejected {
+		return Reject(ProcessorContentFilter, domain.ErrContentRejected)
+	}
+	msg.Content = result.Content
+	flagFilteredMessage(msg, result)
+	return nil
+}
+
+// 報告するルールに一致した場合は、保存後に管理者に報告するようにメッセージに記録する
+// 投票の選択肢など本文以外で一致したルールもまとめて報告できるように、記録済みのルールに追加する
+func flagFilteredMessage(msg *OutgoingMessage, result FilterResult) {
+	if !result.Flagged() {
+		return
+	}
+	ruleIDs, _ := msg.Fields["filter_rule_ids"].([]string)
+	for _, rule := range result.Matched {
+		if rule.Action == domain.FilterActionFlag && !containsString(ruleIDs, rule.ID) {
+			ruleIDs = append(ruleIDs, rule.ID)
+		}
+	}
+	msg.Set("flagged", true)
+	msg.Set("filter_rule_ids", ruleIDs)
+}
+
+// 報告に保存するメッセージの内容（投票の場合は選択肢も含める）
+func filterSnapshot(msg *OutgoingMessage) string {
+	poll, _ := msg.Fields["poll"].(map[string]interface{})
+	options, _ := poll["options"].([]map[string]interface{})
+	if len(options) == 0 {
+		return msg.Content
+	}
+	lines := []string{msg.Content}
+	for _, option := range options {
+		text, _ := option["text"].(string)
+		lines = append(lines, "・"+text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p contentFilterProcessor) AfterSave(msg *OutgoingMessage) {
+	if flagged, _ := msg.Fields["flagged"].(bool); !flagged {
+		return
+	}
+
+	ruleIDs, _ := msg.Fields["filter_rule_ids"].([]string)
+	report := domain.Report{
+		TargetType:       domain.ReportTargetMessage,
+		ReporterName:     "コンテンツフィルター",
+		ReportedUserID:   msg.SenderID,
+		ReportedUserName: msg.SenderName,
+		ChatID:           msg.ChatID,
+		MessageID:        msg.ID,
+		Reason:           domain.ReportReasonContentFilter,
+		Comment:          "一致したルール: " + strings.Join(p.filter.patterns(ruleIDs), ", "),
+		Snapshot:         filterSnapshot(msg),
+		CreatedAt:        time.Now(),
+	}
+	reportID, err := firebase.AddReport(report)
+	if err != nil {
+		log.Printf("フィルターによる報告に失敗: chatID=%s, messageID=%s, error=%v", msg.ChatID, msg.ID, err)
+		return
+	}
+
+	err = firebase.AddAuditLog(domain.AuditLog{
+		Action:       domain.AuditReportCreated,
+		ActorName:    report.ReporterName,
+		ReportID:     reportID,
+		TargetUserID: msg.SenderID,
+		ChatID:       msg.ChatID,
+		MessageID:    msg.ID,
+		Detail:       report.Reason.Label(),
+		CreatedAt:    report.CreatedAt,
+	})
+	if err != nil {
+		log.Printf("監査ログの保存に失敗: reportID=%s, error=%v", reportID, err)
+	}
+}
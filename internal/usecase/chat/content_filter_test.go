@@ -0,0 +1,135 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"security_chat_app/internal/domain"
+)
+
+func TestContentFilterApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    domain.FilterRule
+		content string
+		want    string // 伏せ字にした後の内容
+		matched bool
+	}{
+		{"語句: 一致しない", wordRule("ばか"), "こんにちは", "こんにちは", false},
+		{"語句: ひらがな", wordRule("ばか"), "ばかです", "**です", true},
+		{"語句: カタカナの本文", wordRule("ばか"), "バカです", "**です", true},
+		{"語句: カタカナの語句", wordRule("バカ"), "ばかです", "**です", true},
+		{"語句: 半角カタカナの濁点", wordRule("ばか"), "ﾊﾞｶです", "***です", true},
+		{"語句: 全角英字", wordRule("spam"), "ＳＰＡＭです", "****です", true},
+		{"語句: 全角英字の語句", wordRule("ＳＰＡＭ"), "Spamです", "****です", true},
+		{"語句: 複数の一致", wordRule("ばか"), "ばか、バカ", "**、**", true},
+		{"語句: 空白は伏せ字にしない", wordRule("a b"), "x a b y", "x * * y", true},
+		{"語句: 前後の文字の位置", wordRule("ばか"), "あなたはﾊﾞｶだ", "あなたは***だ", true},
+		{"正規表現: ひらがな", regexRule("ば+か"), "ばばかです", "***です", true},
+		{"正規表現: カタカナのパターン", regexRule("バカ"), "ばかです", "**です", true},
+		{"正規表現: カタカナの本文", regexRule("ばか"), "バカです", "**です", true},
+		{"正規表現: 半角カタカナのパターン", regexRule("ﾊﾞｶ"), "バカです", "**です", true},
+		{"正規表現: 半角カタカナの本文", regexRule("バ+カ"), "ﾊﾞﾊﾞｶです", "*****です", true},
+		{"正規表現: 全角英字のパターン", regexRule("ＳＰＡＭ+"), "spammです", "*****です", true},
+		{"正規表現: 全角英字の本文", regexRule("spam"), "ＳＰＡＭです", "****です", true},
+		{"正規表現: 大文字のパターン", regexRule("SPAM"), "spamです", "****です", true},
+		{"正規表現: カタカナの文字クラス", regexRule("[ア-オ]{2}"), "あいです", "**です", true},
+		{"正規表現: 全角数字の文字クラス", regexRule("[０-９]{3}"), "123です", "***です", true},
+		{"正規表現: 半角数字の文字クラス", regexRule(`\d{3}`), "１２３です", "***です", true},
+		{"正規表現: 一致しない", regexRule("バカ"), "ばらです", "ばらです", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewContentFilter(0)
+			f.SetRules([]domain.FilterRule{tt.rule})
+
+			result := f.Apply(tt.content)
+			if result.Content != tt.want {
+				t.Errorf("Apply(%q).Content = %q, want %q", tt.content, result.Content, tt.want)
+			}
+			if got := len(result.Matched) > 0; got != tt.matched {
+				t.Errorf("Apply(%q).Matched = %v, want matched %v", tt.content, result.Matched, tt.matched)
+			}
+			if result.Rejected || result.Flagged() {
+				t.Errorf("Apply(%q) = %+v, 伏せ字にするルールで拒否・報告されています", tt.content, result)
+			}
+		})
+	}
+}
+
+func TestContentFilterActions(t *testing.T) {
+	mask := wordRule("ばか")
+	reject := regexRule("スパム")
+	reject.ID, reject.Action = "reject", domain.FilterActionReject
+	flag := wordRule("ｱﾔｼｲ")
+	flag.ID, flag.Action = "flag", domain.FilterActionFlag
+	disabled := wordRule("こんにちは")
+	disabled.ID, disabled.Enabled = "disabled", false
+
+	f := NewContentFilter(0)
+	f.SetRules([]domain.FilterRule{mask, reject, flag, disabled})
+
+	tests := []struct {
+		name         string
+		content      string
+		want         string
+		wantRejected bool
+		wantFlagged  bool
+	}{
+		{"一致しない", "こんにちは", "こんにちは", false, false},
+		{"伏せ字", "バカ", "**", false, false},
+		{"拒否", "すぱむです", "すぱむです", true, false},
+		{"拒否は伏せ字より優先する", "ばかなすぱむ", "ばかなすぱむ", true, false},
+		{"報告", "あやしい話", "あやしい話", false, true},
+		{"報告と伏せ字", "アヤシイばか", "アヤシイ**", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := f.Apply(tt.content)
+			if result.Content != tt.want || result.Rejected != tt.wantRejected || result.Flagged() != tt.wantFlagged {
+				t.Errorf("Apply(%q) = {Content: %q, Rejected: %v, Flagged: %v}, want {%q, %v, %v}",
+					tt.content, result.Content, result.Rejected, result.Flagged(), tt.want, tt.wantRejected, tt.wantFlagged)
+			}
+		})
+	}
+}
+
+func TestContentFilterProcessor(t *testing.T) {
+	reject := wordRule("すぱむ")
+	reject.Action = domain.FilterActionReject
+	flag := wordRule("あやしい")
+	flag.ID, flag.Action = "flag", domain.FilterActionFlag
+	f := NewContentFilter(0)
+	f.SetRules([]domain.FilterRule{reject, flag})
+	p := contentFilterProcessor{filter: f}
+
+	err := p.BeforeSave(&OutgoingMessage{Content: "スパム"})
+	var rejected *domain.MessageRejectedError
+	if !errors.As(err, &rejected) || rejected.Processor != ProcessorContentFilter || !errors.Is(err, domain.ErrContentRejected) {
+		t.Errorf("BeforeSave() error = %v, want rejected by %s", err, ProcessorContentFilter)
+	}
+
+	msg := &OutgoingMessage{Content: "アヤシイ"}
+	if err := p.BeforeSave(msg); err != nil {
+		t.Fatalf("BeforeSave() error = %v", err)
+	}
+	if flagged, _ := msg.Fields["flagged"].(bool); !flagged {
+		t.Errorf("flagged = %v, want true", msg.Fields["flagged"])
+	}
+	if ids, _ := msg.Fields["filter_rule_ids"].([]string); len(ids) != 1 || ids[0] != "flag" {
+		t.Errorf("filter_rule_ids = %v, want [flag]", msg.Fields["filter_rule_ids"])
+	}
+
+	// 暗号化されたメッセージは検査しない
+	if err := p.BeforeSave(&OutgoingMessage{Content: "すぱむ", Type: domain.MessageTypeEncrypted}); err != nil {
+		t.Errorf("BeforeSave(暗号化) error = %v, want nil", err)
+	}
+}
+
+func wordRule(pattern string) domain.FilterRule {
+	return domain.FilterRule{ID: "word", Pattern: pattern, MatchType: domain.FilterMatchWord, Action: domain.FilterActionMask, Enabled: true}
+}
+
+func regexRule(pattern string) domain.FilterRule {
+	return domain.FilterRule{ID: "regex", Pattern: pattern, MatchType: domain.FilterMatchRegex, Action: domain.FilterActionMask, Enabled: true}
+}
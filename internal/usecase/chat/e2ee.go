@@ -0,0 +1,210 @@
+package chat
+
+import (
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+)
+
+// 公開鍵を登録し、ユーザーの現在の鍵にする
+// 既に現在の鍵として登録されている場合はそのまま返す
+// 鍵を更新した場合は、それまでの現在の鍵をpreviousとして返す（初めて登録した場合はnil）
+func PublishIdentityKey(user *domain.User, publicKey string) (key *domain.IdentityKey, previous *domain.IdentityKey, err error) {
+	// ボットはメッセージを復号できないため鍵を持たない
+	if user.IsBot {
+		return nil, nil, domain.ErrIdentityKeyInvalid
+	}
+	newKey, err := domain.NewIdentityKey(user.ID, publicKey, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 他のユーザーが登録した鍵と同じ鍵は登録できない
+	if existing, err := firebase.GetIdentityKey(newKey.KeyID); err == nil {
+		if existing.UserID != user.ID {
+			return nil, nil, domain.ErrIdentityKeyInvalid
+		}
+		if existing.Current {
+			return existing, nil, nil
+		}
+	}
+
+	previous, err = firebase.SaveIdentityKey(newKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &newKey, previous, nil
+}
+
+// チャットのエンドツーエンド暗号化を有効にする
+// ボットが参加していない1対1のチャットで、双方が公開鍵を登録している場合のみ有効にできる
+// 有効にした後は暗号化していないメッセージを送信できず、無効にもできない（暗号化していない状態に戻されるのを防ぐ）
+func EnableE2EE(user *domain.User, chatID string) error {
+	participants, err := firebase.GetChatParticipants(chatID)
+	if err != nil {
+		return err
+	}
+	if !containsString(participants, user.ID) {
+		return domain.ErrNotChatParticipant
+	}
+	if len(participants) != 2 {
+		return domain.ErrE2EENotSupported
+	}
+	bots, err := firebase.GetChatBots(chatID)
+	if err != nil {
+		return err
+	}
+	if len(bots) > 0 {
+		return domain.ErrE2EENotSupported
+	}
+
+	enabled, err := firebase.IsChatE2EE(chatID)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return domain.ErrE2EEAlreadyEnabled
+	}
+
+	for _, p := range participants {
+		if _, err := firebase.GetCurrentIdentityKey(p); err != nil {
+			if p == user.ID {
+				return domain.ErrIdentityKeyNotFound
+			}
+			return domain.ErrE2EEUnavailable
+		}
+	}
+	return firebase.EnableChatE2EE(chatID, time.Now())
+}
+
+// ユーザーと相手の安全番号を取得する
+// verifiedは、ユーザーが現在の鍵の組み合わせで安全番号を確認済みにしているかどうか
+// どちらかが公開鍵を登録していない場合はErrIdentityKeyNotFoundを返す
+func GetSafetyNumber(userID string, targetID string) (number domain.SafetyNumber, verified bool, err error) {
+	own, target, err := currentKeyPair(userID, targetID)
+	if err != nil {
+		return "", false, err
+	}
+	number, err = domain.NewSafetyNumber(*own, *target)
+	if err != nil {
+		return "", false, err
+	}
+	v, err := firebase.GetKeyVerification(userID, targetID)
+	if err != nil {
+		return "", false, err
+	}
+	return number, v != nil && v.ValidFor(*own, *target), nil
+}
+
+// 相手の鍵を確認済みにする
+// 画面に表示した安全番号を受け取り、その間に鍵が変更されていた場合はErrSafetyNumberMismatchを返す
+func VerifyIdentityKey(userID string, targetID string, safetyNumber string) error {
+	if userID == targetID {
+		return domain.ErrIdentityKeyInvalid
+	}
+	own, target, err := currentKeyPair(userID, targetID)
+	if err != nil {
+		return err
+	}
+	number, err := domain.NewSafetyNumber(*own, *target)
+	if err != nil {
+		return err
+	}
+	if string(number) != strings.Join(strings.Fields(safetyNumber), "") {
+		return domain.ErrSafetyNumberMismatch
+	}
+	return firebase.SaveKeyVerification(domain.KeyVerification{
+		VerifierID:    userID,
+		TargetID:      targetID,
+		VerifierKeyID: own.KeyID,
+		TargetKeyID:   target.KeyID,
+		VerifiedAt:    time.Now(),
+	})
+}
+
+// 相手の鍵の確認を取り消す
+func UnverifyIdentityKey(userID string, targetID string) error {
+	return firebase.DeleteKeyVerification(userID, targetID)
+}
+
+// ユーザーが参加しているエンドツーエンド暗号化されたチャットのIDを取得する
+// 鍵が変更されたことを各チャットに通知するために使う
+func E2EEChatIDs(userID string) ([]string, error) {
+	chats, err := firebase.GetAllChats(userID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, c := range chats {
+		if enabled, _ := c["e2ee_enabled"].(bool); enabled {
+			if id, ok := c["id"].(string); ok {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// ユーザーと相手の現在の鍵を取得する
+func currentKeyPair(userID string, targetID string) (own *domain.IdentityKey, target *domain.IdentityKey, err error) {
+	own, err = firebase.GetCurrentIdentityKey(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	target, err = firebase.GetCurrentIdentityKey(targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return own, target, nil
+}
+
+// エンドツーエンド暗号化されたチャットでは暗号化されたメッセージだけを受け付ける送信処理
+// 暗号文は形式と鍵のIDだけを検証し、送信者と受信者の現在の鍵で暗号化されていないものは拒否する
+type e2eeProcessor struct{}
+
+func (e2eeProcessor) Name() string { return ProcessorE2EE }
+
+func (e2eeProcessor) BeforeSave(msg *OutgoingMessage) error {
+	enabled, err := firebase.IsChatE2EE(msg.ChatID)
+	if err != nil {
+		return err
+	}
+	if !msg.IsEncrypted() {
+		if enabled {
+			return Reject(ProcessorE2EE, domain.ErrPlaintextInE2EEChat)
+		}
+		return nil
+	}
+	if !enabled {
+		return Reject(ProcessorE2EE, domain.ErrEncryptedMessageNotAllowed)
+	}
+	if msg.Encrypted == nil {
+		return Reject(ProcessorE2EE, domain.ErrEncryptedMessageInvalid)
+	}
+	if err := domain.ValidateEncryptedEnvelope(*msg.Encrypted); err != nil {
+		return Reject(ProcessorE2EE, err)
+	}
+
+	for _, p := range msg.Participants {
+		key, err := firebase.GetCurrentIdentityKey(p)
+		if err != nil {
+			return Reject(ProcessorE2EE, domain.ErrE2EEKeyOutdated)
+		}
+		expected := msg.Encrypted.RecipientKeyID
+		if p == msg.SenderID {
+			expected = msg.Encrypted.SenderKeyID
+		}
+		if key.KeyID != expected {
+			return Reject(ProcessorE2EE, domain.ErrE2EEKeyOutdated)
+		}
+	}
+
+	// サーバーには暗号文と鍵のIDだけを保存する
+	msg.Content = ""
+	msg.Set("e2ee", firebase.EncryptedEnvelopeToData(*msg.Encrypted))
+	return nil
+}
+
+func (e2eeProcessor) AfterSave(msg *OutgoingMessage) {}
@@ -0,0 +1,45 @@
+package chat
+
+import (
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/usecase/notification"
+)
+
+// 未読メッセージのメールのテンプレートを置くディレクトリ
+const emailDigestTemplateDir = "internal/web/templates/email"
+
+// 未読メッセージのメールの送信処理を生成する（データはFirestoreから取得する）
+func NewEmailDigestSender(mailer notification.MailSender, delay time.Duration, interval time.Duration, baseURL string) (*notification.EmailDigestSender, error) {
+	return notification.NewEmailDigestSender(emailDigestStore{}, mailer, emailDigestTemplateDir, delay, interval, baseURL)
+}
+
+// Firestoreに保存した未読メッセージのメールのデータ
+type emailDigestStore struct{}
+
+func (emailDigestStore) GetEmailDigestUsers() ([]domain.User, error) {
+	return firebase.GetEmailDigestUsers()
+}
+
+func (emailDigestStore) GetAllChats(userID string) ([]map[string]interface{}, error) {
+	return firebase.GetAllChats(userID)
+}
+
+func (emailDigestStore) GetUnreadMessagesBetween(chatID string, userID string, after time.Time, before time.Time) ([]map[string]interface{}, error) {
+	return firebase.GetUnreadMessagesBetween(chatID, userID, after, before)
+}
+
+func (emailDigestStore) GetUserName(userID string) (string, error) {
+	data, err := firebase.GetData("users", userID)
+	if err != nil {
+		return "", err
+	}
+	name, _ := data["Name"].(string)
+	return name, nil
+}
+
+func (emailDigestStore) UpdateEmailDigestAt(userID string, at time.Time) error {
+	return firebase.UpdateField("users", userID, "EmailDigestAt", at)
+}
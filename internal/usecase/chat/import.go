@@ -0,0 +1,472 @@
+package chat
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+)
+
+// ImportChat エクスポートしたファイルのメッセージをチャットに取り込む
+// 取り込むのは通常のメッセージのみで、送信者はメールアドレスでチャットの参加者に対応付ける
+// importerを指定した場合（画面からのインポート）は、他の参加者のメッセージを偽装できないようにそのユーザーが送信したメッセージだけを取り込む
+// importerがnilの場合（サーバーの管理者がコマンドで実行する場合）は、全ての参加者のメッセージを取り込む
+// 各メッセージには送信時と同じブロック・文字数・コンテンツフィルターの検査を行い、取り込み済みのメッセージは除外する
+// dryRunの場合は保存せずに取り込む予定の内容を報告する
+func ImportChat(importer *domain.User, chatID string, source domain.ImportSource, data []byte, channel string, dryRun bool) (*domain.ImportReport, error) {
+	participants, err := firebase.GetChatParticipants(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if importer != nil && !containsString(participants, importer.ID) {
+		return nil, domain.ErrNotChatParticipant
+	}
+
+	// 取り込むメッセージは暗号化されていないため、暗号化されたチャットには取り込めない
+	e2ee, err := firebase.IsChatE2EE(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if e2ee {
+		return nil, domain.ErrPlaintextInE2EEChat
+	}
+
+	var messages []domain.ImportMessage
+	switch source {
+	case domain.ImportSourceSlack:
+		messages, err = parseSlackExport(data, channel)
+	default:
+		messages, err = parseAppExport(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, domain.ErrImportMessagesNotFound
+	}
+	domain.SortImportMessages(messages)
+
+	report := &domain.ImportReport{
+		DryRun:          dryRun,
+		Source:          source,
+		Total:           len(messages),
+		UnmappedSenders: []string{},
+		Mapping:         []domain.ImportMapping{},
+	}
+
+	users, err := mapImportSenders(importer, participants, messages, report)
+	if err != nil {
+		return nil, err
+	}
+
+	var imported []*OutgoingMessage
+	var ids []string
+	sourceIDs := make(map[string]string) // メッセージIDごとの取り込み元のID
+	seen := make(map[string]bool)
+	for _, m := range messages {
+		messageID := m.MessageID(source)
+		if seen[messageID] {
+			report.Duplicates++
+			continue
+		}
+		seen[messageID] = true
+
+		user, ok := users[normalizeEmail(m.SenderEmail)]
+		if m.Type != domain.MessageTypeText || !ok {
+			report.Skipped++
+			continue
+		}
+
+		msg := &OutgoingMessage{
+			ID:           messageID,
+			ChatID:       chatID,
+			SenderID:     user.ID,
+			SenderName:   user.Name,
+			Content:      m.Content,
+			Type:         domain.MessageTypeText,
+			CreatedAt:    m.CreatedAt,
+			Participants: participants,
+		}
+		if err := checkImportMessage(msg); err != nil {
+			var rejected *domain.MessageRejectedError
+			if !errors.As(err, &rejected) {
+				return nil, err
+			}
+			// 画面からのインポートでブロックされている場合は、どのメッセージも取り込めない
+			if importer != nil && rejected.Processor == ProcessorBlock {
+				return nil, err
+			}
+			report.Rejected++
+			continue
+		}
+		imported = append(imported, msg)
+		ids = append(ids, messageID)
+		sourceIDs[messageID] = m.SourceID
+	}
+
+	// 以前のインポートで取り込み済みのメッセージを除外する
+	existing, err := firebase.GetExistingChatMessageIDs(chatID, ids)
+	if err != nil {
+		return nil, err
+	}
+	var newMessages []*OutgoingMessage
+	var docs []map[string]interface{}
+	for _, msg := range imported {
+		if existing[msg.ID] {
+			report.Duplicates++
+			continue
+		}
+		doc := msg.toData()
+		doc["is_read"] = true
+		doc["import_source_id"] = string(source) + ":" + sourceIDs[msg.ID]
+		newMessages = append(newMessages, msg)
+		docs = append(docs, doc)
+	}
+
+	report.Imported = len(newMessages)
+	if len(newMessages) > 0 {
+		first := newMessages[0].CreatedAt
+		last := newMessages[len(newMessages)-1].CreatedAt
+		report.FirstMessageAt, report.LastMessageAt = &first, &last
+	}
+
+	if dryRun || len(docs) == 0 {
+		return report, nil
+	}
+	if err := firebase.AddChatMessages(chatID, docs); err != nil {
+		return nil, err
+	}
+	log.Printf("メッセージをインポートしました: chatID=%s, source=%s, count=%d", chatID, source, len(docs))
+
+	// コンテンツフィルターで報告の対象になったメッセージを管理者に報告する
+	filter := contentFilterProcessor{filter: DefaultContentFilter}
+	for _, msg := range newMessages {
+		filter.AfterSave(msg)
+	}
+	return report, nil
+}
+
+// インポートするメッセージに送信時と同じ検査を行う（拒否された場合は*domain.MessageRejectedError）
+// コンテンツフィルターで伏せ字にした場合はmsg.Contentを書き換える
+// 取り込むのは過去のメッセージのため、通知やWebhookなどの保存後の処理は行わない
+func checkImportMessage(msg *OutgoingMessage) error {
+	checks := []MessageProcessor{
+		blockProcessor{isBlocked: firebase.IsBlocked},
+		lengthLimitProcessor{max: domain.MaxMessageLength},
+		contentFilterProcessor{filter: DefaultContentFilter},
+	}
+	for _, check := range checks {
+		if err := check.BeforeSave(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 送信者のメールアドレスをチャットの参加者に対応付ける
+// importerを指定した場合はそのユーザーだけに対応付け、それ以外の送信者は取り込まない送信者として報告する
+func mapImportSenders(importer *domain.User, participants []string, messages []domain.ImportMessage, report *domain.ImportReport) (map[string]*domain.User, error) {
+	users := make(map[string]*domain.User)
+	checked := make(map[string]bool)
+	for _, m := range messages {
+		if m.Type != domain.MessageTypeText {
+			continue
+		}
+		email := normalizeEmail(m.SenderEmail)
+		key := email
+		if key == "" {
+			// メールアドレスが無い送信者は名前で報告する
+			key = "name:" + m.SenderName
+		}
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+
+		var user *domain.User
+		switch {
+		case email == "":
+		case importer != nil:
+			if email == normalizeEmail(importer.Email) {
+				user = importer
+			}
+		default:
+			// 登録時の表記のまま保存されているため、元の表記でも検索する
+			for _, candidate := range []string{strings.TrimSpace(m.SenderEmail), email} {
+				var err error
+				if user, err = repository.GetUserByEmail(candidate); err != nil {
+					return nil, err
+				}
+				if user != nil {
+					break
+				}
+			}
+		}
+		// 対応するユーザーがチャットの参加者でない場合も取り込まない
+		if user == nil || !containsString(participants, user.ID) {
+			label := m.SenderEmail
+			if label == "" {
+				label = m.SenderName + "（メールアドレスなし）"
+			}
+			report.UnmappedSenders = append(report.UnmappedSenders, label)
+			continue
+		}
+		users[email] = user
+		report.Mapping = append(report.Mapping, domain.ImportMapping{
+			SenderEmail: m.SenderEmail,
+			SenderName:  m.SenderName,
+			UserID:      user.ID,
+			UserName:    user.Name,
+		})
+	}
+	return users, nil
+}
+
+// メールアドレスの比較用に前後の空白を除き小文字にする
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// このアプリのJSON形式のエクスポートを読み込む
+func parseAppExport(data []byte) ([]domain.ImportMessage, error) {
+	var export domain.ChatExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("エクスポートの読み込みに失敗しました: %w", err)
+	}
+	if export.Version != domain.ExportVersion {
+		return nil, domain.ErrUnsupportedExport
+	}
+
+	emails := make(map[string]string)
+	for _, p := range export.Participants {
+		emails[p.ID] = p.Email
+	}
+
+	messages := make([]domain.ImportMessage, 0, len(export.Messages))
+	for _, m := range export.Messages {
+		messageType := domain.MessageType(m.Type)
+		if messageType == "" {
+			messageType = domain.MessageTypeText
+		}
+		// 取り込むのは通常のメッセージのみ
+		// システムメッセージ（暗号化の有効化や鍵の変更の通知など）は偽装できないように、
+		// 投票は投票のデータを、暗号化されたメッセージは本文をエクスポートに含まないため取り込まない
+		if messageType != domain.MessageTypeText {
+			continue
+		}
+		content := m.Content
+		// 添付ファイルは本文にURLを残す
+		if m.MediaURL != "" {
+			content = strings.TrimSpace(content + "\n" + m.MediaURL)
+		}
+		messages = append(messages, domain.ImportMessage{
+			SourceID:    export.ChatID + "/" + m.ID,
+			SenderEmail: emails[m.SenderID],
+			SenderName:  m.SenderName,
+			Content:     content,
+			Type:        messageType,
+			CreatedAt:   m.CreatedAt,
+		})
+	}
+	return messages, nil
+}
+
+// Slackのエクスポートのユーザー
+type slackUser struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	RealName string `json:"real_name"`
+	Profile  struct {
+		Email       string `json:"email"`
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+// Slackのエクスポートのメッセージ
+type slackMessage struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	User    string `json:"user"`
+	Text    string `json:"text"`
+	Ts      string `json:"ts"`
+	Files   []struct {
+		Name      string `json:"name"`
+		Permalink string `json:"permalink"`
+	} `json:"files"`
+}
+
+// 取り込むSlackのメッセージの種類（参加・退出などのイベントは取り込まない）
+var slackMessageSubtypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true,
+	"me_message":       true,
+	"file_share":       true,
+}
+
+// Slackのエクスポート（zip）から指定したチャンネルのメッセージを読み込む
+// チャンネルが1つしか含まれない場合は指定を省略できる
+func parseSlackExport(data []byte, channel string) ([]domain.ImportMessage, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("エクスポートの読み込みに失敗しました: %w", err)
+	}
+
+	users := make(map[string]slackUser)
+	channelFiles := make(map[string][]*zip.File)
+	for _, f := range archive.File {
+		dir, name := path.Split(f.Name)
+		dir = strings.TrimSuffix(dir, "/")
+		switch {
+		case dir == "" && name == "users.json":
+			var list []slackUser
+			if err := readZipJSON(f, &list); err != nil {
+				return nil, err
+			}
+			for _, u := range list {
+				users[u.ID] = u
+			}
+		case dir != "" && !strings.Contains(dir, "/") && strings.HasSuffix(name, ".json"):
+			channelFiles[dir] = append(channelFiles[dir], f)
+		}
+	}
+
+	if channel == "" {
+		if len(channelFiles) != 1 {
+			return nil, domain.ErrImportChannelRequired
+		}
+		for name := range channelFiles {
+			channel = name
+		}
+	}
+	files, ok := channelFiles[channel]
+	if !ok {
+		return nil, domain.ErrImportChannelNotFound
+	}
+	// 日付ごとのファイル名の順に読み込む
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	var messages []domain.ImportMessage
+	for _, f := range files {
+		var list []slackMessage
+		if err := readZipJSON(f, &list); err != nil {
+			return nil, err
+		}
+		for _, m := range list {
+			if m.Type != "message" || !slackMessageSubtypes[m.Subtype] {
+				continue
+			}
+			createdAt, err := parseSlackTimestamp(m.Ts)
+			if err != nil {
+				log.Printf("Slackのメッセージの日時の解析に失敗: channel=%s, ts=%s, error=%v", channel, m.Ts, err)
+				continue
+			}
+
+			user := users[m.User]
+			content := convertSlackText(m.Text, users)
+			for _, file := range m.Files {
+				content = strings.TrimSpace(content + "\n" + file.Name + " " + file.Permalink)
+			}
+			messages = append(messages, domain.ImportMessage{
+				// tsはチャンネル内で一意のため取り込み元のIDとして使用する
+				SourceID:    channel + "/" + m.Ts,
+				SenderEmail: user.Profile.Email,
+				SenderName:  slackUserName(user, m.User),
+				Content:     content,
+				Type:        domain.MessageTypeText,
+				CreatedAt:   createdAt,
+			})
+		}
+	}
+	return messages, nil
+}
+
+// zip内のJSONファイルを読み込む
+func readZipJSON(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := json.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%sの読み込みに失敗しました: %w", f.Name, err)
+	}
+	return nil
+}
+
+// Slackのts（"1700000000.123456"の形式）を日時に変換する
+func parseSlackTimestamp(ts string) (time.Time, error) {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	var usec int64
+	if frac != "" {
+		frac = (frac + "000000")[:6]
+		if usec, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return time.Unix(s, usec*int64(time.Microsecond)), nil
+}
+
+// Slackのユーザーの表示名
+func slackUserName(user slackUser, fallback string) string {
+	for _, name := range []string{user.Profile.DisplayName, user.Profile.RealName, user.RealName, user.Name} {
+		if name != "" {
+			return name
+		}
+	}
+	return fallback
+}
+
+// Slackの本文中の特殊な表記（<@U123>や<https://...|表示名>）
+var slackTokenPattern = regexp.MustCompile(`<([^<>]+)>`)
+
+// Slackの本文の表記をこのアプリの表記に変換する
+func convertSlackText(text string, users map[string]slackUser) string {
+	converted := slackTokenPattern.ReplaceAllStringFunc(text, func(token string) string {
+		inner := token[1 : len(token)-1]
+		target, label, _ := strings.Cut(inner, "|")
+		switch {
+		case strings.HasPrefix(target, "@"):
+			if user, ok := users[target[1:]]; ok {
+				return "@" + slackUserName(user, target[1:])
+			}
+			if label != "" {
+				return "@" + label
+			}
+			return target
+		case strings.HasPrefix(target, "#"):
+			if label != "" {
+				return "#" + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			return "@" + strings.TrimPrefix(target, "!")
+		default:
+			if label != "" && label != target {
+				return label + " (" + target + ")"
+			}
+			return target
+		}
+	})
+	// Slackは&・<・>をエスケープして出力する
+	return html.UnescapeString(converted)
+}
@@ -0,0 +1,156 @@
+package chat
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/utils/uuid"
+)
+
+// 受信Webhookへの投稿の内容
+type IncomingWebhookPost struct {
+	Text        string                     // 本文
+	Attachments []domain.MessageAttachment // 添付（任意）
+}
+
+// 受信Webhookを作成し、トークンを返す
+// トークンはハッシュ値だけを保存するため、作成時にしか表示できない
+func CreateIncomingWebhook(user *domain.User, chatID string, name string) (*domain.IncomingWebhook, string, error) {
+	name = strings.TrimSpace(name)
+	if err := domain.ValidateIncomingWebhookName(name); err != nil {
+		return nil, "", err
+	}
+	webhooks, err := firebase.GetIncomingWebhooksByChat(chatID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(webhooks) >= domain.MaxIncomingWebhooksPerChat {
+		return nil, "", domain.ErrIncomingWebhookLimitExceeded
+	}
+
+	webhookID, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := generateToken(domain.IncomingWebhookTokenPrefix)
+	if err != nil {
+		return nil, "", err
+	}
+
+	webhook := &domain.IncomingWebhook{
+		ID:        webhookID,
+		ChatID:    chatID,
+		Name:      name,
+		TokenHash: hashToken(token),
+		CreatedBy: user.ID,
+		CreatedAt: time.Now(),
+	}
+	if err := firebase.AddIncomingWebhook(*webhook); err != nil {
+		return nil, "", err
+	}
+	return webhook, token, nil
+}
+
+// トークンから受信Webhookを認証する
+func AuthenticateIncomingWebhook(token string) (*domain.IncomingWebhook, error) {
+	if !strings.HasPrefix(token, domain.IncomingWebhookTokenPrefix) {
+		return nil, domain.ErrInvalidIncomingWebhookToken
+	}
+	return firebase.GetIncomingWebhookByTokenHash(hashToken(token))
+}
+
+// 受信Webhookとしてチャットに投稿する
+// 投稿は受信Webhookの名前を送信者として表示し、本文は通常のメッセージと同じ検証を通す
+func PostIncomingWebhook(webhook *domain.IncomingWebhook, post IncomingWebhookPost) (*OutgoingMessage, error) {
+	if err := domain.ValidateAttachments(post.Attachments); err != nil {
+		return nil, err
+	}
+	if !incomingWebhookLimiter.Allow(webhook.ID, time.Now()) {
+		return nil, domain.ErrIncomingWebhookRateLimited
+	}
+
+	participants, err := firebase.GetChatParticipants(webhook.ChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &OutgoingMessage{
+		ChatID:     webhook.ChatID,
+		SenderID:   webhook.ID,
+		SenderName: webhook.Name,
+		// 作成したユーザーが相手にブロックされている場合は投稿できない
+		OnBehalfOf: webhook.CreatedBy,
+		Content:    post.Text,
+		// 受信Webhookも送信者として検証・メンションの対象にする
+		Participants: append(participants, webhook.ID),
+	}
+	msg.Set("is_integration", true)
+	if len(post.Attachments) > 0 {
+		msg.Set("attachments", firebase.AttachmentsToData(post.Attachments))
+	}
+	if err := SendMessage(msg); err != nil {
+		return nil, err
+	}
+
+	if err := firebase.TouchIncomingWebhook(webhook.ID, msg.CreatedAt); err != nil {
+		log.Printf("受信Webhookの使用日時の更新に失敗: webhookID=%s, error=%v", webhook.ID, err)
+	}
+	return msg, nil
+}
+
+// 期間ごとの投稿数を数えて制限する（サーバーごとに数える）
+type rateLimiter struct {
+	limit  int           // 期間内に許可する数
+	window time.Duration // 数える期間
+
+	mu      sync.Mutex
+	windows map[string]rateWindow
+}
+
+// キーごとの期間と投稿数
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// 期間ごとの投稿数の制限を生成する
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]rateWindow),
+	}
+}
+
+// 投稿を許可するかどうか（許可した場合は数に含める）
+func (l *rateLimiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		// 期間が過ぎたキーが溜まらないように、新しい期間を始めるときに掃除する
+		for k, old := range l.windows {
+			if now.Sub(old.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+		w = rateWindow{start: now}
+	}
+	if w.count >= l.limit {
+		return false
+	}
+	w.count++
+	l.windows[key] = w
+	return true
+}
+
+// 受信Webhookの投稿数の制限
+var incomingWebhookLimiter = newRateLimiter(
+	domain.IncomingWebhookRateLimit,
+	domain.IncomingWebhookRateWindowSec*time.Second,
+)
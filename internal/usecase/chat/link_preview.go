@@ -0,0 +1,32 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/linkpreview"
+)
+
+// URLのプレビューを取得してメッセージに追加する
+func attachLinkPreview(chatID string, messageID string, pageURL string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	preview, err := linkpreview.Fetch(ctx, pageURL)
+	if err != nil {
+		log.Printf("リンクのプレビューの取得に失敗: url=%s, error=%v", pageURL, err)
+		return
+	}
+	if preview.IsEmpty() {
+		return
+	}
+
+	err = firebase.UpdateChatMessage(chatID, messageID, map[string]interface{}{
+		"link_preview": linkpreview.ToData(preview),
+	})
+	if err != nil {
+		log.Printf("リンクのプレビューの保存に失敗: chatID=%s, messageID=%s, error=%v", chatID, messageID, err)
+	}
+}
@@ -0,0 +1,61 @@
+package chat
+
+import (
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+)
+
+// 本文中のメンションをチャットの参加者から解決する
+func resolveMentions(participantIDs []string, content string) []domain.Mention {
+	var contacts []domain.Contact
+	for _, participantID := range participantIDs {
+		participant, err := repository.GetUserByID(participantID)
+		if err != nil {
+			log.Printf("参加者の情報の取得に失敗: userID=%s, error=%v", participantID, err)
+			continue
+		}
+		contacts = append(contacts, domain.Contact{ID: participant.ID, Username: participant.Name})
+	}
+	return domain.ParseMentions(content, contacts)
+}
+
+// メンションをFirestoreに保存する形式に変換
+func mentionsToData(mentions []domain.Mention) []map[string]interface{} {
+	data := make([]map[string]interface{}, 0, len(mentions))
+	for _, m := range mentions {
+		data = append(data, map[string]interface{}{
+			"user_id":  m.UserID,
+			"username": m.Username,
+			"start":    m.Start,
+			"end":      m.End,
+		})
+	}
+	return data
+}
+
+// メンションされたユーザーに通知する（チャットをミュートしていても通知する）
+func notifyMentions(chatID string, messageID string, senderID string, senderName string, content string, mentions []domain.Mention) {
+	for _, userID := range domain.MentionedUserIDs(mentions) {
+		if userID == senderID {
+			continue
+		}
+		_, err := firebase.AddNotification(map[string]interface{}{
+			"user_id":     userID,
+			"type":        string(domain.NotificationTypeMention),
+			"chat_id":     chatID,
+			"message_id":  messageID,
+			"sender_id":   senderID,
+			"sender_name": senderName,
+			"content":     content,
+			"is_read":     false,
+			"created_at":  time.Now(),
+		})
+		if err != nil {
+			log.Printf("メンションの通知に失敗: userID=%s, messageID=%s, error=%v", userID, messageID, err)
+		}
+	}
+}
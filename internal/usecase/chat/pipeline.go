@@ -0,0 +1,223 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+)
+
+// パイプラインで処理する送信前のメッセージ
+type OutgoingMessage struct {
+	ID            string                    // メッセージのID（空の場合は採番する）
+	ChatID        string                    // 送信先のチャットのID
+	SenderID      string                    // 送信者のID
+	SenderName    string                    // 送信者の名前
+	OnBehalfOf    string                    // ボットなどが送信する場合の作成したユーザーのID（ブロックの判定に使用する）
+	Content       string                    // メッセージの内容（プロセッサが書き換えることがある）
+	Type          domain.MessageType        // メッセージの種類（空の場合は通常のメッセージ）
+	CreatedAt     time.Time                 // 送信日時（ゼロ値の場合は現在時刻）
+	Participants  []string                  // チャットの参加者のID（空の場合はパイプラインが取得する）
+	Mentions      []domain.Mention          // 本文中のメンション
+	LinkURL       string                    // 本文中で検出したURL（プレビューの取得対象）
+	Fields        map[string]interface{}    // プロセッサが追加する保存時のフィールド
+	AllowCommands bool                      // 「/」で始まる本文をスラッシュコマンドとして解釈するかどうか
+	Handled       bool                      // プロセッサが処理を終えたかどうか（trueの場合は保存しない）
+	Reply         string                    // 送信者だけに表示する応答（コマンドの結果など）
+	Encrypted     *domain.EncryptedEnvelope // 暗号化されたメッセージの暗号文（Typeがencryptedの場合のみ）
+}
+
+// 保存時のフィールドを追加する
+func (m *OutgoingMessage) Set(key string, value interface{}) {
+	if m.Fields == nil {
+		m.Fields = make(map[string]interface{})
+	}
+	m.Fields[key] = value
+}
+
+// 暗号化されたメッセージかどうか
+// 暗号化されたメッセージの本文はサーバーでは読めないため、本文を扱う処理は行わない
+func (m *OutgoingMessage) IsEncrypted() bool {
+	return m.Type == domain.MessageTypeEncrypted
+}
+
+// 添付メディアがあるかどうか
+func (m *OutgoingMessage) HasMedia() bool {
+	mediaURL, _ := m.Fields["media_url"].(string)
+	return mediaURL != ""
+}
+
+// Firestoreに保存する形式に変換
+func (m *OutgoingMessage) toData() map[string]interface{} {
+	data := make(map[string]interface{}, len(m.Fields)+8)
+	for key, value := range m.Fields {
+		data[key] = value
+	}
+	data["id"] = m.ID
+	data["sender_id"] = m.SenderID
+	data["sender_name"] = m.SenderName
+	data["content"] = m.Content
+	data["created_at"] = m.CreatedAt
+	data["is_read"] = false
+	data["type"] = string(m.Type)
+	data["mentions"] = mentionsToData(m.Mentions)
+	return data
+}
+
+// メッセージの送信処理
+// BeforeSaveでメッセージの検証・変換・拒否・情報の追加を行い、AfterSaveで保存後の処理（通知など）を行う
+type MessageProcessor interface {
+	// 処理の名前（登録位置の指定やログに使用する）
+	Name() string
+	// 保存前の処理。エラーを返すとメッセージは保存されない
+	BeforeSave(msg *OutgoingMessage) error
+	// 保存後の処理。送信の結果には影響しない
+	AfterSave(msg *OutgoingMessage)
+}
+
+// 関数からメッセージの送信処理を作成する
+// 不要な処理はnilのままでよい
+type ProcessorFuncs struct {
+	ProcessorName string
+	Before        func(msg *OutgoingMessage) error
+	After         func(msg *OutgoingMessage)
+}
+
+func (p ProcessorFuncs) Name() string {
+	return p.ProcessorName
+}
+
+func (p ProcessorFuncs) BeforeSave(msg *OutgoingMessage) error {
+	if p.Before == nil {
+		return nil
+	}
+	return p.Before(msg)
+}
+
+func (p ProcessorFuncs) AfterSave(msg *OutgoingMessage) {
+	if p.After != nil {
+		p.After(msg)
+	}
+}
+
+// メッセージを拒否する（理由はそのままユーザーに表示される）
+func Reject(processor string, reason error) error {
+	return &domain.MessageRejectedError{Processor: processor, Err: reason}
+}
+
+// 登録された順にメッセージの送信処理を実行するパイプライン
+type MessagePipeline struct {
+	mu         sync.RWMutex
+	processors []MessageProcessor
+	save       func(chatID string, data map[string]interface{}) error // メッセージの保存処理
+}
+
+// メッセージのパイプラインを生成する（メッセージはFirestoreに保存する）
+func NewMessagePipeline(processors ...MessageProcessor) *MessagePipeline {
+	return &MessagePipeline{processors: processors, save: firebase.AddChatMessage}
+}
+
+// 送信処理を最後に追加する
+func (p *MessagePipeline) Register(processor MessageProcessor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.processors = append(p.processors, processor)
+}
+
+// 指定した名前の送信処理の直前に追加する
+// 見つからない場合は最後に追加する
+func (p *MessagePipeline) RegisterBefore(name string, processor MessageProcessor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i, existing := range p.processors {
+		if existing.Name() == name {
+			p.processors = append(p.processors[:i], append([]MessageProcessor{processor}, p.processors[i:]...)...)
+			return
+		}
+	}
+	p.processors = append(p.processors, processor)
+}
+
+// 登録されている送信処理の名前（実行順）
+func (p *MessagePipeline) Names() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	names := make([]string, 0, len(p.processors))
+	for _, processor := range p.processors {
+		names = append(names, processor.Name())
+	}
+	return names
+}
+
+// メッセージを処理して保存する
+// いずれかの処理で拒否された場合は*domain.MessageRejectedErrorを返す
+// いずれかの処理がHandledをtrueにした場合は保存せずにnilを返す
+func (p *MessagePipeline) Send(msg *OutgoingMessage) error {
+	p.mu.RLock()
+	processors := make([]MessageProcessor, len(p.processors))
+	copy(processors, p.processors)
+	p.mu.RUnlock()
+
+	if msg.ID == "" {
+		msg.ID = fmt.Sprintf("msg_%d", time.Now().UnixNano())
+	}
+	if msg.Type == "" {
+		msg.Type = domain.MessageTypeText
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now()
+	}
+	if msg.Participants == nil {
+		participants, err := firebase.GetChatParticipants(msg.ChatID)
+		if err != nil {
+			return err
+		}
+		msg.Participants = participants
+	}
+
+	for _, processor := range processors {
+		if err := processor.BeforeSave(msg); err != nil {
+			var rejected *domain.MessageRejectedError
+			if errors.As(err, &rejected) {
+				return err
+			}
+			return fmt.Errorf("%s: %w", processor.Name(), err)
+		}
+		// コマンドなどで処理が完了した場合は、以降の処理を行わず保存もしない
+		if msg.Handled {
+			return nil
+		}
+	}
+
+	if err := p.save(msg.ChatID, msg.toData()); err != nil {
+		return err
+	}
+
+	for _, processor := range processors {
+		processor.AfterSave(msg)
+	}
+	return nil
+}
+
+// メッセージの送信に使用する既定のパイプライン
+var DefaultPipeline = NewMessagePipeline(
+	participantProcessor{},
+	blockProcessor{isBlocked: firebase.IsBlocked},
+	e2eeProcessor{},
+	commandProcessor{commands: DefaultCommands},
+	lengthLimitProcessor{max: domain.MaxMessageLength},
+	contentFilterProcessor{filter: DefaultContentFilter},
+	mentionProcessor{},
+	linkPreviewProcessor{},
+	botEventProcessor{},
+	webhookProcessor{},
+	pushProcessor{},
+)
+
+// 既定のパイプラインでメッセージを送信する
+func SendMessage(msg *OutgoingMessage) error {
+	return DefaultPipeline.Send(msg)
+}
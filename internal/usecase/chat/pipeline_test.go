@@ -0,0 +1,251 @@
+package chat
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"security_chat_app/internal/domain"
+)
+
+// 実行した処理を記録する送信処理
+func recordingProcessor(name string, calls *[]string, before func(msg *OutgoingMessage) error) ProcessorFuncs {
+	return ProcessorFuncs{
+		ProcessorName: name,
+		Before: func(msg *OutgoingMessage) error {
+			*calls = append(*calls, "before:"+name)
+			if before != nil {
+				return before(msg)
+			}
+			return nil
+		},
+		After: func(msg *OutgoingMessage) {
+			*calls = append(*calls, "after:"+name)
+		},
+	}
+}
+
+// Firestoreの代わりに保存したメッセージを記録するパイプライン
+func newTestPipeline(calls *[]string, processors ...MessageProcessor) *MessagePipeline {
+	p := NewMessagePipeline(processors...)
+	p.save = func(chatID string, data map[string]interface{}) error {
+		*calls = append(*calls, "save:"+chatID)
+		return nil
+	}
+	return p
+}
+
+func testOutgoingMessage() *OutgoingMessage {
+	return &OutgoingMessage{
+		ChatID:       "chat-1",
+		SenderID:     "alice",
+		SenderName:   "Alice",
+		Content:      "こんにちは",
+		Participants: []string{"alice", "bob"},
+	}
+}
+
+func TestDefaultPipelineOrder(t *testing.T) {
+	want := []string{
+		ProcessorParticipant,
+		ProcessorBlock,
+		ProcessorE2EE,
+		ProcessorCommand,
+		ProcessorLengthLimit,
+		ProcessorContentFilter,
+		ProcessorMention,
+		ProcessorLinkPreview,
+		ProcessorBotEvent,
+		ProcessorWebhook,
+		ProcessorPush,
+	}
+	if got := DefaultPipeline.Names(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("DefaultPipeline.Names() = %v, want %v", got, want)
+	}
+}
+
+func TestRegisterBefore(t *testing.T) {
+	var calls []string
+	p := newTestPipeline(&calls,
+		recordingProcessor("a", &calls, nil),
+		recordingProcessor("b", &calls, nil),
+	)
+	p.RegisterBefore("b", recordingProcessor("x", &calls, nil))
+	p.RegisterBefore("a", recordingProcessor("y", &calls, nil))
+	p.RegisterBefore("unknown", recordingProcessor("z", &calls, nil))
+	p.Register(recordingProcessor("last", &calls, nil))
+
+	if got, want := strings.Join(p.Names(), ","), "y,a,x,b,z,last"; got != want {
+		t.Errorf("Names() = %s, want %s", got, want)
+	}
+}
+
+func TestPipelineSend(t *testing.T) {
+	var calls []string
+	p := newTestPipeline(&calls,
+		recordingProcessor("a", &calls, nil),
+		recordingProcessor("b", &calls, nil),
+	)
+	msg := testOutgoingMessage()
+
+	if err := p.Send(msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got, want := strings.Join(calls, ","), "before:a,before:b,save:chat-1,after:a,after:b"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+	if msg.ID == "" || msg.Type != domain.MessageTypeText || msg.CreatedAt.IsZero() {
+		t.Errorf("既定値が設定されていません: ID=%q, Type=%q, CreatedAt=%v", msg.ID, msg.Type, msg.CreatedAt)
+	}
+}
+
+func TestPipelineSendRejected(t *testing.T) {
+	var calls []string
+	p := newTestPipeline(&calls,
+		recordingProcessor("a", &calls, nil),
+		recordingProcessor("reject", &calls, func(msg *OutgoingMessage) error {
+			return Reject("reject", domain.ErrMessageNotAllowed)
+		}),
+		recordingProcessor("c", &calls, nil),
+	)
+
+	err := p.Send(testOutgoingMessage())
+	var rejected *domain.MessageRejectedError
+	if !errors.As(err, &rejected) {
+		t.Fatalf("Send() error = %v, want *domain.MessageRejectedError", err)
+	}
+	if rejected.Processor != "reject" || !errors.Is(err, domain.ErrMessageNotAllowed) {
+		t.Errorf("rejected = %+v, want Processor=reject, Err=%v", rejected, domain.ErrMessageNotAllowed)
+	}
+	// 拒否した後の処理・保存・保存後の処理は実行しない
+	if got, want := strings.Join(calls, ","), "before:a,before:reject"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestPipelineSendError(t *testing.T) {
+	var calls []string
+	errFailed := errors.New("取得に失敗しました")
+	p := newTestPipeline(&calls,
+		recordingProcessor("fail", &calls, func(msg *OutgoingMessage) error { return errFailed }),
+	)
+
+	err := p.Send(testOutgoingMessage())
+	var rejected *domain.MessageRejectedError
+	if errors.As(err, &rejected) {
+		t.Fatalf("Send() error = %v, 拒否ではないエラーが拒否として返されています", err)
+	}
+	if !errors.Is(err, errFailed) || !strings.HasPrefix(err.Error(), "fail: ") {
+		t.Errorf("Send() error = %v, want %q wrapped with the processor name", err, errFailed)
+	}
+	if got, want := strings.Join(calls, ","), "before:fail"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestPipelineSendHandled(t *testing.T) {
+	var calls []string
+	p := newTestPipeline(&calls,
+		recordingProcessor("command", &calls, func(msg *OutgoingMessage) error {
+			msg.Handled = true
+			return nil
+		}),
+		recordingProcessor("c", &calls, nil),
+	)
+
+	if err := p.Send(testOutgoingMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	// 処理が完了した場合は保存せず、以降の処理と保存後の処理も実行しない
+	if got, want := strings.Join(calls, ","), "before:command"; got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestLengthLimitProcessor(t *testing.T) {
+	const max = 10
+	tests := []struct {
+		name string
+		msg  OutgoingMessage
+		want error
+	}{
+		{"通常の本文", OutgoingMessage{Content: "こんにちは"}, nil},
+		{"空の本文", OutgoingMessage{Content: ""}, domain.ErrMessageEmpty},
+		{"空白だけの本文", OutgoingMessage{Content: " \n\t"}, domain.ErrMessageEmpty},
+		{"メディアのある空の本文", OutgoingMessage{Fields: map[string]interface{}{"media_url": "https://example.com/a.png"}}, nil},
+		{"URLが空のメディア", OutgoingMessage{Fields: map[string]interface{}{"media_url": ""}}, domain.ErrMessageEmpty},
+		{"上限ちょうどの本文", OutgoingMessage{Content: strings.Repeat("あ", max)}, nil},
+		{"上限を超える本文", OutgoingMessage{Content: strings.Repeat("あ", max+1)}, domain.ErrMessageTooLong},
+		{"上限を超えるメディアの本文", OutgoingMessage{Content: strings.Repeat("あ", max+1), Fields: map[string]interface{}{"media_url": "https://example.com/a.png"}}, domain.ErrMessageTooLong},
+		{"暗号化されたメッセージ", OutgoingMessage{Type: domain.MessageTypeEncrypted}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := lengthLimitProcessor{max: max}.BeforeSave(&tt.msg)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("BeforeSave() error = %v, want %v", err, tt.want)
+			}
+			var rejected *domain.MessageRejectedError
+			if tt.want != nil && (!errors.As(err, &rejected) || rejected.Processor != ProcessorLengthLimit) {
+				t.Errorf("BeforeSave() error = %#v, want rejected by %s", err, ProcessorLengthLimit)
+			}
+		})
+	}
+}
+
+func TestBlockProcessor(t *testing.T) {
+	// blockerがblockedをブロックしている組み合わせ
+	blocks := map[[2]string]bool{
+		{"carol", "alice"}: true,
+		{"bob", "owner"}:   true,
+	}
+	tests := []struct {
+		name         string
+		senderID     string
+		onBehalfOf   string
+		participants []string
+		wantRejected bool
+		wantChecked  []string
+	}{
+		{"ブロックされていない", "alice", "", []string{"alice", "bob"}, false, []string{"bob>alice"}},
+		{"相手にブロックされている", "alice", "", []string{"alice", "bob", "carol"}, true, []string{"bob>alice", "carol>alice"}},
+		{"送信者がブロックしている相手には送信できる", "carol", "", []string{"carol", "alice"}, false, []string{"alice>carol"}},
+		{"ボットを作成したユーザーがブロックされている", "bot", "owner", []string{"bot", "owner", "bob"}, true, []string{"bob>bot", "bob>owner"}},
+		{"ボットを作成したユーザーはブロックされていない", "bot", "owner", []string{"bot", "owner", "carol"}, false, []string{"carol>bot", "carol>owner"}},
+		{"送信者と同じユーザーの代理", "alice", "alice", []string{"alice", "bob"}, false, []string{"bob>alice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var checked []string
+			p := blockProcessor{isBlocked: func(blockerID string, blockedID string) (bool, error) {
+				checked = append(checked, blockerID+">"+blockedID)
+				return blocks[[2]string{blockerID, blockedID}], nil
+			}}
+			msg := &OutgoingMessage{SenderID: tt.senderID, OnBehalfOf: tt.onBehalfOf, Participants: tt.participants}
+
+			err := p.BeforeSave(msg)
+			var rejected *domain.MessageRejectedError
+			if got := errors.As(err, &rejected); got != tt.wantRejected {
+				t.Fatalf("BeforeSave() error = %v, wantRejected %v", err, tt.wantRejected)
+			}
+			if tt.wantRejected && (rejected.Processor != ProcessorBlock || !errors.Is(err, domain.ErrMessageNotAllowed)) {
+				t.Errorf("rejected = %+v, want Processor=%s, Err=%v", rejected, ProcessorBlock, domain.ErrMessageNotAllowed)
+			}
+			if strings.Join(checked, ",") != strings.Join(tt.wantChecked, ",") {
+				t.Errorf("checked = %v, want %v", checked, tt.wantChecked)
+			}
+		})
+	}
+}
+
+func TestBlockProcessorError(t *testing.T) {
+	errFailed := errors.New("取得に失敗しました")
+	p := blockProcessor{isBlocked: func(blockerID string, blockedID string) (bool, error) {
+		return false, errFailed
+	}}
+	err := p.BeforeSave(&OutgoingMessage{SenderID: "alice", Participants: []string{"alice", "bob"}})
+	var rejected *domain.MessageRejectedError
+	if !errors.Is(err, errFailed) || errors.As(err, &rejected) {
+		t.Errorf("BeforeSave() error = %v, want %v", err, errFailed)
+	}
+}
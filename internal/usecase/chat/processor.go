@@ -0,0 +1,126 @@
+package chat
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/linkpreview"
+)
+
+// 既定の送信処理の名前
+const (
+	ProcessorParticipant   = "participant"
+	ProcessorBlock         = "block"
+	ProcessorE2EE          = "e2ee"
+	ProcessorCommand       = "command"
+	ProcessorLengthLimit   = "length_limit"
+	ProcessorContentFilter = "content_filter"
+	ProcessorMention       = "mention"
+	ProcessorLinkPreview   = "link_preview"
+	ProcessorBotEvent      = "bot_event"
+	ProcessorWebhook       = "webhook"
+	ProcessorPush          = "push"
+)
+
+// 送信者がチャットの参加者であることを確認する
+type participantProcessor struct{}
+
+func (participantProcessor) Name() string { return ProcessorParticipant }
+
+func (participantProcessor) BeforeSave(msg *OutgoingMessage) error {
+	for _, p := range msg.Participants {
+		if p == msg.SenderID {
+			return nil
+		}
+	}
+	return Reject(ProcessorParticipant, domain.ErrNotChatParticipant)
+}
+
+func (participantProcessor) AfterSave(msg *OutgoingMessage) {}
+
+// 相手にブロックされている場合は送信しない
+// ボットなどが送信する場合は、作成したユーザーがブロックされている場合も送信しない
+type blockProcessor struct {
+	isBlocked func(blockerID string, blockedID string) (bool, error) // blockerIDがblockedIDをブロックしているかどうか
+}
+
+func (blockProcessor) Name() string { return ProcessorBlock }
+
+func (p blockProcessor) BeforeSave(msg *OutgoingMessage) error {
+	senders := []string{msg.SenderID}
+	if msg.OnBehalfOf != "" && msg.OnBehalfOf != msg.SenderID {
+		senders = append(senders, msg.OnBehalfOf)
+	}
+	for _, participant := range msg.Participants {
+		if containsString(senders, participant) {
+			continue
+		}
+		for _, sender := range senders {
+			blocked, err := p.isBlocked(participant, sender)
+			if err != nil {
+				return err
+			}
+			if blocked {
+				return Reject(ProcessorBlock, domain.ErrMessageNotAllowed)
+			}
+		}
+	}
+	return nil
+}
+
+func (blockProcessor) AfterSave(msg *OutgoingMessage) {}
+
+// 空のメッセージと長すぎるメッセージを拒否する
+type lengthLimitProcessor struct {
+	max int // 最大文字数
+}
+
+func (lengthLimitProcessor) Name() string { return ProcessorLengthLimit }
+
+func (p lengthLimitProcessor) BeforeSave(msg *OutgoingMessage) error {
+	// 暗号文の長さはe2eeProcessorで検証する
+	if msg.IsEncrypted() {
+		return nil
+	}
+	// 添付メディアのあるメッセージ（転送したものなど）は本文が空でもよい
+	if strings.TrimSpace(msg.Content) == "" && !msg.HasMedia() {
+		return Reject(ProcessorLengthLimit, domain.ErrMessageEmpty)
+	}
+	if utf8.RuneCountInString(msg.Content) > p.max {
+		return Reject(ProcessorLengthLimit, domain.ErrMessageTooLong)
+	}
+	return nil
+}
+
+func (lengthLimitProcessor) AfterSave(msg *OutgoingMessage) {}
+
+// 本文中のメンションを解決し、保存後にメンションされたユーザーに通知する
+type mentionProcessor struct{}
+
+func (mentionProcessor) Name() string { return ProcessorMention }
+
+func (mentionProcessor) BeforeSave(msg *OutgoingMessage) error {
+	msg.Mentions = resolveMentions(msg.Participants, msg.Content)
+	return nil
+}
+
+func (mentionProcessor) AfterSave(msg *OutgoingMessage) {
+	notifyMentions(msg.ChatID, msg.ID, msg.SenderID, msg.SenderName, msg.Content, msg.Mentions)
+}
+
+// 本文中のURLを検出し、保存後にバックグラウンドでプレビューを取得する
+type linkPreviewProcessor struct{}
+
+func (linkPreviewProcessor) Name() string { return ProcessorLinkPreview }
+
+func (linkPreviewProcessor) BeforeSave(msg *OutgoingMessage) error {
+	msg.LinkURL = linkpreview.FindURL(msg.Content)
+	return nil
+}
+
+func (linkPreviewProcessor) AfterSave(msg *OutgoingMessage) {
+	if msg.LinkURL != "" {
+		go attachLinkPreview(msg.ChatID, msg.ID, msg.LinkURL)
+	}
+}
@@ -0,0 +1,114 @@
+package chat
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/repository"
+	"security_chat_app/internal/usecase/notification"
+)
+
+// プッシュ通知の送信処理を生成する（データはFirestoreから取得する）
+func NewPushNotifier(service notification.PushService, concurrency int) *notification.PushNotifier {
+	return notification.NewPushNotifier(pushStore{}, service, concurrency)
+}
+
+// 既定のプッシュ通知の送信処理（VAPIDの鍵が設定されていない場合はnilのまま通知しない）
+var DefaultPushNotifier *notification.PushNotifier
+
+// プッシュ通知を購読する
+// 1人あたりの上限を超えた場合は古い購読から削除する
+func SubscribePush(userID string, sub domain.PushSubscription) error {
+	if err := domain.ValidatePushSubscription(sub); err != nil {
+		return err
+	}
+	sub.ID = pushSubscriptionID(sub.Endpoint)
+	sub.UserID = userID
+	sub.CreatedAt = time.Now()
+	if err := firebase.SavePushSubscription(sub); err != nil {
+		return err
+	}
+
+	subs, err := firebase.GetPushSubscriptionsByUser(userID)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(subs)-domain.MaxPushSubscriptionsPerUser; i++ {
+		if err := firebase.DeletePushSubscription(subs[i].ID); err != nil {
+			log.Printf("古いプッシュ通知の購読の削除に失敗: subscriptionID=%s, error=%v", subs[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// プッシュ通知の購読を解除する（他のユーザーの購読は解除できない）
+func UnsubscribePush(userID string, endpoint string) error {
+	sub, err := firebase.GetPushSubscription(pushSubscriptionID(endpoint))
+	if err != nil {
+		return err
+	}
+	if sub.UserID != userID {
+		return domain.ErrPushSubscriptionNotFound
+	}
+	return firebase.DeletePushSubscription(sub.ID)
+}
+
+// エンドポイントから購読のIDを生成する（同じブラウザで購読し直した場合は上書きする）
+func pushSubscriptionID(endpoint string) string {
+	sum := sha256.Sum256([]byte(endpoint))
+	return hex.EncodeToString(sum[:])
+}
+
+// Firestoreに保存したプッシュ通知のデータ
+type pushStore struct{}
+
+func (pushStore) GetChat(chatID string) (map[string]interface{}, error) {
+	return firebase.GetData("chats", chatID)
+}
+
+func (pushStore) GetUser(userID string) (*domain.User, error) {
+	return repository.GetUserByID(userID)
+}
+
+func (pushStore) GetPushSubscriptionsByUser(userID string) ([]domain.PushSubscription, error) {
+	return firebase.GetPushSubscriptionsByUser(userID)
+}
+
+func (pushStore) DeletePushSubscription(subscriptionID string) error {
+	return firebase.DeletePushSubscription(subscriptionID)
+}
+
+func (pushStore) DeleteExpiredPushSubscriptions(now time.Time) (int, error) {
+	return firebase.DeleteExpiredPushSubscriptions(now)
+}
+
+func (pushStore) TouchPushSubscription(subscriptionID string, usedAt time.Time) error {
+	return firebase.TouchPushSubscription(subscriptionID, usedAt)
+}
+
+// 保存したメッセージをプッシュ通知で送信する送信処理
+type pushProcessor struct{}
+
+func (pushProcessor) Name() string { return ProcessorPush }
+
+func (pushProcessor) BeforeSave(msg *OutgoingMessage) error { return nil }
+
+func (pushProcessor) AfterSave(msg *OutgoingMessage) {
+	if notifier := DefaultPushNotifier; notifier != nil {
+		go notifier.NotifyMessage(notification.PushMessage{
+			ID:           msg.ID,
+			ChatID:       msg.ChatID,
+			SenderID:     msg.SenderID,
+			SenderName:   msg.SenderName,
+			Content:      msg.Content,
+			Type:         msg.Type,
+			HasMedia:     msg.HasMedia(),
+			Participants: msg.Participants,
+			Mentions:     msg.Mentions,
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+)
+
+// チャットの保持期間を過ぎたメッセージを削除するスイーパー
+type RetentionSweeper struct {
+	interval time.Duration // 期限切れのメッセージを確認する間隔
+}
+
+// 保持期間のスイーパーを生成する
+func NewRetentionSweeper(interval time.Duration) *RetentionSweeper {
+	return &RetentionSweeper{interval: interval}
+}
+
+// Start ctxがキャンセルされるまで、保持期間を過ぎたメッセージを定期的に削除する
+func (s *RetentionSweeper) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.sweep()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("保持期間のスイーパーを停止します")
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// 保持期間が設定された全チャットの期限切れメッセージを削除する
+func (s *RetentionSweeper) sweep() {
+	chats, err := firebase.GetChatsWithRetention()
+	if err != nil {
+		log.Printf("保持期間が設定されたチャットの取得に失敗: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, chatData := range chats {
+		chatID, _ := chatData["id"].(string)
+		value, _ := chatData["retention"].(string)
+		policy, err := domain.ParseRetentionPolicy(value)
+		if err != nil || policy.Duration() == 0 {
+			continue
+		}
+
+		deleted, err := firebase.DeleteMessagesBefore(chatID, now.Add(-policy.Duration()))
+		if err != nil {
+			log.Printf("期限切れメッセージの削除に失敗: chatID=%s, error=%v", chatID, err)
+			continue
+		}
+
+		// ピン留めされているメッセージのID
+		pinnedIDs := make(map[string]bool)
+		if pinned, ok := chatData["pinned_messages"].([]interface{}); ok {
+			for _, p := range pinned {
+				if pm, ok := p.(map[string]interface{}); ok {
+					if id, ok := pm["message_id"].(string); ok {
+						pinnedIDs[id] = true
+					}
+				}
+			}
+		}
+
+		for _, message := range deleted {
+			NotifyWebhooks(domain.WebhookMessageDeleted, chatID, WebhookMessageFromData(message))
+
+			// 添付されたメディアを削除
+			if mediaPath, ok := message["media_path"].(string); ok && mediaPath != "" {
+				if err := firebase.DeleteMedia(mediaPath); err != nil {
+					log.Printf("期限切れメディアの削除に失敗: chatID=%s, path=%s, error=%v", chatID, mediaPath, err)
+				}
+			}
+
+			// ピン留めに残った内容も削除
+			messageID, _ := message["id"].(string)
+			if !pinnedIDs[messageID] {
+				continue
+			}
+			if err := firebase.UnpinChatMessage(chatID, messageID); err != nil && !errors.Is(err, domain.ErrNotPinned) {
+				log.Printf("期限切れメッセージのピン留め解除に失敗: chatID=%s, messageID=%s, error=%v", chatID, messageID, err)
+			}
+		}
+
+		if len(deleted) > 0 {
+			log.Printf("保持期間を過ぎたメッセージを削除しました: chatID=%s, count=%d", chatID, len(deleted))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package chat
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+)
+
+// 一度に配信する予約メッセージの最大件数
+const scheduledBatchSize = 50
+
+// 送信処理中のまま放置された予約メッセージを送信待ちに戻すまでの時間
+const staleClaimTimeout = 5 * time.Minute
+
+// 予約メッセージを送信時刻に配信するディスパッチャー
+type ScheduledMessageDispatcher struct {
+	interval time.Duration // 送信時刻を確認する間隔
+}
+
+// 予約メッセージのディスパッチャーを生成する
+func NewScheduledMessageDispatcher(interval time.Duration) *ScheduledMessageDispatcher {
+	return &ScheduledMessageDispatcher{interval: interval}
+}
+
+// Start ctxがキャンセルされるまで、送信時刻を過ぎた予約メッセージを定期的に配信する
+// 予約はFirestoreに保存されているため、サーバーを再起動しても配信は継続される
+func (d *ScheduledMessageDispatcher) Start(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	// 起動直後に停止中に送信時刻を迎えた予約を配信する
+	d.requeueStale()
+	d.dispatchDue()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("予約メッセージのディスパッチャーを停止します")
+			return
+		case <-ticker.C:
+			d.requeueStale()
+			d.dispatchDue()
+		}
+	}
+}
+
+// 送信処理中のまま放置された予約メッセージを送信待ちに戻す
+func (d *ScheduledMessageDispatcher) requeueStale() {
+	count, err := firebase.RequeueStaleScheduledMessages(time.Now().Add(-staleClaimTimeout))
+	if err != nil {
+		log.Printf("予約メッセージの再登録に失敗: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("送信処理中のまま停止した予約メッセージを再登録しました: %d件", count)
+	}
+}
+
+// 送信時刻を過ぎた予約メッセージを配信する
+func (d *ScheduledMessageDispatcher) dispatchDue() {
+	messages, err := firebase.ClaimDueScheduledMessages(time.Now(), scheduledBatchSize)
+	if err != nil {
+		log.Printf("送信予定の予約メッセージの取得に失敗: %v", err)
+	}
+
+	for _, scheduled := range messages {
+		status := domain.ScheduleStatusSent
+		if err := deliverScheduledMessage(scheduled); err != nil {
+			log.Printf("予約メッセージの配信に失敗: scheduleID=%s, error=%v", scheduled.ID, err)
+			status = domain.ScheduleStatusFailed
+		}
+		if err := firebase.UpdateField("scheduled_messages", scheduled.ID, "status", string(status)); err != nil {
+			log.Printf("予約メッセージの状態の更新に失敗: scheduleID=%s, error=%v", scheduled.ID, err)
+		}
+	}
+}
+
+// 予約メッセージを通常のメッセージとしてチャットに追加する
+// 予約後にチャットから外れた場合や相手からブロックされた場合は、パイプラインで拒否される
+func deliverScheduledMessage(scheduled domain.ScheduledMessage) error {
+	msg := &OutgoingMessage{
+		ChatID:     scheduled.ChatID,
+		SenderID:   scheduled.SenderID,
+		SenderName: scheduled.SenderName,
+		Content:    scheduled.Content,
+	}
+	msg.Set("scheduled_id", scheduled.ID)
+	return SendMessage(msg)
+}
@@ -3,6 +3,7 @@ package chat
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"security_chat_app/internal/domain"
@@ -58,8 +59,10 @@ func (c *chatUsecaseImpl) GetChatHistory(user *domain.User) ([]domain.Chat, erro
 
 // GetContactsメソッドの実装
 func (c *chatUsecaseImpl) GetContacts(user *domain.User) ([]domain.Contact, error) {
-	// TODO: 実装
-	return nil, fmt.Errorf("not implemented")
+	if user == nil {
+		return nil, fmt.Errorf("ユーザー情報が無効です")
+	}
+	return c.repo.GetContacts(user.ID)
 }
 
 // **************************************************
@@ -129,6 +132,53 @@ func (r *chatRepository) GetMessages(chatID string) ([]domain.Message, error) {
 	return messages, nil
 }
 
+// GetContactsメソッドの実装
+func (r *chatRepository) GetContacts(userID string) ([]domain.Contact, error) {
+	// Firestoreから連絡先を取得する処理
+	ctx := context.Background()
+	contacts := []domain.Contact{}
+	now := time.Now()
+	iter := r.client.Collection("contacts").Where("user_id", "==", userID).Documents(ctx)
+
+	for {
+		doc, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		contactID, _ := doc.Data()["contact_id"].(string)
+		userDoc, err := r.client.Collection("users").Doc(contactID).Get(ctx)
+		if err != nil {
+			// 退会などで存在しないユーザーは表示しない
+			continue
+		}
+
+		var user domain.User
+		if err := userDoc.DataTo(&user); err != nil {
+			return nil, err
+		}
+		// オフライン表示の連絡先はオフラインとして表示する
+		presence := user.PresenceAt(now)
+		contacts = append(contacts, domain.Contact{
+			ID:       contactID,
+			Username: user.Name,
+			Icon:     user.Icon,
+			IsOnline: presence != domain.AvailabilityOffline,
+			Presence: presence,
+			Profile:  user.Profile,
+		})
+	}
+
+	// 名前の順に並べる
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].Username < contacts[j].Username
+	})
+	return contacts, nil
+}
+
 // **************************************************
 // ChatControllerの定義 **************
 // **************************************************
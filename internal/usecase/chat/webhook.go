@@ -0,0 +1,178 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/firebase"
+	"security_chat_app/internal/infrastructure/webhook"
+	"security_chat_app/internal/utils/uuid"
+)
+
+// Webhookで送信するJSON
+type WebhookPayload struct {
+	ID        string              `json:"id"`         // 配信のID（再試行しても変わらない）
+	Event     domain.WebhookEvent `json:"event"`      // イベントの種類
+	ChatID    string              `json:"chat_id"`    // イベントが発生したチャットのID
+	Message   WebhookMessage      `json:"message"`    // 対象のメッセージ
+	CreatedAt time.Time           `json:"created_at"` // イベントの発生日時
+}
+
+// Webhookで送信するメッセージ
+// 削除のイベントでは削除した内容を外部に残さないように本文を含めない
+type WebhookMessage struct {
+	ID         string             `json:"id"`
+	SenderID   string             `json:"sender_id"`
+	SenderName string             `json:"sender_name"`
+	Content    string             `json:"content,omitempty"`
+	Type       domain.MessageType `json:"type,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+// Firestoreのメッセージのデータから送信するメッセージを作成する
+func WebhookMessageFromData(data map[string]interface{}) WebhookMessage {
+	var m WebhookMessage
+	m.ID, _ = data["id"].(string)
+	m.SenderID, _ = data["sender_id"].(string)
+	m.SenderName, _ = data["sender_name"].(string)
+	m.Content, _ = data["content"].(string)
+	m.CreatedAt, _ = data["created_at"].(time.Time)
+	if t, ok := data["type"].(string); ok {
+		m.Type = domain.MessageType(t)
+	}
+	return m
+}
+
+// WebhookDispatcher 登録されたWebhookにイベントを配信する
+// 一時的な失敗は間隔を倍にしながら再試行し、最終的な結果を配信履歴に記録する
+type WebhookDispatcher struct {
+	sender      *webhook.Sender
+	maxAttempts int           // 最大試行回数
+	baseDelay   time.Duration // 最初の再試行までの間隔（以降は倍にする）
+	sem         chan struct{} // 同時に送信する数の制限
+}
+
+// Webhookの配信処理を生成する
+func NewWebhookDispatcher(sender *webhook.Sender, maxAttempts int, baseDelay time.Duration, concurrency int) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		baseDelay:   baseDelay,
+		sem:         make(chan struct{}, concurrency),
+	}
+}
+
+// 既定のWebhookの配信処理（最大5回、2秒・4秒・8秒・16秒の間隔で再試行する）
+var DefaultWebhookDispatcher = NewWebhookDispatcher(webhook.NewSender(10*time.Second), 5, 2*time.Second, 10)
+
+// チャットのイベントを登録されたWebhookに通知する
+// 配信は別のゴルーチンで行うため、呼び出し元の処理は待たない
+func NotifyWebhooks(event domain.WebhookEvent, chatID string, message WebhookMessage) {
+	DefaultWebhookDispatcher.Dispatch(event, chatID, message)
+}
+
+// イベントを通知するWebhookを探して配信を始める
+func (d *WebhookDispatcher) Dispatch(event domain.WebhookEvent, chatID string, message WebhookMessage) {
+	webhooks, err := firebase.GetWebhooksForChat(chatID)
+	if err != nil {
+		log.Printf("Webhookの取得に失敗: chatID=%s, error=%v", chatID, err)
+		return
+	}
+	if event == domain.WebhookMessageDeleted {
+		message.Content = ""
+	}
+
+	now := time.Now()
+	for _, wh := range webhooks {
+		if !wh.Subscribes(event) {
+			continue
+		}
+		deliveryID, err := uuid.GenerateUUID()
+		if err != nil {
+			log.Printf("配信IDの生成に失敗: webhookID=%s, error=%v", wh.ID, err)
+			continue
+		}
+		payload := WebhookPayload{
+			ID:        deliveryID,
+			Event:     event,
+			ChatID:    chatID,
+			Message:   message,
+			CreatedAt: now,
+		}
+		go d.deliver(wh, payload)
+	}
+}
+
+// 1つのWebhookに配信し、結果を記録する
+func (d *WebhookDispatcher) deliver(wh domain.Webhook, payload WebhookPayload) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Webhookの配信で異常終了: webhookID=%s, error=%v", wh.ID, r)
+		}
+	}()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Webhookの本文の作成に失敗: webhookID=%s, error=%v", wh.ID, err)
+		return
+	}
+	req := webhook.Request{
+		URL:        wh.URL,
+		Secret:     wh.Secret,
+		Event:      string(payload.Event),
+		DeliveryID: payload.ID,
+		Body:       body,
+	}
+
+	result := webhook.Deliver(func() (int, error) { return d.send(req) }, d.maxAttempts, d.baseDelay)
+	delivery := domain.WebhookDelivery{
+		ID:          payload.ID,
+		WebhookID:   wh.ID,
+		Event:       payload.Event,
+		Status:      domain.WebhookDeliverySucceeded,
+		StatusCode:  result.StatusCode,
+		Attempts:    result.Attempts,
+		CreatedAt:   payload.CreatedAt,
+		CompletedAt: time.Now(),
+	}
+	if result.Err != nil {
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.Error = result.Err.Error()
+		log.Printf("Webhookの配信に失敗: webhookID=%s, deliveryID=%s, attempts=%d, error=%v", wh.ID, payload.ID, result.Attempts, result.Err)
+	}
+
+	if err := firebase.RecordWebhookDelivery(delivery); err != nil {
+		log.Printf("Webhookの配信履歴の保存に失敗: webhookID=%s, deliveryID=%s, error=%v", wh.ID, payload.ID, err)
+	}
+}
+
+// 同時に送信する数を制限して1回送信する（再試行の待ち時間は制限に含めない）
+func (d *WebhookDispatcher) send(req webhook.Request) (int, error) {
+	d.sem <- struct{}{}
+	defer func() { <-d.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	return d.sender.Send(ctx, req)
+}
+
+// 保存したメッセージをWebhookに通知する送信処理
+type webhookProcessor struct{}
+
+func (webhookProcessor) Name() string { return ProcessorWebhook }
+
+func (webhookProcessor) BeforeSave(msg *OutgoingMessage) error { return nil }
+
+func (webhookProcessor) AfterSave(msg *OutgoingMessage) {
+	go NotifyWebhooks(domain.WebhookMessageCreated, msg.ChatID, WebhookMessage{
+		ID:         msg.ID,
+		SenderID:   msg.SenderID,
+		SenderName: msg.SenderName,
+		Content:    msg.Content,
+		Type:       msg.Type,
+		CreatedAt:  msg.CreatedAt,
+	})
+}
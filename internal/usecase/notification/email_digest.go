@@ -0,0 +1,240 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"path/filepath"
+	texttemplate "text/template"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/mail"
+)
+
+// 初めて通知するユーザーについて遡るメッセージの期間（有効にする前の未読を大量に送らない）
+const emailDigestLookback = 24 * time.Hour
+
+// メールの送信処理（テストではSMTPサーバーの代わりを差し替えられるようにする）
+type MailSender interface {
+	Send(msg mail.Message) error
+}
+
+// 未読メッセージのメールに必要なデータの取得と保存
+type EmailDigestStore interface {
+	// メールを受け取るユーザーを取得する
+	GetEmailDigestUsers() ([]domain.User, error)
+	// ユーザーが参加しているチャットを取得する
+	GetAllChats(userID string) ([]map[string]interface{}, error)
+	// afterより後、before以前に相手から届いた未読メッセージを古い順に取得する
+	GetUnreadMessagesBetween(chatID string, userID string, after time.Time, before time.Time) ([]map[string]interface{}, error)
+	// ユーザーの名前を取得する
+	GetUserName(userID string) (string, error)
+	// 最後に通知したメッセージの日時を記録する
+	UpdateEmailDigestAt(userID string, at time.Time) error
+}
+
+// 未読のまま一定時間が過ぎたメッセージをメールで通知する
+type EmailDigestSender struct {
+	store    EmailDigestStore // データの取得と保存
+	mailer   MailSender       // メールの送信処理
+	delay    time.Duration    // 未読のメッセージを通知するまでの時間
+	interval time.Duration    // 未読のメッセージを確認する間隔
+	baseURL  string           // メールに記載するアプリのURL
+
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// 未読メッセージのメールの送信処理を生成する
+// templateDirにはdigest.htmlとdigest.txtを置く
+func NewEmailDigestSender(store EmailDigestStore, mailer MailSender, templateDir string, delay time.Duration, interval time.Duration, baseURL string) (*EmailDigestSender, error) {
+	funcs := map[string]interface{}{
+		"formatTime": func(t time.Time) string { return t.Format("1月2日 15:04") },
+	}
+	html, err := htmltemplate.New("digest.html").Funcs(funcs).ParseFiles(filepath.Join(templateDir, "digest.html"))
+	if err != nil {
+		return nil, err
+	}
+	text, err := texttemplate.New("digest.txt").Funcs(funcs).ParseFiles(filepath.Join(templateDir, "digest.txt"))
+	if err != nil {
+		return nil, err
+	}
+	return &EmailDigestSender{
+		store:    store,
+		mailer:   mailer,
+		delay:    delay,
+		interval: interval,
+		baseURL:  baseURL,
+		html:     html,
+		text:     text,
+	}, nil
+}
+
+// Start ctxがキャンセルされるまで、未読のメッセージを定期的に確認してメールを送信する
+func (s *EmailDigestSender) Start(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("未読メッセージのメール通知を停止します")
+			return
+		case <-ticker.C:
+			s.SendAll(time.Now())
+		}
+	}
+}
+
+// SendAll メールを受け取る全ユーザーに未読のメッセージを通知する
+func (s *EmailDigestSender) SendAll(now time.Time) {
+	users, err := s.store.GetEmailDigestUsers()
+	if err != nil {
+		log.Printf("メール通知を受け取るユーザーの取得に失敗: %v", err)
+		return
+	}
+	for _, user := range users {
+		if user.IsBot || user.IsSuspended || user.Email == "" {
+			continue
+		}
+		// おやすみモードの間は送信せず、終了後にまとめて通知する
+		if user.NotificationsPausedAt(now) {
+			continue
+		}
+		if err := s.send(user, now); err != nil {
+			log.Printf("未読メッセージのメールの送信に失敗: userID=%s, error=%v", user.ID, err)
+		}
+	}
+}
+
+// ユーザーに未読のメッセージを通知する
+// 前回通知したメッセージより後で、送信からdelay以上経っても未読のメッセージが対象
+func (s *EmailDigestSender) send(user domain.User, now time.Time) error {
+	before := now.Add(-s.delay)
+	after := user.EmailDigestAt
+	if earliest := before.Add(-emailDigestLookback); after.Before(earliest) {
+		after = earliest
+	}
+
+	digest, err := s.buildDigest(user, after, before)
+	if err != nil {
+		return err
+	}
+	if digest.Total == 0 {
+		return nil
+	}
+
+	msg, err := s.render(user, digest)
+	if err != nil {
+		return err
+	}
+	if err := s.mailer.Send(msg); err != nil {
+		return err
+	}
+
+	// 同じメッセージを再び通知しないように、通知した期間の終わりを記録する
+	return s.store.UpdateEmailDigestAt(user.ID, before)
+}
+
+// ユーザーの未読メッセージをチャットごとにまとめる
+// ミュートしているチャットは対象にしない
+func (s *EmailDigestSender) buildDigest(user domain.User, after time.Time, before time.Time) (*domain.EmailDigest, error) {
+	chats, err := s.store.GetAllChats(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := &domain.EmailDigest{
+		UserName: user.Name,
+		AppURL:   s.baseURL + "/",
+		Settings: s.baseURL + "/settings",
+	}
+	for _, chatData := range chats {
+		if IsChatMuted(chatData, user.ID) {
+			continue
+		}
+		chatID, _ := chatData["id"].(string)
+		messages, err := s.store.GetUnreadMessagesBetween(chatID, user.ID, after, before)
+		if err != nil {
+			return nil, err
+		}
+
+		chat := domain.EmailDigestChat{
+			URL: s.baseURL + "/chat?chat_id=" + url.QueryEscape(chatID),
+		}
+		for _, message := range messages {
+			if messageType, _ := message["type"].(string); messageType == string(domain.MessageTypeSystem) {
+				continue
+			}
+			chat.Unread++
+			senderName, _ := message["sender_name"].(string)
+			if chat.Name == "" {
+				chat.Name = senderName
+			}
+			createdAt, _ := message["created_at"].(time.Time)
+			chat.Messages = append(chat.Messages, domain.EmailDigestMessage{
+				SenderName: senderName,
+				Content:    digestContent(message),
+				CreatedAt:  createdAt,
+			})
+		}
+		if chat.Unread == 0 {
+			continue
+		}
+		if len(chat.Messages) > domain.EmailDigestMessagesPerChat {
+			chat.Messages = chat.Messages[len(chat.Messages)-domain.EmailDigestMessagesPerChat:]
+		}
+		if name := s.chatPartnerName(chatData, user.ID); name != "" {
+			chat.Name = name
+		}
+		digest.Chats = append(digest.Chats, chat)
+		digest.Total += chat.Unread
+	}
+	return digest, nil
+}
+
+// メールを組み立てる
+func (s *EmailDigestSender) render(user domain.User, digest *domain.EmailDigest) (mail.Message, error) {
+	var text, html bytes.Buffer
+	if err := s.text.Execute(&text, digest); err != nil {
+		return mail.Message{}, err
+	}
+	if err := s.html.Execute(&html, digest); err != nil {
+		return mail.Message{}, err
+	}
+	return mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("未読のメッセージが%d件あります", digest.Total),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// チャットの相手の名前
+func (s *EmailDigestSender) chatPartnerName(chatData map[string]interface{}, userID string) string {
+	participants, _ := chatData["participants"].([]interface{})
+	for _, p := range participants {
+		id, _ := p.(string)
+		if id == "" || id == userID {
+			continue
+		}
+		name, err := s.store.GetUserName(id)
+		if err != nil {
+			return ""
+		}
+		return name
+	}
+	return ""
+}
+
+// メールに載せる本文
+func digestContent(message map[string]interface{}) string {
+	content, _ := message["content"].(string)
+	messageType, _ := message["type"].(string)
+	mediaURL, _ := message["media_url"].(string)
+	return PreviewContent(content, domain.MessageType(messageType), mediaURL != "", domain.EmailDigestContentLength)
+}
@@ -0,0 +1,283 @@
+package notification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/mail"
+)
+
+const testTemplateDir = "../../web/templates/email"
+
+// メモリ上に保存した未読メッセージのメールのデータ
+type fakeDigestStore struct {
+	users    []*domain.User
+	chats    map[string][]map[string]interface{} // ユーザーIDごとのチャット
+	messages map[string][]map[string]interface{} // チャットIDごとのメッセージ
+	names    map[string]string
+	updates  int // EmailDigestAtを更新した回数
+}
+
+func (s *fakeDigestStore) GetEmailDigestUsers() ([]domain.User, error) {
+	var users []domain.User
+	for _, u := range s.users {
+		if u.EmailDigest {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (s *fakeDigestStore) GetAllChats(userID string) ([]map[string]interface{}, error) {
+	return s.chats[userID], nil
+}
+
+func (s *fakeDigestStore) GetUnreadMessagesBetween(chatID string, userID string, after time.Time, before time.Time) ([]map[string]interface{}, error) {
+	var messages []map[string]interface{}
+	for _, m := range s.messages[chatID] {
+		createdAt := m["created_at"].(time.Time)
+		if m["sender_id"] == userID || m["is_read"] == true || !createdAt.After(after) || createdAt.After(before) {
+			continue
+		}
+		messages = append(messages, m)
+	}
+	return messages, nil
+}
+
+func (s *fakeDigestStore) GetUserName(userID string) (string, error) {
+	return s.names[userID], nil
+}
+
+func (s *fakeDigestStore) UpdateEmailDigestAt(userID string, at time.Time) error {
+	for _, u := range s.users {
+		if u.ID == userID {
+			u.EmailDigestAt = at
+			s.updates++
+		}
+	}
+	return nil
+}
+
+// 送信したメールを記録するSMTPサーバーの代わり
+type fakeMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (m *fakeMailer) Send(msg mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func message(senderID string, senderName string, content string, messageType domain.MessageType, createdAt time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"sender_id":   senderID,
+		"sender_name": senderName,
+		"content":     content,
+		"type":        string(messageType),
+		"created_at":  createdAt,
+		"is_read":     false,
+	}
+}
+
+func chat(id string, participants []interface{}, mutedBy ...string) map[string]interface{} {
+	settings := map[string]interface{}{}
+	for _, userID := range mutedBy {
+		settings[userID] = map[string]interface{}{"muted": true}
+	}
+	return map[string]interface{}{"id": id, "participants": participants, "participant_settings": settings}
+}
+
+const testDelay = 15 * time.Minute
+
+var testNow = time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+
+// alice宛てにbobとのチャットとミュートしたcarolとのチャットで未読のメッセージがある状態
+func newTestDigest(t *testing.T) (*EmailDigestSender, *fakeDigestStore, *fakeMailer) {
+	t.Helper()
+	sent := testNow.Add(-time.Hour)
+	store := &fakeDigestStore{
+		users: []*domain.User{
+			{ID: "alice", Name: "Alice", Email: "alice@example.com", EmailDigest: true},
+		},
+		chats: map[string][]map[string]interface{}{
+			"alice": {
+				chat("chat-bob", []interface{}{"alice", "bob"}),
+				chat("chat-carol", []interface{}{"alice", "carol"}, "alice"),
+			},
+		},
+		messages: map[string][]map[string]interface{}{
+			"chat-bob": {
+				message("bob", "Bob", "こんにちは <b>太字</b>", domain.MessageTypeText, sent),
+				message("system", "システム", "Bobがメッセージをピン留めしました", domain.MessageTypeSystem, sent.Add(time.Minute)),
+				message("bob", "Bob", "まだ通知しない", domain.MessageTypeText, testNow.Add(-time.Minute)),
+			},
+			"chat-carol": {
+				message("carol", "Carol", "Carolからのお知らせ", domain.MessageTypeText, sent),
+			},
+		},
+		names: map[string]string{"bob": "Bob", "carol": "Carol"},
+	}
+	mailer := &fakeMailer{}
+	sender, err := NewEmailDigestSender(store, mailer, testTemplateDir, testDelay, time.Minute, "https://chat.example.com")
+	if err != nil {
+		t.Fatalf("NewEmailDigestSender() error = %v", err)
+	}
+	return sender, store, mailer
+}
+
+func TestEmailDigestSend(t *testing.T) {
+	sender, store, mailer := newTestDigest(t)
+
+	sender.SendAll(testNow)
+
+	if len(mailer.sent) != 1 {
+		t.Fatalf("sent %d mails, want 1", len(mailer.sent))
+	}
+	msg := mailer.sent[0]
+	if msg.To != "alice@example.com" {
+		t.Errorf("To = %q, want %q", msg.To, "alice@example.com")
+	}
+	// システムメッセージとミュートしたチャット、delayが過ぎていないメッセージは数えない
+	if want := "未読のメッセージが1件あります"; msg.Subject != want {
+		t.Errorf("Subject = %q, want %q", msg.Subject, want)
+	}
+	for _, body := range []string{msg.Text, msg.HTML} {
+		if !strings.Contains(body, "Bob") || !strings.Contains(body, "chat_id=chat-bob") {
+			t.Errorf("チャットの相手とURLが含まれていません: %q", body)
+		}
+		for _, s := range []string{"ピン留め", "Carol", "まだ通知しない"} {
+			if strings.Contains(body, s) {
+				t.Errorf("通知しないメッセージ %q が含まれています: %q", s, body)
+			}
+		}
+	}
+	if !strings.Contains(msg.Text, "こんにちは <b>太字</b>") {
+		t.Errorf("テキストの本文にメッセージが含まれていません: %q", msg.Text)
+	}
+	if strings.Contains(msg.HTML, "<b>太字</b>") || !strings.Contains(msg.HTML, "&lt;b&gt;") {
+		t.Errorf("HTMLの本文でメッセージがエスケープされていません: %q", msg.HTML)
+	}
+
+	if want := testNow.Add(-testDelay); !store.users[0].EmailDigestAt.Equal(want) {
+		t.Errorf("EmailDigestAt = %v, want %v", store.users[0].EmailDigestAt, want)
+	}
+
+	// 通知したメッセージは再び送信しない
+	sender.SendAll(testNow.Add(time.Minute))
+	if len(mailer.sent) != 1 {
+		t.Errorf("同じメッセージを再び通知しました: sent %d mails", len(mailer.sent))
+	}
+
+	// delayが過ぎたメッセージは次の確認で通知する
+	sender.SendAll(testNow.Add(testDelay))
+	if len(mailer.sent) != 2 || !strings.Contains(mailer.sent[1].Text, "まだ通知しない") {
+		t.Fatalf("delayが過ぎたメッセージが通知されていません: %+v", mailer.sent[1:])
+	}
+	if strings.Contains(mailer.sent[1].Text, "こんにちは") {
+		t.Errorf("通知済みのメッセージが含まれています: %q", mailer.sent[1].Text)
+	}
+}
+
+func TestEmailDigestSendFailure(t *testing.T) {
+	sender, store, mailer := newTestDigest(t)
+	mailer.err = errors.New("SMTPサーバーに接続できません")
+
+	sender.SendAll(testNow)
+
+	// 送信に失敗した場合は通知済みにしない
+	if store.updates != 0 || !store.users[0].EmailDigestAt.IsZero() {
+		t.Fatalf("送信に失敗したのにEmailDigestAtが更新されました: %v", store.users[0].EmailDigestAt)
+	}
+
+	// 次の確認で同じメッセージを送信する
+	mailer.err = nil
+	sender.SendAll(testNow.Add(time.Minute))
+	if len(mailer.sent) != 1 || !strings.Contains(mailer.sent[0].Text, "こんにちは") {
+		t.Fatalf("失敗したメールが再送されていません: %+v", mailer.sent)
+	}
+	if store.updates != 1 {
+		t.Errorf("updates = %d, want 1", store.updates)
+	}
+}
+
+func TestEmailDigestPaused(t *testing.T) {
+	tests := []struct {
+		name string
+		user func(u *domain.User)
+	}{
+		{"おやすみモード", func(u *domain.User) { u.Availability = domain.AvailabilityDND }},
+		{"おやすみモードの時間帯", func(u *domain.User) {
+			u.DNDSchedule = domain.DNDSchedule{Enabled: true, Start: "20:00", End: "23:00", TimeZone: "Asia/Tokyo"}
+		}},
+		{"ボット", func(u *domain.User) { u.IsBot = true }},
+		{"停止されたアカウント", func(u *domain.User) { u.IsSuspended = true }},
+		{"メールを受け取らない設定", func(u *domain.User) { u.EmailDigest = false }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, store, mailer := newTestDigest(t)
+			tt.user(store.users[0])
+
+			// 日本時間の21時
+			sender.SendAll(testNow)
+
+			if len(mailer.sent) != 0 || store.updates != 0 {
+				t.Errorf("sent %d mails, updates = %d, want none", len(mailer.sent), store.updates)
+			}
+		})
+	}
+
+	// おやすみモードが終わったら、停止中のメッセージをまとめて通知する
+	sender, store, mailer := newTestDigest(t)
+	store.users[0].Availability = domain.AvailabilityDND
+	sender.SendAll(testNow)
+	store.users[0].Availability = domain.AvailabilityOnline
+	sender.SendAll(testNow.Add(time.Hour))
+	if len(mailer.sent) != 1 || !strings.Contains(mailer.sent[0].Text, "こんにちは") || !strings.Contains(mailer.sent[0].Text, "まだ通知しない") {
+		t.Errorf("おやすみモードの間のメッセージが通知されていません: %+v", mailer.sent)
+	}
+}
+
+func TestEmailDigestNoUnread(t *testing.T) {
+	sender, store, mailer := newTestDigest(t)
+	for _, m := range store.messages["chat-bob"] {
+		m["is_read"] = true
+	}
+
+	sender.SendAll(testNow)
+
+	if len(mailer.sent) != 0 {
+		t.Errorf("未読のメッセージがないのにメールを送信しました: %+v", mailer.sent)
+	}
+}
+
+func TestPreviewContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		messageType domain.MessageType
+		hasMedia    bool
+		want        string
+	}{
+		{"テキスト", "こんにちは\n\n世界", domain.MessageTypeText, false, "こんにちは 世界"},
+		{"長いテキスト", strings.Repeat("あ", 12), domain.MessageTypeText, false, strings.Repeat("あ", 10) + "…"},
+		{"ファイル", "", domain.MessageTypeText, true, "（ファイル）"},
+		{"本文なし", " ", domain.MessageTypeText, false, "（本文なし）"},
+		{"投票", "昼食は？", domain.MessageTypePoll, false, "投票: 昼食は？"},
+		{"暗号化", "ciphertext", domain.MessageTypeEncrypted, false, "（暗号化されたメッセージ）"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PreviewContent(tt.content, tt.messageType, tt.hasMedia, 10); got != tt.want {
+				t.Errorf("PreviewContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
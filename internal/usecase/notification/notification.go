@@ -0,0 +1,38 @@
+package notification
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"security_chat_app/internal/domain"
+)
+
+// IsChatMuted ユーザーがチャットをミュートしているかどうか
+func IsChatMuted(chatData map[string]interface{}, userID string) bool {
+	settings, _ := chatData["participant_settings"].(map[string]interface{})
+	userSettings, _ := settings[userID].(map[string]interface{})
+	muted, _ := userSettings["muted"].(bool)
+	return muted
+}
+
+// PreviewContent 通知に載せる本文（長い場合は省略し、本文のないメッセージは種類を表示する）
+func PreviewContent(content string, messageType domain.MessageType, hasMedia bool, maxLength int) string {
+	// 暗号化されたメッセージの本文はサーバーでは読めない
+	if messageType == domain.MessageTypeEncrypted {
+		return "（暗号化されたメッセージ）"
+	}
+	content = strings.Join(strings.Fields(content), " ")
+	if content == "" {
+		if hasMedia {
+			return "（ファイル）"
+		}
+		return "（本文なし）"
+	}
+	if messageType == domain.MessageTypePoll {
+		content = "投票: " + content
+	}
+	if utf8.RuneCountInString(content) > maxLength {
+		content = string([]rune(content)[:maxLength]) + "…"
+	}
+	return content
+}
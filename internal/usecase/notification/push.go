@@ -0,0 +1,213 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+
+	"security_chat_app/internal/domain"
+)
+
+// プッシュサービスへの通知の送信処理（テストでは偽のプッシュサービスに差し替えられるようにする）
+// 購読が無効になっている場合はdomain.ErrPushSubscriptionExpiredを返す
+type PushService interface {
+	Send(ctx context.Context, sub domain.PushSubscription, payload []byte) error
+}
+
+// プッシュ通知に必要なデータの取得と保存
+type PushStore interface {
+	// チャットを取得する
+	GetChat(chatID string) (map[string]interface{}, error)
+	// 通知するユーザーを取得する
+	GetUser(userID string) (*domain.User, error)
+	// ユーザーの購読を取得する
+	GetPushSubscriptionsByUser(userID string) ([]domain.PushSubscription, error)
+	// 購読を削除する
+	DeletePushSubscription(subscriptionID string) error
+	// 有効期限が切れた購読を削除し、削除した件数を返す
+	DeleteExpiredPushSubscriptions(now time.Time) (int, error)
+	// 購読に最後に通知した日時を記録する
+	TouchPushSubscription(subscriptionID string, usedAt time.Time) error
+}
+
+// プッシュ通知で送信するJSON（Service Workerが通知を表示する）
+type PushPayload struct {
+	Type      domain.PushNotificationType `json:"type"`       // 通知の種類
+	Title     string                      `json:"title"`      // 通知のタイトル
+	Body      string                      `json:"body"`       // 通知の本文
+	URL       string                      `json:"url"`        // 通知をクリックしたときに開くページ
+	Tag       string                      `json:"tag"`        // 同じタグの通知は置き換えて表示する
+	ChatID    string                      `json:"chat_id"`    // チャットのID
+	MessageID string                      `json:"message_id"` // メッセージのID
+}
+
+// プッシュ通知で知らせる保存したメッセージ
+type PushMessage struct {
+	ID           string             // メッセージのID
+	ChatID       string             // チャットのID
+	SenderID     string             // 送信者のID
+	SenderName   string             // 送信者の名前
+	Content      string             // メッセージの内容
+	Type         domain.MessageType // メッセージの種類
+	HasMedia     bool               // ファイルが添付されているかどうか
+	Participants []string           // チャットの参加者のID
+	Mentions     []domain.Mention   // 本文中のメンション
+}
+
+// PushNotifier 新しいメッセージとメンションをプッシュ通知で送信する
+// 無効になった購読と有効期限が切れた購読は削除する
+type PushNotifier struct {
+	store   PushStore
+	service PushService
+	sem     chan struct{}  // 同時に送信する数の制限
+	wg      sync.WaitGroup // 送信中の通知
+}
+
+// プッシュ通知の送信処理を生成する
+func NewPushNotifier(store PushStore, service PushService, concurrency int) *PushNotifier {
+	return &PushNotifier{
+		store:   store,
+		service: service,
+		sem:     make(chan struct{}, concurrency),
+	}
+}
+
+// Start ctxがキャンセルされるまで、有効期限が切れた購読を定期的に削除する
+func (n *PushNotifier) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("プッシュ通知の購読の削除を停止します")
+			return
+		case <-ticker.C:
+			deleted, err := n.store.DeleteExpiredPushSubscriptions(time.Now())
+			if err != nil {
+				log.Printf("有効期限が切れたプッシュ通知の購読の削除に失敗: %v", err)
+			} else if deleted > 0 {
+				log.Printf("有効期限が切れたプッシュ通知の購読を削除しました: count=%d", deleted)
+			}
+		}
+	}
+}
+
+// 保存したメッセージを送信者以外の参加者に通知する
+// ミュートしているチャットのメッセージは、メンションされた場合のみ通知する
+func (n *PushNotifier) NotifyMessage(msg PushMessage) {
+	if msg.Type == domain.MessageTypeSystem {
+		return
+	}
+	chatData, err := n.store.GetChat(msg.ChatID)
+	if err != nil {
+		log.Printf("チャットの取得に失敗: chatID=%s, error=%v", msg.ChatID, err)
+		return
+	}
+
+	mentioned := make(map[string]bool)
+	for _, userID := range domain.MentionedUserIDs(msg.Mentions) {
+		mentioned[userID] = true
+	}
+	body := PreviewContent(msg.Content, msg.Type, msg.HasMedia, domain.PushBodyLength)
+
+	for _, userID := range msg.Participants {
+		if userID == msg.SenderID {
+			continue
+		}
+		payload := PushPayload{
+			Type:      domain.PushNotificationMessage,
+			Title:     msg.SenderName,
+			Body:      body,
+			URL:       "/chat?chat_id=" + url.QueryEscape(msg.ChatID),
+			Tag:       msg.ChatID,
+			ChatID:    msg.ChatID,
+			MessageID: msg.ID,
+		}
+		if mentioned[userID] {
+			payload.Type = domain.PushNotificationMention
+			payload.Title = fmt.Sprintf("%sさんがあなたをメンションしました", msg.SenderName)
+		} else if IsChatMuted(chatData, userID) {
+			continue
+		}
+		n.NotifyUser(userID, payload)
+	}
+}
+
+// ユーザーの全ての購読に通知する
+// 送信は別のゴルーチンで行うため、呼び出し元の処理は待たない
+// 通知を停止している（おやすみモードの）ユーザーには送信しない
+func (n *PushNotifier) NotifyUser(userID string, payload PushPayload) {
+	now := time.Now()
+	user, err := n.store.GetUser(userID)
+	if err != nil {
+		log.Printf("通知するユーザーの取得に失敗: userID=%s, error=%v", userID, err)
+		return
+	}
+	if user.NotificationsPausedAt(now) {
+		return
+	}
+
+	subs, err := n.store.GetPushSubscriptionsByUser(userID)
+	if err != nil {
+		log.Printf("プッシュ通知の購読の取得に失敗: userID=%s, error=%v", userID, err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("プッシュ通知の本文の作成に失敗: userID=%s, error=%v", userID, err)
+		return
+	}
+
+	for _, sub := range subs {
+		if sub.IsExpired(now) {
+			n.prune(sub)
+			continue
+		}
+		n.wg.Add(1)
+		go n.send(sub, body)
+	}
+}
+
+// 1つの購読に送信する（無効になった購読は削除する）
+func (n *PushNotifier) send(sub domain.PushSubscription, body []byte) {
+	defer n.wg.Done()
+	n.sem <- struct{}{}
+	defer func() { <-n.sem }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	err := n.service.Send(ctx, sub, body)
+	switch {
+	case errors.Is(err, domain.ErrPushSubscriptionExpired):
+		n.prune(sub)
+	case err != nil:
+		log.Printf("プッシュ通知の送信に失敗: subscriptionID=%s, userID=%s, error=%v", sub.ID, sub.UserID, err)
+	default:
+		if err := n.store.TouchPushSubscription(sub.ID, time.Now()); err != nil {
+			log.Printf("プッシュ通知の購読の更新に失敗: subscriptionID=%s, error=%v", sub.ID, err)
+		}
+	}
+}
+
+// Wait 送信中の通知が終わるまで待つ
+func (n *PushNotifier) Wait() {
+	n.wg.Wait()
+}
+
+// 無効になった購読を削除する
+func (n *PushNotifier) prune(sub domain.PushSubscription) {
+	if err := n.store.DeletePushSubscription(sub.ID); err != nil {
+		log.Printf("無効なプッシュ通知の購読の削除に失敗: subscriptionID=%s, error=%v", sub.ID, err)
+		return
+	}
+	log.Printf("無効なプッシュ通知の購読を削除しました: subscriptionID=%s, userID=%s", sub.ID, sub.UserID)
+}
@@ -0,0 +1,272 @@
+package notification
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"security_chat_app/internal/domain"
+	"security_chat_app/internal/infrastructure/webpush"
+)
+
+// メモリ上に保存したプッシュ通知のデータ
+type fakePushStore struct {
+	mu      sync.Mutex
+	chats   map[string]map[string]interface{}
+	users   map[string]*domain.User
+	subs    map[string][]domain.PushSubscription // ユーザーIDごとの購読
+	deleted []string
+	touched []string
+}
+
+func (s *fakePushStore) GetChat(chatID string) (map[string]interface{}, error) {
+	chat, ok := s.chats[chatID]
+	if !ok {
+		return nil, errors.New("チャットが見つかりません")
+	}
+	return chat, nil
+}
+
+func (s *fakePushStore) GetUser(userID string) (*domain.User, error) {
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, errors.New("ユーザーが見つかりません")
+	}
+	return user, nil
+}
+
+func (s *fakePushStore) GetPushSubscriptionsByUser(userID string) ([]domain.PushSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]domain.PushSubscription(nil), s.subs[userID]...), nil
+}
+
+func (s *fakePushStore) DeletePushSubscription(subscriptionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deleted = append(s.deleted, subscriptionID)
+	for userID, subs := range s.subs {
+		for i, sub := range subs {
+			if sub.ID == subscriptionID {
+				s.subs[userID] = append(subs[:i:i], subs[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (s *fakePushStore) DeleteExpiredPushSubscriptions(now time.Time) (int, error) {
+	return 0, nil
+}
+
+func (s *fakePushStore) TouchPushSubscription(subscriptionID string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.touched = append(s.touched, subscriptionID)
+	return nil
+}
+
+// 送信した通知を記録するプッシュサービスの代わり
+type recordingPushService struct {
+	mu   sync.Mutex
+	sent map[string]PushPayload // 購読のIDごとの通知
+}
+
+func (s *recordingPushService) Send(ctx context.Context, sub domain.PushSubscription, payload []byte) error {
+	var p PushPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent[sub.ID] = p
+	return nil
+}
+
+func (s *recordingPushService) subscriptionIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.sent {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// 参加者ごとに購読が1つあるチャット
+// carolとdaveはチャットをミュートし、erinはおやすみモード、frankはおやすみモードの時間帯
+func newTestPushStore() *fakePushStore {
+	store := &fakePushStore{
+		chats: map[string]map[string]interface{}{
+			"chat-1": chat("chat-1", nil, "carol", "dave"),
+		},
+		users: map[string]*domain.User{
+			"alice": {ID: "alice"},
+			"bob":   {ID: "bob"},
+			"carol": {ID: "carol"},
+			"dave":  {ID: "dave"},
+			"erin":  {ID: "erin", Availability: domain.AvailabilityDND},
+			"frank": {ID: "frank", DNDSchedule: domain.DNDSchedule{Enabled: true, Start: "00:00", End: "00:00", TimeZone: "UTC"}}, // 終日
+		},
+		subs: map[string][]domain.PushSubscription{},
+	}
+	for userID := range store.users {
+		store.subs[userID] = []domain.PushSubscription{{ID: "sub-" + userID, UserID: userID, Endpoint: "https://push.example.com/" + userID}}
+	}
+	return store
+}
+
+func testPushMessage() PushMessage {
+	return PushMessage{
+		ID:           "msg-1",
+		ChatID:       "chat-1",
+		SenderID:     "alice",
+		SenderName:   "Alice",
+		Content:      "@dave 明日の会議について",
+		Type:         domain.MessageTypeText,
+		Participants: []string{"alice", "bob", "carol", "dave", "erin", "frank"},
+		Mentions:     []domain.Mention{{UserID: "dave", Username: "dave", Start: 0, End: len("@dave")}},
+	}
+}
+
+func TestNotifyMessage(t *testing.T) {
+	store := newTestPushStore()
+	service := &recordingPushService{sent: map[string]PushPayload{}}
+	notifier := NewPushNotifier(store, service, 2)
+
+	notifier.NotifyMessage(testPushMessage())
+	notifier.Wait()
+
+	// 送信者・ミュートしたチャット（メンションを除く）・おやすみモードのユーザーには送信しない
+	if got, want := service.subscriptionIDs(), []string{"sub-bob", "sub-dave"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("通知した購読 = %v, want %v", got, want)
+	}
+
+	bob := service.sent["sub-bob"]
+	if bob.Type != domain.PushNotificationMessage || bob.Title != "Alice" || bob.Body != "@dave 明日の会議について" {
+		t.Errorf("bobへの通知 = %+v", bob)
+	}
+	if bob.URL != "/chat?chat_id=chat-1" || bob.Tag != "chat-1" || bob.MessageID != "msg-1" {
+		t.Errorf("bobへの通知 = %+v", bob)
+	}
+	if dave := service.sent["sub-dave"]; dave.Type != domain.PushNotificationMention || !strings.Contains(dave.Title, "メンション") {
+		t.Errorf("daveへの通知 = %+v, メンションの通知である必要があります", dave)
+	}
+	if len(store.touched) != 2 {
+		t.Errorf("touched = %v, want 2 subscriptions", store.touched)
+	}
+}
+
+func TestNotifyMessageSkipped(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  func(m *PushMessage)
+	}{
+		{"システムメッセージ", func(m *PushMessage) { m.Type = domain.MessageTypeSystem }},
+		{"存在しないチャット", func(m *PushMessage) { m.ChatID = "unknown" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &recordingPushService{sent: map[string]PushPayload{}}
+			notifier := NewPushNotifier(newTestPushStore(), service, 2)
+			msg := testPushMessage()
+			tt.msg(&msg)
+
+			notifier.NotifyMessage(msg)
+			notifier.Wait()
+
+			if ids := service.subscriptionIDs(); len(ids) != 0 {
+				t.Errorf("通知した購読 = %v, want none", ids)
+			}
+		})
+	}
+}
+
+// 偽のプッシュサービスに送信し、無効になった購読を削除することを確認する
+func TestNotifyUserPrunesSubscriptions(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]int{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received[r.URL.Path]++
+		mu.Unlock()
+		if r.Header.Get("Content-Encoding") != "aes128gcm" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+		case "/gone":
+			w.WriteHeader(http.StatusGone)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	publicKey, privateKey, _ := webpush.GenerateVAPIDKeys()
+	vapid, err := webpush.ParseVAPID(publicKey, privateKey, "mailto:admin@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _ := ecdh.P256().GenerateKey(rand.Reader)
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	subscription := func(id string, path string, expiresAt time.Time) domain.PushSubscription {
+		return domain.PushSubscription{
+			ID:        id,
+			UserID:    "bob",
+			Endpoint:  server.URL + path,
+			P256dh:    base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			Auth:      base64.RawURLEncoding.EncodeToString(auth),
+			ExpiresAt: expiresAt,
+		}
+	}
+
+	store := newTestPushStore()
+	store.subs["bob"] = []domain.PushSubscription{
+		subscription("ok", "/ok", time.Time{}),
+		subscription("not-found", "/not-found", time.Time{}),
+		subscription("gone", "/gone", time.Time{}),
+		subscription("error", "/error", time.Time{}),
+		subscription("expired", "/expired", time.Now().Add(-time.Minute)),
+	}
+	notifier := NewPushNotifier(store, webpush.NewClient(vapid, server.Client()), 2)
+
+	notifier.NotifyUser("bob", PushPayload{Type: domain.PushNotificationMessage, Title: "Alice", Body: "こんにちは"})
+	notifier.Wait()
+
+	sort.Strings(store.deleted)
+	if got, want := strings.Join(store.deleted, ","), "expired,gone,not-found"; got != want {
+		t.Errorf("削除した購読 = %s, want %s", got, want)
+	}
+	if got := strings.Join(store.touched, ","); got != "ok" {
+		t.Errorf("更新した購読 = %s, want ok", got)
+	}
+	// 有効期限が切れた購読には送信しない
+	if received["/expired"] != 0 || received["/ok"] != 1 || received["/error"] != 1 {
+		t.Errorf("received = %v", received)
+	}
+
+	// 無効になった購読には再び送信しない
+	notifier.NotifyUser("bob", PushPayload{Type: domain.PushNotificationMessage, Title: "Alice", Body: "2通目"})
+	notifier.Wait()
+	if received["/gone"] != 1 || received["/not-found"] != 1 || received["/ok"] != 2 {
+		t.Errorf("received = %v", received)
+	}
+}
@@ -0,0 +1,17 @@
+// 取り消せない操作の前に確認する
+document.addEventListener("click", function (e) {
+  const button = e.target.closest(".js-moderationConfirm");
+  if (!button) {
+    return;
+  }
+  if (!confirm(button.dataset.confirm)) {
+    e.preventDefault();
+  }
+});
+
+// 一度しか表示されないURLやトークンは、フォーカスしたときに全体を選択する
+document.addEventListener("focusin", function (e) {
+  if (e.target.matches(".js-botToken")) {
+    e.target.select();
+  }
+});
@@ -16,6 +16,56 @@ document.addEventListener("DOMContentLoaded", function () {
     adjustTextareaHeight(this);
   });
 
+  // 下書きの自動保存（入力が止まってから1秒後に保存する）
+  let draftTimer = null;
+  let draftRequest = Promise.resolve();
+  let savedDraft = messageInput.value;
+
+  function draftFormData() {
+    const formData = new FormData();
+    formData.append("chatID", messageForm.elements.chatID.value);
+    formData.append("content", messageInput.value);
+    return formData;
+  }
+
+  function saveDraft() {
+    draftTimer = null;
+    const content = messageInput.value;
+    const formData = draftFormData();
+    // 保存の順序が入れ替わらないように、前の保存が終わってから送信する
+    draftRequest = draftRequest
+      .then(() => fetch("/chat/draft", { method: "POST", body: formData }))
+      .then((response) => {
+        if (!response.ok) {
+          throw new Error("下書きの保存に失敗しました");
+        }
+        savedDraft = content;
+      })
+      .catch((error) => console.error("Error:", error));
+  }
+
+  messageInput.addEventListener("input", function () {
+    clearTimeout(draftTimer);
+    draftTimer = null;
+    // 暗号化されたチャットでは下書きをサーバーに保存しない
+    if (messageForm.dataset.e2ee) {
+      return;
+    }
+    if (messageInput.value !== savedDraft) {
+      draftTimer = setTimeout(saveDraft, 1000);
+    }
+  });
+
+  // 保存前にページを離れる場合（別のチャットを開くなど）はその時点の内容を送信する
+  window.addEventListener("pagehide", function () {
+    if (draftTimer === null) {
+      return;
+    }
+    clearTimeout(draftTimer);
+    draftTimer = null;
+    navigator.sendBeacon("/chat/draft", draftFormData());
+  });
+
   // Ctrl + Enter で送信
   messageInput.addEventListener("keydown", function (e) {
     if (e.key === "Enter" && e.ctrlKey) {
@@ -24,6 +74,13 @@ document.addEventListener("DOMContentLoaded", function () {
     }
   });
 
+  // 入力欄をクリア（下書きは送信時にサーバーで削除される）
+  function clearMessageInput() {
+    messageInput.value = "";
+    savedDraft = "";
+    adjustTextareaHeight(messageInput);
+  }
+
   messageForm.addEventListener("submit", async function (e) {
     e.preventDefault();
 
@@ -31,44 +88,110 @@ document.addEventListener("DOMContentLoaded", function () {
       return;
     }
 
-    const formData = new FormData(messageForm);
+    const content = messageInput.value;
+    let formData = new FormData(messageForm);
     sendButton.disabled = true;
     buttonText.textContent = "送信中";
 
+    // 送信後に下書きが保存し直されないように、保存待ちと保存中の下書きを片付ける
+    clearTimeout(draftTimer);
+    draftTimer = null;
+    await draftRequest;
+
     try {
+      // 暗号化されたチャットでは本文を端末で暗号化し、暗号文だけを送信する
+      if (messageForm.dataset.e2ee) {
+        formData = await E2EE.encryptMessage(
+          messageForm.elements.chatID.value,
+          messageForm.dataset.userId,
+          messageForm.dataset.peerId,
+          content
+        );
+      }
+
       const response = await fetch("/chat", {
         method: "POST",
         body: formData,
       });
 
       if (!response.ok) {
-        throw new Error("メッセージの送信に失敗しました");
+        throw new Error(
+          response.status === 400 || response.status === 403 || response.status === 409
+            ? await response.text()
+            : "メッセージの送信に失敗しました"
+        );
       }
 
       const data = await response.json();
 
-      // メッセージ要素を作成
+      // コマンドの結果は自分だけに表示する（メッセージとしては保存されない）
+      if (data.handled) {
+        if (data.reply) {
+          const replyDiv = document.createElement("div");
+          replyDiv.className = "l-chatMain__message p-message --system --ephemeral";
+          replyDiv.innerHTML = `
+            <p class="p-message__system c-txt">${escapeHtml(data.reply)}</p>
+            <span class="p-message__ephemeral">あなただけに表示されています</span>
+          `;
+          messageArea.appendChild(replyDiv);
+          messageArea.scrollTop = messageArea.scrollHeight;
+        }
+        clearMessageInput();
+        return;
+      }
+
+      // 投票は選択肢を表示するため再読み込み
+      if (data.type === "poll") {
+        clearMessageInput();
+        window.location.reload();
+        return;
+      }
+
+      // メッセージ要素を作成（暗号化したメッセージは送信した本文をそのまま表示する）
+      const encrypted = data.type === "encrypted";
       const messageDiv = document.createElement("div");
       messageDiv.className = "l-chatMain__message p-message --sent";
+      messageDiv.id = `message-${data.id}`;
       messageDiv.innerHTML = `
         <div class="l-chatMain__content p-message__content">
-          <p class="p-message__text c-txt">${escapeHtml(data.content)}</p>
+          ${
+            encrypted
+              ? `<div class="p-message__text p-message__encrypted c-txt --decrypted">${escapeHtml(content)}</div>`
+              : `<div class="p-message__text c-txt">${data.html}</div>`
+          }
           <time class="p-message__time c-time">${data.created_at}</time>
+          ${
+            data.expires_at
+              ? `<span class="p-message__expires js-expiresAt" data-expires-at="${escapeHtml(data.expires_at)}"></span>`
+              : ""
+          }
+          <div class="p-message__actions">
+            ${
+              encrypted
+                ? ""
+                : `<button type="button" class="p-message__action js-pinButton" data-message-id="${escapeHtml(data.id)}">ピン留め</button>
+            <button type="button" class="p-message__action js-forwardButton" data-message-id="${escapeHtml(data.id)}">転送</button>`
+            }
+          </div>
         </div>
       `;
 
       // メッセージを追加
       messageArea.appendChild(messageDiv);
+      updateExpiresAt();
+
+      // URLのプレビューはバックグラウンドで取得されるため後から表示する
+      if (data.has_link) {
+        loadLinkPreview(messageForm.elements.chatID.value, data.id, messageDiv);
+      }
 
       // 最下部にスクロール
       messageArea.scrollTop = messageArea.scrollHeight;
 
-      // 入力欄をクリア
-      messageInput.value = "";
-      adjustTextareaHeight(messageInput);
+      clearMessageInput();
     } catch (error) {
       console.error("Error:", error);
-      alert("メッセージの送信に失敗しました");
+      alert(error.message || "メッセージの送信に失敗しました");
     } finally {
       sendButton.disabled = false;
       buttonText.textContent = "送信";
@@ -79,6 +202,594 @@ document.addEventListener("DOMContentLoaded", function () {
   messageInput.dispatchEvent(new Event("input"));
 });
 
+// ユーザーのブロック・ブロック解除
+document.addEventListener("click", async function (e) {
+  const button = e.target.closest(".js-blockButton");
+  if (!button) {
+    return;
+  }
+
+  const action = button.dataset.action;
+  if (
+    action === "block" &&
+    !confirm(
+      "このユーザーをブロックしますか？\nブロックしたユーザーはあなたにメッセージを送信できなくなります。"
+    )
+  ) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("userID", button.dataset.userId);
+
+  button.disabled = true;
+  try {
+    const response = await fetch(action === "block" ? "/block" : "/unblock", {
+      method: "POST",
+      body: formData,
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    window.location.reload();
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "ブロックの更新に失敗しました");
+    button.disabled = false;
+  }
+});
+
+// ピン留め・ピン留め解除
+document.addEventListener("click", async function (e) {
+  const button = e.target.closest(".js-pinButton, .js-unpinButton");
+  if (!button) {
+    return;
+  }
+
+  const messageForm = document.getElementById("messageForm");
+  if (!messageForm) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("chatID", messageForm.elements.chatID.value);
+  formData.append("messageID", button.dataset.messageId);
+  const endpoint = button.classList.contains("js-pinButton")
+    ? "/chat/pin"
+    : "/chat/unpin";
+
+  button.disabled = true;
+  try {
+    const response = await fetch(endpoint, {
+      method: "POST",
+      body: formData,
+    });
+
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+
+    // ピン留めバーとシステムメッセージを反映するため再読み込み
+    window.location.reload();
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "ピン留めの更新に失敗しました");
+    button.disabled = false;
+  }
+});
+
+// 投票（同じ選択肢をもう一度押すと取り消し）
+document.addEventListener("click", async function (e) {
+  const button = e.target.closest(".js-pollOption");
+  if (!button) {
+    return;
+  }
+
+  const messageForm = document.getElementById("messageForm");
+  const poll = button.closest(".js-poll");
+  if (!messageForm || !poll) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("chatID", messageForm.elements.chatID.value);
+  formData.append("messageID", poll.dataset.messageId);
+  formData.append("option", button.dataset.option);
+
+  poll.querySelectorAll(".js-pollOption").forEach((b) => (b.disabled = true));
+  try {
+    const response = await fetch("/chat/poll/vote", {
+      method: "POST",
+      body: formData,
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+
+    // 集計結果を反映する
+    const data = await response.json();
+    poll.querySelectorAll(".js-pollOption").forEach((b, i) => {
+      const option = data.options[i];
+      b.classList.toggle("--voted", i === data.voted);
+      b.querySelector(".js-pollCount").textContent = `${option.votes}票`;
+      b.querySelector(".js-pollBar").style.width = `${option.percent}%`;
+    });
+    poll.querySelector(".js-pollTotal").textContent = `${data.total}票`;
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "投票に失敗しました");
+  } finally {
+    poll.querySelectorAll(".js-pollOption").forEach((b) => (b.disabled = false));
+  }
+});
+
+// チャットへのボットの追加・削除
+document.addEventListener("click", async function (e) {
+  const button = e.target.closest(".js-addBotButton, .js-removeBotButton");
+  if (!button) {
+    return;
+  }
+
+  const messageForm = document.getElementById("messageForm");
+  if (!messageForm) {
+    return;
+  }
+
+  const adding = button.classList.contains("js-addBotButton");
+  const botID = adding
+    ? document.querySelector(".js-botSelect").value
+    : button.dataset.botId;
+  if (!botID) {
+    return;
+  }
+  if (!adding && !confirm("このボットをチャットから外しますか？")) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("chatID", messageForm.elements.chatID.value);
+  formData.append("botID", botID);
+
+  button.disabled = true;
+  try {
+    const response = await fetch(adding ? "/chat/bots/add" : "/chat/bots/remove", {
+      method: "POST",
+      body: formData,
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    window.location.reload();
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "ボットの更新に失敗しました");
+    button.disabled = false;
+  }
+});
+
+// チャット設定（ミュート・アーカイブ・非表示）の更新
+document.addEventListener("click", async function (e) {
+  const button = e.target.closest(".js-chatSettingsButton");
+  if (!button) {
+    return;
+  }
+
+  const messageForm = document.getElementById("messageForm");
+  if (!messageForm) {
+    return;
+  }
+
+  const action = button.dataset.action;
+  if (
+    action === "hide" &&
+    !confirm("このチャットを非表示にしますか？新しいメッセージが届くと再表示されます。")
+  ) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("chatID", messageForm.elements.chatID.value);
+  formData.append("action", action);
+
+  button.disabled = true;
+  try {
+    const response = await fetch("/chat/settings", {
+      method: "POST",
+      body: formData,
+    });
+
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+
+    // 非表示にした場合はチャット一覧に戻る
+    if (action === "hide") {
+      window.location.href = "/chat";
+      return;
+    }
+    window.location.reload();
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "チャット設定の更新に失敗しました");
+    button.disabled = false;
+  }
+});
+
+// エンドツーエンド暗号化
+document.addEventListener("DOMContentLoaded", async function () {
+  const messageForm = document.getElementById("messageForm");
+  const messageArea = document.getElementById("js-messageArea");
+  if (!messageForm || !window.crypto || !window.crypto.subtle || !window.indexedDB) {
+    return;
+  }
+  const chatID = messageForm.elements.chatID.value;
+  const userID = messageForm.dataset.userId;
+
+  // 暗号化を有効にする
+  const enableButton = document.querySelector(".js-enableE2EEButton");
+  if (enableButton) {
+    enableButton.addEventListener("click", async function () {
+      if (
+        !confirm(
+          "このチャットのエンドツーエンド暗号化を有効にしますか？有効にした後は無効にできず、メッセージは鍵を登録した端末でのみ読むことができます。"
+        )
+      ) {
+        return;
+      }
+      enableButton.disabled = true;
+      try {
+        if (!(await E2EE.ensureIdentityKey(userID))) {
+          throw new Error("この端末の鍵が登録されていません。別の端末で有効にしてください");
+        }
+        const formData = new FormData();
+        formData.append("chatID", chatID);
+        const response = await fetch("/chat/e2ee", { method: "POST", body: formData });
+        if (!response.ok) {
+          throw new Error(await response.text());
+        }
+        window.location.reload();
+      } catch (error) {
+        console.error("Error:", error);
+        alert(error.message || "暗号化を有効にできませんでした");
+        enableButton.disabled = false;
+      }
+    });
+  }
+
+  if (!messageForm.dataset.e2ee) {
+    // 相手が暗号化を有効にできるように、鍵が未登録の場合は登録しておく
+    E2EE.ensureIdentityKey(userID).catch((error) => console.error("Error:", error));
+    return;
+  }
+
+  // この端末に鍵が無い場合（別の端末で登録した鍵が現在の鍵の場合）は、この端末の鍵に切り替えられるようにする
+  try {
+    if (!(await E2EE.ensureIdentityKey(userID))) {
+      const notice = document.createElement("div");
+      notice.className = "p-e2eeNotice";
+      notice.innerHTML = `
+        <p class="p-e2eeNotice__text">この端末には暗号化の鍵がありません。この端末の鍵に切り替えると、別の端末では新しいメッセージを読めなくなります。</p>
+        <button type="button" class="p-e2eeNotice__btn c-btn --secondary">この端末で暗号化を使う</button>
+      `;
+      notice.querySelector("button").addEventListener("click", async function () {
+        this.disabled = true;
+        try {
+          await E2EE.useThisDevice(userID);
+          window.location.reload();
+        } catch (error) {
+          console.error("Error:", error);
+          alert(error.message || "鍵の登録に失敗しました");
+          this.disabled = false;
+        }
+      });
+      messageForm.before(notice);
+    }
+  } catch (error) {
+    console.error("Error:", error);
+  }
+
+  await E2EE.decryptAll(chatID, messageArea);
+});
+
+// メッセージの転送
+document.addEventListener("DOMContentLoaded", function () {
+  const dialog = document.getElementById("js-forwardDialog");
+  const forwardForm = document.getElementById("js-forwardForm");
+  const messageForm = document.getElementById("messageForm");
+  if (!dialog || !forwardForm || !messageForm) {
+    return;
+  }
+
+  // 転送ボタンで転送先の選択を開く
+  document.addEventListener("click", function (e) {
+    const button = e.target.closest(".js-forwardButton");
+    if (!button) {
+      return;
+    }
+    forwardForm.reset();
+    forwardForm.elements.messageID.value = button.dataset.messageId;
+    dialog.showModal();
+  });
+
+  dialog.querySelector(".js-forwardCancel").addEventListener("click", function () {
+    dialog.close();
+  });
+
+  forwardForm.addEventListener("submit", async function (e) {
+    e.preventDefault();
+
+    const formData = new FormData(forwardForm);
+    if (formData.getAll("targetChatID").length === 0) {
+      alert("転送先のチャットを選択してください");
+      return;
+    }
+    formData.append("chatID", messageForm.elements.chatID.value);
+
+    const submitButton = forwardForm.querySelector("button[type='submit']");
+    submitButton.disabled = true;
+    try {
+      const response = await fetch("/chat/forward", {
+        method: "POST",
+        body: formData,
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+
+      const data = await response.json();
+      if (data.failed && data.failed.length > 0) {
+        alert(`${data.failed.length}件のチャットへの転送に失敗しました`);
+      } else {
+        alert("メッセージを転送しました");
+      }
+      dialog.close();
+    } catch (error) {
+      console.error("Error:", error);
+      alert(error.message || "メッセージの転送に失敗しました");
+    } finally {
+      submitButton.disabled = false;
+    }
+  });
+});
+
+// メッセージ保持期間の変更
+document.addEventListener("change", async function (e) {
+  const select = e.target.closest(".js-retentionSelect");
+  if (!select) {
+    return;
+  }
+
+  const messageForm = document.getElementById("messageForm");
+  if (!messageForm) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("chatID", messageForm.elements.chatID.value);
+  formData.append("retention", select.value);
+
+  select.disabled = true;
+  try {
+    const response = await fetch("/chat/retention", {
+      method: "POST",
+      body: formData,
+    });
+
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+
+    // システムメッセージと削除予定時刻を反映するため再読み込み
+    window.location.reload();
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "保持期間の更新に失敗しました");
+    select.disabled = false;
+  }
+});
+
+// 自動削除までの残り時間を表示し、期限を過ぎたメッセージを画面から取り除く
+function updateExpiresAt() {
+  const now = Date.now();
+  document.querySelectorAll(".js-expiresAt").forEach((el) => {
+    const remaining = new Date(el.dataset.expiresAt).getTime() - now;
+    if (remaining <= 0) {
+      const message = el.closest(".p-message");
+      if (message) {
+        message.remove();
+      }
+      return;
+    }
+
+    const minutes = Math.ceil(remaining / 60000);
+    const days = Math.floor(minutes / (60 * 24));
+    const hours = Math.floor((minutes % (60 * 24)) / 60);
+    if (days > 0) {
+      el.textContent = `あと${days}日${hours}時間で削除`;
+    } else if (hours > 0) {
+      el.textContent = `あと${hours}時間${minutes % 60}分で削除`;
+    } else {
+      el.textContent = `あと${minutes}分で削除`;
+    }
+  });
+}
+
+document.addEventListener("DOMContentLoaded", function () {
+  updateExpiresAt();
+  setInterval(updateExpiresAt, 30000);
+});
+
+// 予約送信
+document.addEventListener("DOMContentLoaded", function () {
+  const messageForm = document.getElementById("messageForm");
+  const messageInput = document.getElementById("js-messageInput");
+  const scheduleInput = document.getElementById("js-scheduleInput");
+  const scheduleButton = document.getElementById("js-scheduleButton");
+  if (!messageForm || !scheduleInput || !scheduleButton) {
+    return;
+  }
+
+  // 予約日時の初期値を表示（サーバーのRFC3339形式をローカル時刻に変換）
+  document.querySelectorAll(".js-scheduleItem").forEach((item) => {
+    const sendAt = new Date(item.dataset.sendAt);
+    item.querySelector(".js-scheduleTime").textContent =
+      sendAt.toLocaleString("ja-JP", {
+        year: "numeric",
+        month: "2-digit",
+        day: "2-digit",
+        hour: "2-digit",
+        minute: "2-digit",
+      });
+    item.querySelector("input[name='send_at_local']").value =
+      toLocalInputValue(sendAt);
+  });
+
+  // 予約メッセージを作成
+  scheduleButton.addEventListener("click", async function () {
+    if (!messageInput.value.trim()) {
+      alert("メッセージを入力してください");
+      return;
+    }
+    if (!scheduleInput.value) {
+      alert("送信日時を指定してください");
+      return;
+    }
+
+    const formData = new FormData();
+    formData.append("chatID", messageForm.elements.chatID.value);
+    formData.append("content", messageInput.value);
+    formData.append("send_at", new Date(scheduleInput.value).toISOString());
+
+    scheduleButton.disabled = true;
+    try {
+      const response = await fetch("/chat/schedule", {
+        method: "POST",
+        body: formData,
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      // 予約した内容の下書きはサーバーで削除される
+      messageInput.value = "";
+      messageInput.dispatchEvent(new Event("input"));
+      window.location.reload();
+    } catch (error) {
+      console.error("Error:", error);
+      alert(error.message || "予約送信の登録に失敗しました");
+      scheduleButton.disabled = false;
+    }
+  });
+
+  // 予約メッセージの編集・取消
+  document.addEventListener("click", async function (e) {
+    const item = e.target.closest(".js-scheduleItem");
+    if (!item) {
+      return;
+    }
+
+    if (e.target.closest(".js-scheduleEdit")) {
+      const editForm = item.querySelector(".js-scheduleEditForm");
+      editForm.hidden = !editForm.hidden;
+      return;
+    }
+
+    if (e.target.closest(".js-scheduleCancel")) {
+      if (!confirm("この予約メッセージを取り消しますか？")) {
+        return;
+      }
+      const formData = new FormData();
+      formData.append("scheduleID", item.dataset.scheduleId);
+      await submitSchedule("/chat/schedule/cancel", formData);
+    }
+  });
+
+  document.querySelectorAll(".js-scheduleEditForm").forEach((editForm) => {
+    editForm.addEventListener("submit", async function (e) {
+      e.preventDefault();
+      const item = editForm.closest(".js-scheduleItem");
+      const formData = new FormData();
+      formData.append("scheduleID", item.dataset.scheduleId);
+      formData.append("content", editForm.elements.content.value);
+      formData.append(
+        "send_at",
+        new Date(editForm.elements.send_at_local.value).toISOString()
+      );
+      await submitSchedule("/chat/schedule/edit", formData);
+    });
+  });
+
+  // 予約メッセージの更新を送信
+  async function submitSchedule(endpoint, formData) {
+    try {
+      const response = await fetch(endpoint, {
+        method: "POST",
+        body: formData,
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      window.location.reload();
+    } catch (error) {
+      console.error("Error:", error);
+      alert(error.message || "予約メッセージの更新に失敗しました");
+    }
+  }
+
+  // Dateをdatetime-localの値（YYYY-MM-DDTHH:MM）に変換
+  function toLocalInputValue(date) {
+    const pad = (n) => String(n).padStart(2, "0");
+    return `${date.getFullYear()}-${pad(date.getMonth() + 1)}-${pad(
+      date.getDate()
+    )}T${pad(date.getHours())}:${pad(date.getMinutes())}`;
+  }
+});
+
+// 送信したメッセージのURLのプレビューを取得して表示する（取得できるまで数回再試行する）
+async function loadLinkPreview(chatID, messageID, messageDiv, attempt = 0) {
+  if (attempt >= 3) {
+    return;
+  }
+  await new Promise((resolve) => setTimeout(resolve, 2000));
+
+  try {
+    const params = new URLSearchParams({ chat_id: chatID, message_id: messageID });
+    const response = await fetch(`/chat/link-preview?${params}`);
+    if (response.status === 204) {
+      loadLinkPreview(chatID, messageID, messageDiv, attempt + 1);
+      return;
+    }
+    if (!response.ok) {
+      return;
+    }
+
+    const preview = await response.json();
+    const card = document.createElement("a");
+    card.className = "p-linkPreview";
+    card.href = preview.url;
+    card.target = "_blank";
+    card.rel = "noopener noreferrer nofollow";
+    card.innerHTML = `
+      ${
+        preview.image_url
+          ? `<img src="${escapeHtml(preview.image_url)}" alt="" class="p-linkPreview__image" loading="lazy" />`
+          : ""
+      }
+      <span class="p-linkPreview__body">
+        <span class="p-linkPreview__site">${escapeHtml(preview.site_name)}</span>
+        <span class="p-linkPreview__title">${escapeHtml(preview.title)}</span>
+        ${
+          preview.description
+            ? `<span class="p-linkPreview__description">${escapeHtml(preview.description)}</span>`
+            : ""
+        }
+      </span>
+    `;
+    messageDiv.querySelector(".p-message__text").after(card);
+  } catch (error) {
+    console.error("Error:", error);
+  }
+}
+
 // HTMLエスケープ
 function escapeHtml(unsafe) {
   return unsafe
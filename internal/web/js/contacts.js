@@ -0,0 +1,7 @@
+// 連絡先の削除の確認
+document.addEventListener("submit", function (e) {
+  const form = e.target.closest(".js-removeContactForm");
+  if (form && !confirm("この連絡先を削除しますか？\n相手の連絡先からも削除されます。")) {
+    e.preventDefault();
+  }
+});
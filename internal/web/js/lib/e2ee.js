@@ -0,0 +1,236 @@
+// エンドツーエンド暗号化
+// 秘密鍵は端末のIndexedDBに取り出せない形で保存し、サーバーには公開鍵だけを登録する
+// 暗号化の方式（v1）: 双方の鍵からECDH（P-256）で共有した値をHKDF-SHA256で鍵にし、AES-256-GCMで暗号化する
+const E2EE = (function () {
+  const VERSION = 1;
+  const DB_NAME = "security_chat_app_e2ee";
+  const STORE_NAME = "identity_keys";
+  const HKDF_INFO = new TextEncoder().encode("security_chat_app e2ee v1");
+  const NONCE_SIZE = 12;
+
+  // 取得した公開鍵（鍵のIDごと。鍵の内容は変わらないためページを開いている間は使い回す）
+  const publicKeys = new Map();
+
+  // 端末の鍵を保存するデータベースを開く
+  function openDatabase() {
+    return new Promise(function (resolve, reject) {
+      const request = indexedDB.open(DB_NAME, 1);
+      request.onupgradeneeded = function () {
+        const store = request.result.createObjectStore(STORE_NAME, { keyPath: "keyId" });
+        store.createIndex("userId", "userId");
+      };
+      request.onsuccess = () => resolve(request.result);
+      request.onerror = () => reject(request.error);
+    });
+  }
+
+  async function withStore(mode, fn) {
+    const db = await openDatabase();
+    try {
+      return await new Promise(function (resolve, reject) {
+        const tx = db.transaction(STORE_NAME, mode);
+        const request = fn(tx.objectStore(STORE_NAME));
+        tx.oncomplete = () => resolve(request.result);
+        tx.onerror = () => reject(tx.error);
+      });
+    } finally {
+      db.close();
+    }
+  }
+
+  // 端末に保存した鍵を取得する（無い場合はundefined）
+  function getLocalKey(keyId) {
+    return withStore("readonly", (store) => store.get(keyId));
+  }
+
+  function putLocalKey(record) {
+    return withStore("readwrite", (store) => store.put(record));
+  }
+
+  function encodeBase64URL(bytes) {
+    const binary = String.fromCharCode(...new Uint8Array(bytes));
+    return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+  }
+
+  function decodeBase64URL(value) {
+    const base64 = (value + "===".slice((value.length + 3) % 4)).replace(/-/g, "+").replace(/_/g, "/");
+    return Uint8Array.from(atob(base64), (c) => c.charCodeAt(0));
+  }
+
+  // 公開鍵から鍵のIDを計算する（SHA-256の先頭16バイトの16進数。サーバーと同じ計算）
+  async function keyIdOf(rawPublicKey) {
+    const digest = new Uint8Array(await crypto.subtle.digest("SHA-256", rawPublicKey));
+    return Array.from(digest.slice(0, 16), (b) => b.toString(16).padStart(2, "0")).join("");
+  }
+
+  // 公開鍵のディレクトリから鍵を取得する（登録されていない場合はnull）
+  async function fetchKey(params) {
+    const response = await fetch(`/keys?${new URLSearchParams(params)}`);
+    if (response.status === 404) {
+      return null;
+    }
+    if (!response.ok) {
+      throw new Error("公開鍵の取得に失敗しました");
+    }
+    const key = await response.json();
+    publicKeys.set(key.key_id, key);
+    return key;
+  }
+
+  async function fetchKeyByID(keyId) {
+    return publicKeys.get(keyId) || (await fetchKey({ key_id: keyId }));
+  }
+
+  // 新しい鍵を作成して端末に保存し、公開鍵を登録する
+  async function createIdentityKey(userId) {
+    // 秘密鍵は取り出せない形で作成する（公開鍵は常に取り出せる）
+    const pair = await crypto.subtle.generateKey({ name: "ECDH", namedCurve: "P-256" }, false, ["deriveBits"]);
+    const raw = await crypto.subtle.exportKey("raw", pair.publicKey);
+    const record = {
+      keyId: await keyIdOf(raw),
+      userId: userId,
+      publicKey: encodeBase64URL(raw),
+      privateKey: pair.privateKey,
+      createdAt: Date.now(),
+    };
+    await putLocalKey(record);
+    await publishKey(record);
+    return record;
+  }
+
+  async function publishKey(record) {
+    const response = await fetch("/keys", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ public_key: record.publicKey }),
+    });
+    if (!response.ok) {
+      throw new Error(response.status === 400 ? await response.text() : "公開鍵の登録に失敗しました");
+    }
+  }
+
+  // ログインユーザーの現在の鍵を取得する
+  // 鍵が未登録の場合は作成して登録し、別の端末で登録した鍵が現在の鍵の場合はnullを返す
+  async function ensureIdentityKey(userId) {
+    const current = await fetchKey({ user_id: userId });
+    if (!current) {
+      return createIdentityKey(userId);
+    }
+    return (await getLocalKey(current.key_id)) || null;
+  }
+
+  // この端末の鍵を作成して現在の鍵にする（別の端末で登録した鍵から切り替える）
+  function useThisDevice(userId) {
+    return createIdentityKey(userId);
+  }
+
+  // 自分の秘密鍵と相手の公開鍵からメッセージの鍵を導出する
+  async function deriveMessageKey(privateKey, peerPublicKey, chatId) {
+    const peerKey = await crypto.subtle.importKey(
+      "raw",
+      decodeBase64URL(peerPublicKey),
+      { name: "ECDH", namedCurve: "P-256" },
+      false,
+      []
+    );
+    const shared = await crypto.subtle.deriveBits({ name: "ECDH", public: peerKey }, privateKey, 256);
+    const hkdfKey = await crypto.subtle.importKey("raw", shared, "HKDF", false, ["deriveKey"]);
+    return crypto.subtle.deriveKey(
+      { name: "HKDF", hash: "SHA-256", salt: new TextEncoder().encode(chatId), info: HKDF_INFO },
+      hkdfKey,
+      { name: "AES-GCM", length: 256 },
+      false,
+      ["encrypt", "decrypt"]
+    );
+  }
+
+  // 暗号文を別のチャットや別の鍵の組み合わせで使い回せないように、チャットと鍵のIDを認証の対象にする
+  function additionalData(chatId, senderKeyId, recipientKeyId) {
+    return new TextEncoder().encode(`${chatId}|${senderKeyId}|${recipientKeyId}`);
+  }
+
+  // メッセージを暗号化し、送信するフォームのデータを作成する
+  async function encryptMessage(chatId, userId, peerId, plaintext) {
+    const own = await ensureIdentityKey(userId);
+    if (!own) {
+      throw new Error("この端末の鍵が登録されていません。「この端末で暗号化を使う」を選択してください");
+    }
+    const peer = await fetchKey({ user_id: peerId });
+    if (!peer) {
+      throw new Error("相手の公開鍵が見つかりません");
+    }
+
+    const key = await deriveMessageKey(own.privateKey, peer.public_key, chatId);
+    const nonce = crypto.getRandomValues(new Uint8Array(NONCE_SIZE));
+    const ciphertext = await crypto.subtle.encrypt(
+      { name: "AES-GCM", iv: nonce, additionalData: additionalData(chatId, own.keyId, peer.key_id) },
+      key,
+      new TextEncoder().encode(plaintext)
+    );
+
+    const formData = new FormData();
+    formData.append("chatID", chatId);
+    formData.append("type", "encrypted");
+    formData.append("version", VERSION);
+    formData.append("sender_key_id", own.keyId);
+    formData.append("recipient_key_id", peer.key_id);
+    formData.append("nonce", encodeBase64URL(nonce));
+    formData.append("ciphertext", encodeBase64URL(ciphertext));
+    return formData;
+  }
+
+  // 暗号化されたメッセージを復号する
+  // 自分が送信したメッセージも、送信時の自分の秘密鍵と相手の公開鍵から同じ鍵を導出して復号できる
+  async function decryptMessage(chatId, envelope) {
+    if (Number(envelope.version) !== VERSION) {
+      throw new Error("対応していない暗号化の方式です");
+    }
+    let own = await getLocalKey(envelope.recipientKeyId);
+    let peerKeyId = envelope.senderKeyId;
+    if (!own) {
+      own = await getLocalKey(envelope.senderKeyId);
+      peerKeyId = envelope.recipientKeyId;
+    }
+    if (!own) {
+      throw new Error("この端末では復号できません");
+    }
+    const peer = await fetchKeyByID(peerKeyId);
+    if (!peer) {
+      throw new Error("相手の公開鍵が見つかりません");
+    }
+
+    const key = await deriveMessageKey(own.privateKey, peer.public_key, chatId);
+    const plaintext = await crypto.subtle.decrypt(
+      {
+        name: "AES-GCM",
+        iv: decodeBase64URL(envelope.nonce),
+        additionalData: additionalData(chatId, envelope.senderKeyId, envelope.recipientKeyId),
+      },
+      key,
+      decodeBase64URL(envelope.ciphertext)
+    );
+    return new TextDecoder().decode(plaintext);
+  }
+
+  // 画面内の暗号化されたメッセージを復号して表示する（本文はテキストとして表示する）
+  async function decryptAll(chatId, root) {
+    const elements = root.querySelectorAll(".js-encryptedMessage:not(.--decrypted):not(.--failed)");
+    for (const element of elements) {
+      try {
+        element.textContent = await decryptMessage(chatId, element.dataset);
+        element.classList.add("--decrypted");
+      } catch (error) {
+        console.error("Error:", error);
+        element.textContent = error instanceof DOMException ? "メッセージを復号できませんでした" : error.message;
+        element.classList.add("--failed");
+      }
+    }
+  }
+
+  return {
+    ensureIdentityKey: ensureIdentityKey,
+    useThisDevice: useThisDevice,
+    encryptMessage: encryptMessage,
+    decryptAll: decryptAll,
+  };
+})();
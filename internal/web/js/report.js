@@ -0,0 +1,49 @@
+// メッセージ・ユーザーの通報
+document.addEventListener("DOMContentLoaded", function () {
+  const dialog = document.getElementById("js-reportDialog");
+  const reportForm = document.getElementById("js-reportForm");
+  if (!dialog || !reportForm) {
+    return;
+  }
+
+  // 通報ボタンで通報の入力を開く
+  document.addEventListener("click", function (e) {
+    const button = e.target.closest(".js-reportButton");
+    if (!button) {
+      return;
+    }
+    reportForm.reset();
+    reportForm.elements.target_type.value = button.dataset.targetType;
+    reportForm.elements.chatID.value = button.dataset.chatId || "";
+    reportForm.elements.messageID.value = button.dataset.messageId || "";
+    reportForm.elements.userID.value = button.dataset.userId || "";
+    dialog.showModal();
+  });
+
+  dialog.querySelector(".js-reportCancel").addEventListener("click", function () {
+    dialog.close();
+  });
+
+  reportForm.addEventListener("submit", async function (e) {
+    e.preventDefault();
+
+    const submitButton = reportForm.querySelector("button[type='submit']");
+    submitButton.disabled = true;
+    try {
+      const response = await fetch("/report", {
+        method: "POST",
+        body: new FormData(reportForm),
+      });
+      if (!response.ok) {
+        throw new Error(await response.text());
+      }
+      alert("通報しました。ご協力ありがとうございます");
+      dialog.close();
+    } catch (error) {
+      console.error("Error:", error);
+      alert(error.message || "通報に失敗しました");
+    } finally {
+      submitButton.disabled = false;
+    }
+  });
+});
@@ -56,3 +56,126 @@ function toggleUsernameForm() {
     window.location.href = "/settings?show_username_form=true";
   }
 }
+
+// ブロックの解除
+document.addEventListener("click", async function (e) {
+  const button = e.target.closest(".js-unblockButton");
+  if (!button) {
+    return;
+  }
+
+  const formData = new FormData();
+  formData.append("userID", button.dataset.userId);
+
+  button.disabled = true;
+  try {
+    const response = await fetch("/unblock", {
+      method: "POST",
+      body: formData,
+    });
+    if (!response.ok) {
+      throw new Error(await response.text());
+    }
+    window.location.reload();
+  } catch (error) {
+    console.error("Error:", error);
+    alert(error.message || "ブロックの解除に失敗しました");
+    button.disabled = false;
+  }
+});
+
+// ボットの削除の確認
+document.addEventListener("submit", function (e) {
+  const form = e.target.closest(".js-deleteBotForm");
+  if (
+    form &&
+    !confirm("このボットを削除しますか？\nAPIトークンは使えなくなり、参加しているチャットからも外れます。")
+  ) {
+    e.preventDefault();
+  }
+});
+
+// APIトークンはクリックで全体を選択する
+document.addEventListener("focusin", function (e) {
+  if (e.target.matches(".js-botToken")) {
+    e.target.select();
+  }
+});
+
+// プッシュ通知の購読
+document.addEventListener("DOMContentLoaded", async function () {
+  const container = document.querySelector(".js-pushNotification");
+  if (!container) {
+    return;
+  }
+  const button = container.querySelector(".js-pushToggle");
+  const label = button.querySelector(".c-btn__text");
+  const status = container.querySelector(".js-pushStatus");
+
+  if (!("serviceWorker" in navigator) || !("PushManager" in window)) {
+    status.textContent = "このブラウザはプッシュ通知に対応していません";
+    return;
+  }
+
+  const registration = await navigator.serviceWorker.register("/sw.js");
+  let subscription = await registration.pushManager.getSubscription();
+
+  const render = function () {
+    label.textContent = subscription ? "このブラウザの通知を停止" : "このブラウザで通知を受け取る";
+    button.disabled = false;
+  };
+  render();
+
+  button.addEventListener("click", async function () {
+    button.disabled = true;
+    try {
+      if (subscription) {
+        const endpoint = subscription.endpoint;
+        await subscription.unsubscribe();
+        subscription = null;
+        await postPushSubscription("/push/unsubscribe", { endpoint: endpoint });
+      } else {
+        if ((await Notification.requestPermission()) !== "granted") {
+          throw new Error("通知が許可されていません。ブラウザの設定を確認してください");
+        }
+        subscription = await registration.pushManager.subscribe({
+          userVisibleOnly: true,
+          applicationServerKey: decodeBase64URL(container.dataset.publicKey),
+        });
+        await postPushSubscription("/push/subscribe", subscription.toJSON());
+      }
+      window.location.reload();
+    } catch (error) {
+      console.error("Error:", error);
+      alert(error.message || "プッシュ通知の設定に失敗しました");
+      render();
+    }
+  });
+});
+
+// 購読情報をサーバーに送信する
+async function postPushSubscription(url, body) {
+  const response = await fetch(url, {
+    method: "POST",
+    headers: { "Content-Type": "application/json" },
+    body: JSON.stringify(body),
+  });
+  if (!response.ok) {
+    throw new Error(await response.text());
+  }
+}
+
+// Base64URLの文字列をバイト列に変換する（VAPIDの公開鍵）
+function decodeBase64URL(value) {
+  const base64 = (value + "===".slice((value.length + 3) % 4)).replace(/-/g, "+").replace(/_/g, "/");
+  const raw = atob(base64);
+  return Uint8Array.from(raw, (c) => c.charCodeAt(0));
+}
+
+// おやすみモードのタイムゾーンが未設定の場合はブラウザのタイムゾーンを入力する
+document.addEventListener("DOMContentLoaded", function () {
+  const input = document.querySelector(".js-timeZone");
+  if (input && !input.value) {
+    input.value = Intl.DateTimeFormat().resolvedOptions().timeZone || "Asia/Tokyo";
+  }
+});
@@ -0,0 +1,66 @@
+// Service Worker（/sw.jsで配信し、サイト全体を対象にする）
+
+// プッシュ通知の表示
+self.addEventListener("push", function (event) {
+  if (!event.data) {
+    return;
+  }
+
+  let payload;
+  try {
+    payload = event.data.json();
+  } catch (error) {
+    console.error("Error:", error);
+    return;
+  }
+
+  event.waitUntil(
+    self.registration.showNotification(payload.title || "新しいメッセージ", {
+      body: payload.body || "",
+      tag: payload.tag || undefined,
+      renotify: Boolean(payload.tag),
+      icon: "/images/favicon.ico",
+      data: { url: payload.url || "/" },
+    })
+  );
+});
+
+// 通知のクリックで該当のチャットを開く（既に開いている場合はそのタブを使う）
+self.addEventListener("notificationclick", function (event) {
+  event.notification.close();
+
+  // 同じオリジンのページのみ開く
+  const url = new URL(event.notification.data.url, self.location.origin);
+  if (url.origin !== self.location.origin) {
+    return;
+  }
+
+  event.waitUntil(
+    self.clients.matchAll({ type: "window", includeUncontrolled: true }).then(function (clients) {
+      for (const client of clients) {
+        if (client.url === url.href && "focus" in client) {
+          return client.focus();
+        }
+      }
+      return self.clients.openWindow(url.href);
+    })
+  );
+});
+
+// ブラウザが購読を更新した場合はサーバーに登録し直す
+self.addEventListener("pushsubscriptionchange", function (event) {
+  const options = event.oldSubscription && event.oldSubscription.options;
+  if (!options) {
+    return;
+  }
+
+  event.waitUntil(
+    self.registration.pushManager.subscribe(options).then(function (subscription) {
+      return fetch("/push/subscribe", {
+        method: "POST",
+        headers: { "Content-Type": "application/json" },
+        body: JSON.stringify(subscription.toJSON()),
+      });
+    })
+  );
+});